@@ -2,12 +2,26 @@ package laconic
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
+// ErrEmptyLLMResponse is returned when a model produces neither text nor
+// reasoning content. It usually points to a misconfigured or overloaded
+// backend rather than a legitimate empty answer.
+var ErrEmptyLLMResponse = errors.New("LLM returned an empty response")
+
+// ErrInvalidJSONAnswer is returned when a WithOutputSchema answer is still
+// not valid JSON after the repair call. Result.Answer holds the last output.
+var ErrInvalidJSONAnswer = errors.New("answer is not valid JSON")
+
 // Agent coordinates the planner, searcher, synthesizer, and finalizer.
+// It is safe for concurrent use; AnswerOptions apply only to their own call.
 type Agent struct {
 	searcher          SearchProvider
 	fetcher           FetchProvider
@@ -17,11 +31,46 @@ type Agent struct {
 	maxIterations     int
 	debug             bool
 	strategy          Strategy
+	strategyMu        sync.Mutex // guards lazy creation of strategy
 	strategyName      string
 	strategyFactories map[string]StrategyFactory
 	graphReaderConfig GraphReaderConfig
 	searchCost        float64
-	priorKnowledge    string // set per-call via AnswerOption
+
+	groundingMode        GroundingMode
+	returnPartialOnError bool
+	reformulateOnEmpty   bool
+	maxLLMCalls          int
+	answerValidation     bool
+	skipNonHTML          bool
+	progressEvery        int
+	progressHandler      func(ProvisionalAnswer)
+	maxAnswerWords       int
+	includeReasoning     bool
+	synthesizerFields    []string
+	titleDedup           float64
+	tokenizer            func(string) int
+	seed                 *int64
+	searchResultLimit    int
+	questionContext      bool
+	synthesizerHistory   bool
+	structuredSummary    bool
+	searchErrorHandler   func(query string, err error)
+	captureRawResponses  bool
+	tracer               Tracer
+	cacheSimilarity      float64
+	cacheSimilarityFunc  func(a, b string) float64
+	answers              answerCache
+	insufficientText     string
+	earlyExit            bool
+	maxCost              float64
+	streamHandler        func(chunk string)
+	logger               Logger
+	iterationHook        func(IterationEvent)
+	plannerPrompt        string
+	synthesizerPrompt    string
+	finalizerPrompt      string
+	withTimeout          func(context.Context, time.Duration) (context.Context, context.CancelFunc)
 }
 
 // New constructs an Agent with optional configuration.
@@ -32,7 +81,9 @@ func New(opts ...Option) *Agent {
 		strategyFactories: map[string]StrategyFactory{
 			"scratchpad":   newScratchpadStrategy,
 			"graph-reader": newGraphReaderStrategy,
+			"deep-read":    newDeepReadStrategy,
 		},
+		withTimeout: context.WithTimeout,
 	}
 	for _, opt := range opts {
 		opt(a)
@@ -40,9 +91,45 @@ func New(opts ...Option) *Agent {
 	if a.finalizer == nil {
 		a.finalizer = a.synthesizer
 	}
+	if a.cacheSimilarityFunc == nil {
+		a.cacheSimilarityFunc = QuestionSimilarity
+	}
+	if a.debug && a.logger == nil {
+		a.logger = stdoutLogger{}
+	}
 	return a
 }
 
+// NewAgent is like New but fails fast on a misconfigured agent: see
+// Validate.
+func NewAgent(opts ...Option) (*Agent, error) {
+	a := New(opts...)
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// configValidator is implemented by the built-in strategies to report
+// missing models before the first question.
+type configValidator interface {
+	validateConfig() error
+}
+
+// Validate builds the selected strategy and checks that the models it
+// needs are configured, returning the error Answer would otherwise only
+// report on the first question. Custom strategies are only built.
+func (a *Agent) Validate() error {
+	strategy, err := a.resolveStrategy()
+	if err != nil {
+		return err
+	}
+	if v, ok := strategy.(configValidator); ok {
+		return v.validateConfig()
+	}
+	return nil
+}
+
 // Answer runs the loop until an answer is produced or the limit is reached.
 // Optional AnswerOption values can supply prior knowledge for follow-up
 // questions (see WithKnowledge).
@@ -51,17 +138,194 @@ func (a *Agent) Answer(ctx context.Context, question string, opts ...AnswerOptio
 	for _, opt := range opts {
 		opt(&cfg)
 	}
-	a.priorKnowledge = cfg.priorKnowledge
-	defer func() { a.priorKnowledge = "" }()
+	strategy, err := a.resolveStrategy()
+	if err != nil {
+		return Result{StopReason: StopError}, err
+	}
+	return a.run(ctx, strategy, question, cfg)
+}
 
+// run answers question with strategy as one run with its own budget and
+// options, and applies the shared post-processing.
+func (a *Agent) run(ctx context.Context, strategy Strategy, question string, opts answerConfig) (Result, error) {
+	ctx, end := a.startSpan(ctx, spanAnswer)
+	// Per-call options can change the answer, so only plain calls share
+	// the answer cache.
+	cacheable := a.cacheSimilarity > 0 && opts == (answerConfig{})
+	if cacheable {
+		if res, ok := a.answers.lookup(question, a.cacheSimilarity, a.cacheSimilarityFunc); ok {
+			res.Cost, res.CostBreakdown, res.Timings, res.Cached = 0, nil, Timings{}, true
+			end(answerSpanAttrs(strategy, res, nil))
+			return res, nil
+		}
+	}
+	ctx = a.startRun(ctx, opts)
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = a.withTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	res, err := strategy.Answer(ctx, question)
+	err = a.finishAnswer(finishContext(ctx), question, &res, err)
+	if cacheable && err == nil {
+		a.answers.store(question, res)
+	}
+	end(answerSpanAttrs(strategy, res, err))
+	return res, err
+}
+
+// answerSpanAttrs describes a finished run for its laconic.answer span.
+func answerSpanAttrs(strategy Strategy, res Result, err error) map[string]any {
+	return withError(map[string]any{
+		"laconic.strategy":    strategy.Name(),
+		"laconic.cost":        res.Cost,
+		"laconic.stop_reason": string(res.StopReason),
+		"laconic.queries":     len(res.Queries),
+		"laconic.cached":      res.Cached,
+	}, err)
+}
+
+// AnswerFromText answers question from the supplied document text without
+// searching. The text goes through the same compression stage as search
+// results (the synthesizer for scratchpad, the fact extractor for
+// graph-reader) and is then finalized. No search provider is required.
+func (a *Agent) AnswerFromText(ctx context.Context, question, docText string) (Result, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return Result{StopReason: StopError}, errors.New("question is empty")
+	}
+	if strings.TrimSpace(docText) == "" {
+		return Result{StopReason: StopError}, errors.New("document text is empty")
+	}
 	strategy, err := a.resolveStrategy()
 	if err != nil {
-		return Result{}, err
+		return Result{StopReason: StopError}, err
 	}
-	return strategy.Answer(ctx, question)
+	ctx, end := a.startSpan(ctx, spanAnswer)
+	ctx = a.startRun(ctx, answerConfig{})
+	res, err := a.answerText(ctx, strategy, question, docText)
+	err = a.finishAnswer(ctx, question, &res, err)
+	end(answerSpanAttrs(strategy, res, err))
+	return res, err
+}
+
+// finishAnswer applies the post-processing shared by every strategy: a
+// default StopReason, WithIncludeReasoning, WithOutputSchema or the
+// WithMaxAnswerWords backstop, WithAnswerValidation, WithStructuredSummary,
+// WithCaptureRawResponses, the CostBreakdown and the Timings. It returns the
+// run's error, which is ErrInvalidJSONAnswer when a successful run's answer
+// does not match WithOutputSchema.
+func (a *Agent) finishAnswer(ctx context.Context, question string, res *Result, err error) error {
+	// Deferred so the validation and summary calls are included.
+	defer func() {
+		res.CostBreakdown = costBreakdown(ctx)
+		res.Timings = runTimings(ctx)
+		if a.captureRawResponses {
+			res.RawResponses = rawResponses(ctx)
+		}
+	}()
+	if res.StopReason == "" {
+		res.StopReason = stopReasonFor(err)
+	}
+	if a.includeReasoning && res.Reasoning == "" {
+		res.Reasoning = finalReasoning(ctx)
+	}
+	if res.Answer == "" {
+		return err
+	}
+	if schema := answerOptions(ctx).outputSchema; schema != "" {
+		if jerr := a.conformToSchema(ctx, schema, res); jerr != nil && err == nil {
+			err = jerr
+			res.StopReason = StopError
+		}
+	} else {
+		res.Answer = truncateAnswer(res.Answer, a.maxAnswerWords)
+	}
+	if a.answerValidation {
+		a.validateAnswer(ctx, question, res)
+	}
+	if a.structuredSummary {
+		a.summarizeAnswer(ctx, question, res)
+	}
+	return err
+}
+
+// conformToSchema replaces res.Answer with the JSON it contains, asking the
+// finalizer model once to repair an answer that does not parse.
+func (a *Agent) conformToSchema(ctx context.Context, schema string, res *Result) error {
+	answer, parseErr := jsonAnswer(res.Answer)
+	if parseErr == nil {
+		res.Answer = answer
+		return nil
+	}
+	a.debugf("Answer is not valid JSON: %v", parseErr)
+	if a.finalizer == nil {
+		return ErrInvalidJSONAnswer
+	}
+	user := buildJSONRepairUserPrompt(schema, res.Answer, parseErr)
+	a.debugf("JSON Repair User Prompt:\n%s", user)
+	resp, err := a.generate(ctx, stageJSONRepair, a.finalizer, jsonRepairSystemPrompt, user)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJSONAnswer, err)
+	}
+	res.Cost += resp.Cost
+	raw := getContent(resp, a.debugf, "JSON Repair")
+	a.debugf("JSON Repair Response:\n%s", raw)
+	if answer, parseErr = jsonAnswer(raw); parseErr != nil {
+		res.Answer = raw
+		return fmt.Errorf("%w: %w", ErrInvalidJSONAnswer, parseErr)
+	}
+	res.Answer = answer
+	return nil
+}
+
+// jsonAnswer returns the JSON value in text, which may be wrapped in a code
+// fence or surrounded by prose, or the error from parsing it.
+func jsonAnswer(text string) (string, error) {
+	candidate := extractJSON(strings.TrimSpace(text))
+	var v any
+	if err := json.Unmarshal([]byte(candidate), &v); err != nil {
+		return "", err
+	}
+	return candidate, nil
+}
+
+func (a *Agent) answerText(ctx context.Context, strategy Strategy, question, docText string) (Result, error) {
+	if g, ok := strategy.(*graphReaderStrategy); ok {
+		return g.answerFromText(ctx, question, docText)
+	}
+
+	if a.synthesizer == nil {
+		return Result{}, errors.New("synthesizer model is not configured")
+	}
+	pad := NewScratchpad(question)
+	doc := []SearchResult{{Title: "Provided document", Snippet: strings.TrimSpace(docText)}}
+	totalCost, err := a.synthesize(ctx, &pad, "(provided document)", doc)
+	if err != nil {
+		return Result{Cost: totalCost}, fmt.Errorf("synthesizer: %w", err)
+	}
+	answer, cost, err := a.finalize(ctx, pad)
+	totalCost += cost
+	if err != nil {
+		return Result{Cost: totalCost, Knowledge: pad.Knowledge}, err
+	}
+	return Result{Answer: answer, Cost: totalCost, Knowledge: pad.Knowledge}, nil
+}
+
+// Strategies returns the sorted names of all strategies that can be selected
+// with WithStrategyName, including those registered via WithStrategyFactory.
+func (a *Agent) Strategies() []string {
+	names := make([]string, 0, len(a.strategyFactories))
+	for name := range a.strategyFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (a *Agent) resolveStrategy() (Strategy, error) {
+	a.strategyMu.Lock()
+	defer a.strategyMu.Unlock()
 	if a.strategy != nil {
 		return a.strategy, nil
 	}
@@ -82,62 +346,241 @@ func (a *Agent) resolveStrategy() (Strategy, error) {
 }
 
 func (a *Agent) plan(ctx context.Context, pad Scratchpad) (PlannerDecision, float64, error) {
-	sys := plannerSystemPrompt
-	user := buildPlannerUserPrompt(pad)
-	if a.debug {
-		fmt.Printf("[LACONIC DEBUG] Planner System Prompt:\n%s\n", sys)
-		fmt.Printf("[LACONIC DEBUG] Planner User Prompt:\n%s\n", user)
+	sys := plannerSystemPromptFor(a.groundingMode)
+	if a.plannerPrompt != "" {
+		sys = a.plannerPrompt
 	}
-	resp, err := a.planner.Generate(ctx, sys, user)
+	user := buildPlannerUserPrompt(pad, a.groundingMode)
+	a.debugf("Planner System Prompt:\n%s", sys)
+	a.debugf("Planner User Prompt:\n%s", user)
+	resp, err := a.generate(ctx, stagePlanner, a.planner, sys, user)
 	if err != nil {
 		return PlannerDecision{}, 0, err
 	}
-	if a.debug {
-		fmt.Printf("[LACONIC DEBUG] Planner Response:\n%s\n", resp.Text)
-	}
+	a.debugf("Planner Response:\n%s", resp.Text)
 	// Strip <think> blocks from models like qwen3; fall back to reasoning if text is empty.
-	raw := getContent(resp, a.debug, "Planner")
+	raw := getContent(resp, a.debugf, "Planner")
+	if strings.TrimSpace(raw) == "" {
+		return PlannerDecision{}, resp.Cost, ErrEmptyLLMResponse
+	}
 	decision, err := parsePlannerDecision(raw)
 	return decision, resp.Cost, err
 }
 
 func (a *Agent) synthesize(ctx context.Context, pad *Scratchpad, query string, results []SearchResult) (float64, error) {
-	sys := synthesizerSystemPrompt
-	user := buildSynthesizerUserPrompt(*pad, query, results)
-	if a.debug {
-		fmt.Printf("[LACONIC DEBUG] Synthesizer System Prompt:\n%s\n", sys)
-		fmt.Printf("[LACONIC DEBUG] Synthesizer User Prompt:\n%s\n", user)
+	sys := synthesizerSystemPromptFor(a.groundingMode)
+	if a.synthesizerPrompt != "" {
+		sys = a.synthesizerPrompt
 	}
-	resp, err := a.synthesizer.Generate(ctx, sys, user)
+	user := buildSynthesizerUserPrompt(*pad, query, results, a.synthesizerFields, a.synthesizerHistory)
+	a.debugf("Synthesizer System Prompt:\n%s", sys)
+	a.debugf("Synthesizer User Prompt:\n%s", user)
+	resp, err := a.generate(ctx, stageSynthesizer, a.synthesizer, sys, user)
 	if err != nil {
 		return 0, err
 	}
-	if a.debug {
-		fmt.Printf("[LACONIC DEBUG] Synthesizer Response:\n%s\n", resp.Text)
-	}
+	a.debugf("Synthesizer Response:\n%s", resp.Text)
 	// Strip <think> blocks from models like qwen3; fall back to reasoning if text is empty.
-	pad.Knowledge = getContent(resp, a.debug, "Synthesizer")
+	knowledge := getContent(resp, a.debugf, "Synthesizer")
+	if strings.TrimSpace(knowledge) == "" {
+		return resp.Cost, ErrEmptyLLMResponse
+	}
+	pad.Knowledge = knowledge
 	pad.CurrentStep = fmt.Sprintf("Last query: %s", query)
 	return resp.Cost, nil
 }
 
 func (a *Agent) finalize(ctx context.Context, pad Scratchpad) (string, float64, error) {
+	return a.finalizeAs(ctx, stageFinalizer, pad)
+}
+
+// emitProgress writes and reports a provisional answer when iteration falls
+// on the WithProgressiveAnswers interval. It returns the cost incurred.
+func (a *Agent) emitProgress(ctx context.Context, iteration int, pad Scratchpad) float64 {
+	if !a.progressDue(iteration) || strings.TrimSpace(pad.Knowledge) == "" {
+		return 0
+	}
+	answer, cost, err := a.finalizeAs(ctx, stageProgressive, pad)
+	if err != nil {
+		a.debugf("Provisional answer failed: %v", err)
+		return cost
+	}
+	a.progressHandler(ProvisionalAnswer{Iteration: iteration, Answer: answer, Cost: cost})
+	return cost
+}
+
+// progressDue reports whether a provisional answer should be written after
+// the given iteration.
+func (a *Agent) progressDue(iteration int) bool {
+	return a.progressHandler != nil && a.progressEvery > 0 && iteration%a.progressEvery == 0
+}
+
+// answerFormatInstruction returns the instruction that ends a finalizer
+// prompt: the WithOutputSchema schema for the final answer, or else the
+// WithMaxAnswerWords limit. Schema answers are never truncated, so they get
+// no word limit either.
+func (a *Agent) answerFormatInstruction(ctx context.Context, stage string) string {
+	schema := answerOptions(ctx).outputSchema
+	if schema == "" {
+		return answerLengthInstruction(a.maxAnswerWords)
+	}
+	if stage == stageFinalizer {
+		return outputSchemaInstruction(schema)
+	}
+	return ""
+}
+
+// finalizeAs runs the finalizer prompt on behalf of stage.
+func (a *Agent) finalizeAs(ctx context.Context, stage string, pad Scratchpad) (string, float64, error) {
+	if a.insufficientText != "" && isStrictGrounding(a.groundingMode) && strings.TrimSpace(pad.Knowledge) == "" {
+		return a.insufficientText, 0, nil
+	}
 	if a.finalizer == nil {
 		return "", 0, errors.New("finalizer model is not configured")
 	}
-	sys := finalizerSystemPrompt
-	user := buildFinalizerUserPrompt(pad)
-	if a.debug {
-		fmt.Printf("[LACONIC DEBUG] Finalizer System Prompt:\n%s\n", sys)
-		fmt.Printf("[LACONIC DEBUG] Finalizer User Prompt:\n%s\n", user)
+	insufficient := a.insufficientText
+	if insufficient == "" {
+		insufficient = defaultInsufficientAnswer
 	}
-	resp, err := a.finalizer.Generate(ctx, sys, user)
+	sys := finalizerSystemPromptFor(a.groundingMode)
+	if a.finalizerPrompt != "" {
+		sys = a.finalizerPrompt
+	}
+	user := buildFinalizerUserPrompt(pad, insufficient) + a.answerFormatInstruction(ctx, stage)
+	a.debugf("Finalizer System Prompt:\n%s", sys)
+	a.debugf("Finalizer User Prompt:\n%s", user)
+	resp, err := a.generate(ctx, stage, a.finalizer, sys, user)
 	if err != nil {
 		return "", 0, err
 	}
-	if a.debug {
-		fmt.Printf("[LACONIC DEBUG] Finalizer Response:\n%s\n", resp.Text)
-	}
+	a.debugf("Finalizer Response:\n%s", resp.Text)
 	// Strip <think> blocks from models like qwen3; fall back to reasoning if text is empty.
-	return getContent(resp, a.debug, "Finalizer"), resp.Cost, nil
+	answer := getContent(resp, a.debugf, "Finalizer")
+	if strings.TrimSpace(answer) == "" {
+		return "", resp.Cost, fmt.Errorf("finalizer: %w", ErrEmptyLLMResponse)
+	}
+	return answer, resp.Cost, nil
+}
+
+// stopReasonFor classifies a run that did not record its own StopReason.
+func stopReasonFor(err error) StopReason {
+	switch {
+	case err == nil:
+		return StopAnswered
+	case errors.Is(err, context.DeadlineExceeded):
+		return StopTimeout
+	case isBudgetError(err):
+		return StopBudgetExceeded
+	default:
+		return StopError
+	}
+}
+
+// validateAnswer checks res.Answer against res.Knowledge with one finalizer
+// call and records the claims the knowledge does not support. Validation
+// never fails the run: on error the answer is returned unannotated.
+func (a *Agent) validateAnswer(ctx context.Context, question string, res *Result) {
+	if a.finalizer == nil {
+		return
+	}
+	user := buildValidatorUserPrompt(question, res.Answer, res.Knowledge)
+	a.debugf("Validator User Prompt:\n%s", user)
+	resp, err := a.generate(ctx, stageValidator, a.finalizer, validatorSystemPrompt, user)
+	if err != nil {
+		a.debugf("Answer validation failed: %v", err)
+		return
+	}
+	res.Cost += resp.Cost
+	raw := getContent(resp, a.debugf, "Validator")
+	a.debugf("Validator Response:\n%s", raw)
+	unsupported, err := parseUnsupportedClaims(raw)
+	if err != nil {
+		a.debugf("Answer validation failed: %v", err)
+		return
+	}
+	res.Unsupported = unsupported
+}
+
+// summarizeAnswer condenses res.Answer into a Summary with one finalizer
+// call. Like validation it never fails the run: on error Summary stays nil.
+func (a *Agent) summarizeAnswer(ctx context.Context, question string, res *Result) {
+	if a.finalizer == nil {
+		return
+	}
+	user := buildSummaryUserPrompt(question, res.Answer)
+	a.debugf("Summary User Prompt:\n%s", user)
+	resp, err := a.generate(ctx, stageSummary, a.finalizer, summarySystemPrompt, user)
+	if err != nil {
+		a.debugf("Structured summary failed: %v", err)
+		return
+	}
+	res.Cost += resp.Cost
+	raw := getContent(resp, a.debugf, "Summary")
+	a.debugf("Summary Response:\n%s", raw)
+	summary, err := parseSummary(raw)
+	if err != nil {
+		a.debugf("Structured summary failed: %v", err)
+		return
+	}
+	res.Summary = summary
+}
+
+// reportSearchError passes a failed search to the WithSearchErrorHandler
+// callback, if any.
+func (a *Agent) reportSearchError(query string, err error) {
+	if a.searchErrorHandler != nil {
+		a.searchErrorHandler(query, err)
+	}
+}
+
+// searchQuery runs one query through the run's search provider, tracing
+// it and reporting a failure to the WithSearchErrorHandler callback.
+func (a *Agent) searchQuery(ctx context.Context, query string) ([]SearchResult, error) {
+	spanCtx, end := a.startSpan(ctx, spanSearch)
+	start := time.Now()
+	results, err := a.searchProvider(ctx).Search(spanCtx, query)
+	addRunTiming(ctx, timingSearch, time.Since(start))
+	end(withError(map[string]any{"laconic.query": query, "laconic.results": len(results)}, err))
+	if err != nil {
+		a.reportSearchError(query, err)
+	} else {
+		addRunCost(ctx, costSearch, a.searchCost)
+	}
+	return results, err
+}
+
+// search runs query through the search provider. When reformulation is
+// enabled and the search returns nothing, the planner rewrites the query and
+// the search is retried once. It returns the results, the query that
+// produced them, and the combined search and LLM cost.
+func (a *Agent) search(ctx context.Context, question, query string) ([]SearchResult, string, float64, error) {
+	results, err := a.searchQuery(ctx, query)
+	if err != nil {
+		return nil, query, 0, err
+	}
+	results = a.prepareResults(results)
+	cost := a.searchCost
+	if len(results) > 0 || !a.reformulateOnEmpty {
+		return results, query, cost, nil
+	}
+
+	user := buildReformulateUserPrompt(question, query)
+	a.debugf("Reformulate User Prompt:\n%s", user)
+	resp, err := a.generate(ctx, stageReformulate, a.planner, reformulateSystemPrompt, user)
+	if err != nil {
+		// Reformulation is best effort; keep the empty result set.
+		return results, query, cost, nil
+	}
+	cost += resp.Cost
+	rewritten := parseReformulatedQuery(getContent(resp, a.debugf, "Reformulate"))
+	a.debugf("Reformulated query: %q -> %q", query, rewritten)
+	if rewritten == "" || strings.EqualFold(rewritten, query) {
+		return results, query, cost, nil
+	}
+	retry, err := a.searchQuery(ctx, rewritten)
+	if err != nil {
+		return nil, rewritten, cost, err
+	}
+	retry = a.prepareResults(retry)
+	return retry, rewritten, cost + a.searchCost, nil
 }
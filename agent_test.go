@@ -3,17 +3,25 @@ package laconic
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type scriptedLLM struct {
 	planner []string
 	synth   []string
 	final   []string
+	reform  []string
 
 	plannerIdx int
 	synthIdx   int
 	finalIdx   int
+	reformIdx  int
 
 	costPerCall float64
 }
@@ -31,12 +39,14 @@ func (s *scriptedLLM) Generate(_ context.Context, systemPrompt, _ string) (LLMRe
 	var text string
 	var err error
 	switch systemPrompt {
-	case plannerSystemPrompt:
+	case plannerSystemPrompt, plannerAugmentSystemPrompt, plannerOffSystemPrompt:
 		text, err = s.next(s.planner, &s.plannerIdx)
-	case synthesizerSystemPrompt:
+	case synthesizerSystemPrompt, synthesizerAugmentSystemPrompt, synthesizerOffSystemPrompt:
 		text, err = s.next(s.synth, &s.synthIdx)
-	case finalizerSystemPrompt:
+	case finalizerSystemPrompt, finalizerAugmentSystemPrompt, finalizerOffSystemPrompt:
 		text, err = s.next(s.final, &s.finalIdx)
+	case reformulateSystemPrompt:
+		text, err = s.next(s.reform, &s.reformIdx)
 	default:
 		return LLMResponse{}, errors.New("unknown system prompt")
 	}
@@ -131,6 +141,15 @@ func TestAgentCostTracking(t *testing.T) {
 	if res.Cost < expectedCost-0.001 || res.Cost > expectedCost+0.001 {
 		t.Fatalf("expected cost ~%.3f, got %.3f", expectedCost, res.Cost)
 	}
+	want := map[string]float64{"planner": 0.02, "search": 0.005, "synthesizer": 0.01, "finalizer": 0.01}
+	if len(res.CostBreakdown) != len(want) {
+		t.Fatalf("CostBreakdown = %v, want %v", res.CostBreakdown, want)
+	}
+	for phase, cost := range want {
+		if got := res.CostBreakdown[phase]; got < cost-1e-9 || got > cost+1e-9 {
+			t.Errorf("CostBreakdown[%q] = %v, want %v", phase, got, cost)
+		}
+	}
 }
 
 func TestAgentZeroCostByDefault(t *testing.T) {
@@ -259,3 +278,1484 @@ func TestPriorKnowledgeCleared(t *testing.T) {
 		t.Fatalf("call 2: expected fresh knowledge, got %q", res2.Knowledge)
 	}
 }
+
+func TestEmptyFinalizerResponse(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: q", "Action: Answer"},
+		synth:   []string{"some knowledge"},
+		final:   []string{""},
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithMaxIterations(3),
+	)
+
+	_, err := agent.Answer(context.Background(), "Q")
+	if !errors.Is(err, ErrEmptyLLMResponse) {
+		t.Fatalf("expected ErrEmptyLLMResponse, got %v", err)
+	}
+}
+
+func TestEmptySynthesizerResponse(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: q"},
+		synth:   []string{"   "},
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithMaxIterations(3),
+	)
+
+	_, err := agent.Answer(context.Background(), "Q")
+	if !errors.Is(err, ErrEmptyLLMResponse) {
+		t.Fatalf("expected ErrEmptyLLMResponse, got %v", err)
+	}
+}
+
+type failingSearch struct{ t *testing.T }
+
+func (f failingSearch) Search(_ context.Context, query string) ([]SearchResult, error) {
+	f.t.Fatalf("unexpected search for %q", query)
+	return nil, nil
+}
+
+func TestGroundingOffAnswersWithoutSearch(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Answer"},
+		final:   []string{"Paris"},
+	}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(failingSearch{t}),
+		WithGroundingMode(GroundingOff),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Paris" {
+		t.Fatalf("unexpected answer: %q", res.Answer)
+	}
+}
+
+func TestCustomSystemPrompts(t *testing.T) {
+	var prompts []string
+	llm := llmFunc(func(_ context.Context, systemPrompt, _ string) (LLMResponse, error) {
+		prompts = append(prompts, systemPrompt)
+		switch systemPrompt {
+		case "custom planner":
+			if len(prompts) == 1 {
+				return LLMResponse{Text: "Action: Search\nQuery: q"}, nil
+			}
+			return LLMResponse{Text: "Action: Answer"}, nil
+		case "custom synthesizer":
+			return LLMResponse{Text: "knowledge"}, nil
+		case "Always answer in French.":
+			return LLMResponse{Text: "Paris est la capitale."}, nil
+		}
+		return LLMResponse{}, fmt.Errorf("unexpected system prompt %q", systemPrompt)
+	})
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithPlannerPrompt("custom planner"),
+		WithSynthesizerPrompt("custom synthesizer"),
+		WithFinalizerPrompt("  Always answer in French.\n"),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Paris est la capitale." {
+		t.Fatalf("unexpected answer: %q", res.Answer)
+	}
+	want := []string{"custom planner", "custom synthesizer", "custom planner", "Always answer in French."}
+	if !reflect.DeepEqual(prompts, want) {
+		t.Fatalf("system prompts = %q, want %q", prompts, want)
+	}
+}
+
+func TestOutputSchema(t *testing.T) {
+	const schema = `{"type": "object", "properties": {"capital": {"type": "string"}}}`
+	tests := []struct {
+		name    string
+		final   string
+		repair  string
+		want    string
+		wantErr error
+	}{
+		{name: "fenced", final: "```json\n{\"capital\": \"Paris\"}\n```", want: `{"capital": "Paris"}`},
+		{name: "repaired", final: "The capital is Paris.", repair: `{"capital": "Paris"}`, want: `{"capital": "Paris"}`},
+		{name: "invalid", final: "The capital is Paris.", repair: "Paris", want: "Paris", wantErr: ErrInvalidJSONAnswer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var finalUser string
+			repairs := 0
+			llm := llmFunc(func(_ context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+				switch systemPrompt {
+				case plannerSystemPrompt:
+					return LLMResponse{Text: "Action: Answer"}, nil
+				case synthesizerSystemPrompt:
+					if strings.Contains(userPrompt, schema) {
+						t.Errorf("synthesizer was given the schema")
+					}
+					return LLMResponse{Text: "Paris is the capital of France."}, nil
+				case finalizerSystemPrompt:
+					finalUser = userPrompt
+					return LLMResponse{Text: tt.final}, nil
+				case jsonRepairSystemPrompt:
+					repairs++
+					return LLMResponse{Text: tt.repair}, nil
+				}
+				return LLMResponse{}, fmt.Errorf("unexpected system prompt %q", systemPrompt)
+			})
+			agent := New(
+				WithPlannerModel(llm),
+				WithSynthesizerModel(llm),
+				WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+				WithMaxAnswerWords(2),
+			)
+
+			res, err := agent.Answer(context.Background(), "What is the capital of France?", WithOutputSchema(schema))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if res.Answer != tt.want {
+				t.Fatalf("answer = %q, want %q", res.Answer, tt.want)
+			}
+			if !strings.Contains(finalUser, schema) {
+				t.Fatalf("finalizer prompt lacks the schema:\n%s", finalUser)
+			}
+			if strings.Contains(finalUser, "Keep the answer under") {
+				t.Fatalf("finalizer prompt asks for a word limit with a schema:\n%s", finalUser)
+			}
+			if wantRepairs := min(len(tt.repair), 1); repairs != wantRepairs {
+				t.Fatalf("repair calls = %d, want %d", repairs, wantRepairs)
+			}
+		})
+	}
+}
+
+func TestInsufficientAnswerTextSkipsFinalizer(t *testing.T) {
+	// The planner call uses up the budget, so the run finalizes with no
+	// knowledge gathered.
+	llm := &scriptedLLM{planner: []string{"Action: Search\nQuery: capital"}} // no finalizer responses
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(failingSearch{t}),
+		WithMaxLLMCalls(1),
+		WithInsufficientAnswerText("Keine Informationen gefunden."),
+	)
+
+	res, err := agent.Answer(context.Background(), "Was ist die Hauptstadt?")
+	if !errors.Is(err, ErrCallBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrCallBudgetExceeded", err)
+	}
+	if res.Answer != "Keine Informationen gefunden." {
+		t.Fatalf("answer = %q, want the configured fallback", res.Answer)
+	}
+	if llm.finalIdx != 0 {
+		t.Fatalf("finalizer called %d times, want 0", llm.finalIdx)
+	}
+}
+
+func TestInsufficientAnswerTextIgnoredWithoutStrictGrounding(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Answer"},
+		final:   []string{"Berlin"},
+	}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithGroundingMode(GroundingOff),
+		WithInsufficientAnswerText("Keine Informationen gefunden."),
+	)
+
+	res, err := agent.Answer(context.Background(), "Was ist die Hauptstadt?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Berlin" {
+		t.Fatalf("answer = %q, want the finalizer's internal-knowledge answer", res.Answer)
+	}
+}
+
+func TestEarlyExitSkipsPlanner(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: capital of France"}, // a second plan would fail
+		synth:   []string{"Paris is the capital of France."},
+		final:   []string{"Paris"},
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithEarlyExit(true),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Paris" || res.StopReason != StopAnswerCheckPassed {
+		t.Fatalf("got answer %q, stop reason %q; want Paris after the early check", res.Answer, res.StopReason)
+	}
+	if llm.plannerIdx != 1 {
+		t.Fatalf("planner called %d times, want 1", llm.plannerIdx)
+	}
+}
+
+func TestReturnPartialOnError(t *testing.T) {
+	llm := &scriptedLLM{
+		// The second planner call has no scripted response and fails.
+		planner:     []string{"Action: Search\nQuery: q"},
+		synth:       []string{"partial knowledge"},
+		costPerCall: 0.01,
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithReturnPartialOnError(true),
+	)
+
+	res, err := agent.Answer(context.Background(), "Q")
+	if err == nil {
+		t.Fatal("expected planner error")
+	}
+	if res.Knowledge != "partial knowledge" {
+		t.Fatalf("expected partial knowledge, got %q", res.Knowledge)
+	}
+	if res.Cost < 0.019 {
+		t.Fatalf("expected accumulated cost, got %f", res.Cost)
+	}
+}
+
+// The built-in strategies must satisfy Strategy, returning a full Result.
+var (
+	_ Strategy = (*scratchpadStrategy)(nil)
+	_ Strategy = (*graphReaderStrategy)(nil)
+)
+
+func TestStrategiesIncludesRegistered(t *testing.T) {
+	agent := New(WithStrategyFactory("custom", func(a *Agent) (Strategy, error) {
+		return newScratchpadStrategy(a)
+	}))
+	got := strings.Join(agent.Strategies(), ",")
+	if got != "custom,deep-read,graph-reader,scratchpad" {
+		t.Fatalf("unexpected strategies: %s", got)
+	}
+}
+
+func TestNewAgentValidatesConfig(t *testing.T) {
+	llm := &scriptedLLM{}
+	cases := []struct {
+		name string
+		opts []Option
+		want string // "" means valid
+	}{
+		{"no models", nil, "planner model is not configured"},
+		{"no synthesizer", []Option{WithPlannerModel(llm)}, "synthesizer model is not configured"},
+		{"scratchpad", []Option{WithPlannerModel(llm), WithSynthesizerModel(llm)}, ""},
+		{"unknown strategy", []Option{WithPlannerModel(llm), WithSynthesizerModel(llm), WithStrategyName("nope")}, "unknown strategy: nope"},
+		{"graph-reader", []Option{WithPlannerModel(llm), WithSynthesizerModel(llm), WithStrategyName("graph-reader")}, ""},
+		{"deep-read without fetcher", []Option{WithPlannerModel(llm), WithSynthesizerModel(llm), WithStrategyName("deep-read")}, "fetch provider is not configured"},
+	}
+	for _, tc := range cases {
+		agent, err := NewAgent(tc.opts...)
+		if tc.want == "" {
+			if err != nil || agent == nil {
+				t.Errorf("%s: NewAgent = %v, %v; want an agent", tc.name, agent, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestReformulateOnEmpty(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: overly specific query", "Action: Answer"},
+		reform:  []string{"Query: broader query"},
+		synth:   []string{"found it"},
+		final:   []string{"answer"},
+	}
+	var queries []string
+	searcher := searchFunc(func(_ context.Context, query string) ([]SearchResult, error) {
+		queries = append(queries, query)
+		if query == "broader query" {
+			return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+		}
+		return nil, nil
+	})
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithReformulateOnEmpty(true),
+	)
+
+	if _, err := agent.Answer(context.Background(), "Q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(queries, "|") != "overly specific query|broader query" {
+		t.Fatalf("unexpected queries: %v", queries)
+	}
+}
+
+func TestAnswerFromText(t *testing.T) {
+	llm := &scriptedLLM{
+		synth: []string{"The report says revenue was $5B."},
+		final: []string{"Revenue was $5B."},
+	}
+
+	// No search provider is configured.
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+	)
+
+	res, err := agent.AnswerFromText(context.Background(), "What was revenue?", "Annual report: revenue was $5B.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Revenue was $5B." {
+		t.Fatalf("unexpected answer: %q", res.Answer)
+	}
+	if res.Knowledge != "The report says revenue was $5B." {
+		t.Fatalf("unexpected knowledge: %q", res.Knowledge)
+	}
+}
+
+func TestMaxLLMCallsForcesFinalize(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: one", "Action: Search\nQuery: two", "Action: Search\nQuery: three"},
+		synth:   []string{"k1", "k2", "k3"},
+		final:   []string{"budget answer"},
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithMaxIterations(5),
+		WithMaxLLMCalls(3),
+	)
+
+	res, err := agent.Answer(context.Background(), "Q")
+	if !errors.Is(err, ErrCallBudgetExceeded) {
+		t.Fatalf("expected ErrCallBudgetExceeded, got %v", err)
+	}
+	if res.Answer != "budget answer" {
+		t.Fatalf("expected finalized answer, got %q", res.Answer)
+	}
+	// plan, synthesize, plan, then the finalizer.
+	if llm.plannerIdx != 2 || llm.synthIdx != 1 || llm.finalIdx != 1 {
+		t.Fatalf("unexpected call counts: planner=%d synth=%d final=%d", llm.plannerIdx, llm.synthIdx, llm.finalIdx)
+	}
+}
+
+func TestMaxCostForcesFinalize(t *testing.T) {
+	llm := &scriptedLLM{
+		planner:     []string{"Action: Search\nQuery: one", "Action: Search\nQuery: two", "Action: Search\nQuery: three"},
+		synth:       []string{"k1", "k2", "k3"},
+		final:       []string{"budget answer"},
+		costPerCall: 0.01,
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithSearchCost(0.005),
+		WithMaxIterations(5),
+		WithMaxCost(0.03),
+	)
+
+	res, err := agent.Answer(context.Background(), "Q")
+	if !errors.Is(err, ErrCostBudgetExceeded) || errors.Is(err, ErrCallBudgetExceeded) {
+		t.Fatalf("expected ErrCostBudgetExceeded, got %v", err)
+	}
+	if res.Answer != "budget answer" || res.StopReason != StopBudgetExceeded {
+		t.Fatalf("got answer %q, stop reason %q; want the finalized answer", res.Answer, res.StopReason)
+	}
+	// plan, search, synthesize (0.025), plan (0.035); the second search is
+	// skipped and the finalizer runs.
+	if llm.plannerIdx != 2 || llm.synthIdx != 1 || llm.finalIdx != 1 {
+		t.Fatalf("unexpected call counts: planner=%d synth=%d final=%d", llm.plannerIdx, llm.synthIdx, llm.finalIdx)
+	}
+	if res.Cost < 0.044 || res.Cost > 0.046 || len(res.Queries) != 1 {
+		t.Fatalf("cost = %v after queries %q, want 0.045 after one search", res.Cost, res.Queries)
+	}
+}
+
+func TestMaxCostSkipsForcedSearch(t *testing.T) {
+	llm := &scriptedLLM{
+		planner:     []string{"Action: Answer"},
+		final:       []string{"budget answer"},
+		costPerCall: 0.05,
+	}
+	searches := 0
+	searcher := searchFunc(func(_ context.Context, _ string) ([]SearchResult, error) {
+		searches++
+		return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+	})
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithMaxCost(0.03))
+
+	res, err := agent.Answer(context.Background(), "Q")
+	if !errors.Is(err, ErrCostBudgetExceeded) || res.StopReason != StopBudgetExceeded {
+		t.Fatalf("err = %v, stop reason %q; want the cost budget", err, res.StopReason)
+	}
+	if searches != 0 || llm.synthIdx != 0 {
+		t.Fatalf("searches = %d, synth calls = %d after the budget ran out; want 0", searches, llm.synthIdx)
+	}
+}
+
+// streamingLLM streams its finalizer answer in words and reports the cost
+// only on the final response.
+type streamingLLM struct {
+	*scriptedLLM
+	words []string
+}
+
+func (s streamingLLM) GenerateStream(_ context.Context, _, _ string) (<-chan string, <-chan LLMResponse, error) {
+	chunks := make(chan string)
+	final := make(chan LLMResponse, 1)
+	go func() {
+		for _, w := range s.words {
+			chunks <- w
+		}
+		close(chunks)
+		final <- LLMResponse{Cost: 0.5}
+		close(final)
+	}()
+	return chunks, final, nil
+}
+
+func TestStreamHandler(t *testing.T) {
+	scripted := &scriptedLLM{planner: []string{"Action: Answer"}}
+	llm := streamingLLM{scriptedLLM: scripted, words: []string{"Paris ", "is ", "the ", "capital."}}
+	var chunks []string
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithGroundingMode(GroundingOff),
+		WithStreamHandler(func(chunk string) { chunks = append(chunks, chunk) }),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Paris is the capital." || res.Cost != 0.5 {
+		t.Fatalf("got answer %q at cost %v; want the joined chunks at 0.5", res.Answer, res.Cost)
+	}
+	if !reflect.DeepEqual(chunks, llm.words) {
+		t.Fatalf("handler saw %q, want %q", chunks, llm.words)
+	}
+	if scripted.finalIdx != 0 {
+		t.Fatal("the finalizer was called through Generate, not GenerateStream")
+	}
+}
+
+// streamFuncLLM streams its finalizer through fn.
+type streamFuncLLM struct {
+	*scriptedLLM
+	fn func(ctx context.Context, params GenParams) (<-chan string, <-chan LLMResponse, error)
+}
+
+func (s streamFuncLLM) GenerateStream(ctx context.Context, _, _ string) (<-chan string, <-chan LLMResponse, error) {
+	return s.fn(ctx, GenParams{})
+}
+
+func (s streamFuncLLM) GenerateStreamWithParams(ctx context.Context, _, _ string, params GenParams) (<-chan string, <-chan LLMResponse, error) {
+	return s.fn(ctx, params)
+}
+
+func TestStreamHandlerFailsOnInterruptedStream(t *testing.T) {
+	llm := streamFuncLLM{
+		scriptedLLM: &scriptedLLM{planner: []string{"Action: Answer"}},
+		fn: func(context.Context, GenParams) (<-chan string, <-chan LLMResponse, error) {
+			chunks := make(chan string, 1)
+			final := make(chan LLMResponse)
+			chunks <- "Par"
+			close(chunks)
+			close(final)
+			return chunks, final, nil
+		},
+	}
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithGroundingMode(GroundingOff),
+		WithStreamHandler(func(string) {}),
+	)
+
+	if _, err := agent.Answer(context.Background(), "Q"); !errors.Is(err, errStreamInterrupted) {
+		t.Fatalf("err = %v, want errStreamInterrupted", err)
+	}
+}
+
+func TestStreamHandlerStopsOnCancel(t *testing.T) {
+	llm := streamFuncLLM{
+		scriptedLLM: &scriptedLLM{planner: []string{"Action: Answer"}},
+		fn: func(context.Context, GenParams) (<-chan string, <-chan LLMResponse, error) {
+			chunks := make(chan string, 1)
+			chunks <- "Paris "
+			return chunks, make(chan LLMResponse), nil // stalls after one chunk
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithGroundingMode(GroundingOff),
+		WithStreamHandler(func(string) { cancel() }),
+	)
+
+	if _, err := agent.Answer(ctx, "Q"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestStreamHandlerPassesSeedAndSkipsThinking(t *testing.T) {
+	var seed *int64
+	llm := streamFuncLLM{
+		scriptedLLM: &scriptedLLM{planner: []string{"Action: Answer"}},
+		fn: func(_ context.Context, params GenParams) (<-chan string, <-chan LLMResponse, error) {
+			seed = params.Seed
+			chunks := make(chan string, 4)
+			final := make(chan LLMResponse, 1)
+			for _, c := range []string{"<thi", "nk>hmm</think>\n", "Paris", "."} {
+				chunks <- c
+			}
+			close(chunks)
+			final <- LLMResponse{}
+			close(final)
+			return chunks, final, nil
+		},
+	}
+	var chunks []string
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithGroundingMode(GroundingOff),
+		WithSeed(7),
+		WithStreamHandler(func(chunk string) { chunks = append(chunks, chunk) }),
+	)
+
+	res, err := agent.Answer(context.Background(), "Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Paris." {
+		t.Fatalf("answer = %q, want %q", res.Answer, "Paris.")
+	}
+	if want := []string{"Paris", "."}; !reflect.DeepEqual(chunks, want) {
+		t.Fatalf("handler saw %q, want %q", chunks, want)
+	}
+	if seed == nil || *seed != 7 {
+		t.Fatalf("seed = %v, want 7", seed)
+	}
+}
+
+func TestAnswerValidationAnnotatesUnsupportedClaims(t *testing.T) {
+	scripted := &scriptedLLM{
+		planner:     []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+		synth:       []string{"The sky is blue due to Rayleigh scattering."},
+		final:       []string{"The sky is blue due to Rayleigh scattering. It was first explained in 1871."},
+		costPerCall: 0.01,
+	}
+	var validatorPrompt string
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == validatorSystemPrompt {
+			validatorPrompt = userPrompt
+			return LLMResponse{Text: `{"unsupported": ["It was first explained in 1871."]}`, Cost: 0.01}, nil
+		}
+		return scripted.Generate(ctx, systemPrompt, userPrompt)
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "Sky", URL: "u", Snippet: "Rayleigh scattering"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithAnswerValidation(true),
+	)
+
+	res, err := agent.Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(res.Answer, "1871") {
+		t.Fatalf("answer should not be modified: %q", res.Answer)
+	}
+	if len(res.Unsupported) != 1 || res.Unsupported[0] != "It was first explained in 1871." {
+		t.Fatalf("unexpected unsupported claims: %v", res.Unsupported)
+	}
+	if !strings.Contains(validatorPrompt, "Rayleigh scattering") {
+		t.Fatalf("validator should see the knowledge, got %q", validatorPrompt)
+	}
+	// planner x2, synthesizer, finalizer, validator
+	if res.Cost < 0.049 || res.Cost > 0.051 {
+		t.Fatalf("expected validation cost to be included, got %f", res.Cost)
+	}
+}
+
+func TestStructuredSummary(t *testing.T) {
+	scripted := &scriptedLLM{
+		planner:     []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+		synth:       []string{"The sky is blue due to Rayleigh scattering."},
+		final:       []string{"The sky is blue due to Rayleigh scattering, described by Lord Rayleigh."},
+		costPerCall: 0.01,
+	}
+	var summaryPrompt string
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == summarySystemPrompt {
+			summaryPrompt = userPrompt
+			return LLMResponse{Text: `{"tldr": " Rayleigh scattering makes the sky blue. ", "key_facts": ["Blue light scatters most.", ""], "entities": ["Lord Rayleigh"]}`, Cost: 0.01}, nil
+		}
+		return scripted.Generate(ctx, systemPrompt, userPrompt)
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "Sky", URL: "u", Snippet: "Rayleigh scattering"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStructuredSummary(true),
+	)
+	res, err := agent.Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "The sky is blue due to Rayleigh scattering, described by Lord Rayleigh." {
+		t.Fatalf("answer should not be modified: %q", res.Answer)
+	}
+	if !strings.Contains(summaryPrompt, res.Answer) {
+		t.Fatalf("summary prompt should contain the answer, got %q", summaryPrompt)
+	}
+	want := Summary{TLDR: "Rayleigh scattering makes the sky blue.", KeyFacts: []string{"Blue light scatters most."}, Entities: []string{"Lord Rayleigh"}}
+	if res.Summary == nil || !reflect.DeepEqual(*res.Summary, want) {
+		t.Fatalf("unexpected summary: %+v", res.Summary)
+	}
+	// planner x2, synthesizer, finalizer, summary
+	if res.Cost < 0.049 || res.Cost > 0.051 {
+		t.Fatalf("expected summary cost to be included, got %f", res.Cost)
+	}
+
+	// Without the option no summary call is made.
+	scripted = &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+		synth:   []string{"The sky is blue."},
+		final:   []string{"Blue."},
+	}
+	summaryPrompt = ""
+	res, err = New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher)).
+		Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Summary != nil || summaryPrompt != "" {
+		t.Fatalf("summary should be off by default, got %+v", res.Summary)
+	}
+}
+
+func TestCaptureRawResponses(t *testing.T) {
+	newLLM := func() *scriptedLLM {
+		return &scriptedLLM{
+			planner: []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+			synth:   []string{"The sky is blue."},
+			final:   []string{"<think>easy</think>Blue."},
+		}
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "Sky", URL: "u", Snippet: "blue"}}}
+
+	llm := newLLM()
+	res, err := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithCaptureRawResponses(true)).
+		Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string][]string{
+		stagePlanner:     {"Action: Search\nQuery: sky color", "Action: Answer"},
+		stageSynthesizer: {"The sky is blue."},
+		stageFinalizer:   {"<think>easy</think>Blue."},
+	}
+	if !reflect.DeepEqual(res.RawResponses, want) {
+		t.Fatalf("RawResponses = %q", res.RawResponses)
+	}
+
+	llm = newLLM()
+	res, err = New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher)).
+		Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.RawResponses != nil {
+		t.Fatalf("RawResponses should be nil by default, got %q", res.RawResponses)
+	}
+}
+
+func TestProgressiveAnswers(t *testing.T) {
+	llm := &scriptedLLM{
+		planner:     []string{"Action: Search\nQuery: one", "Action: Search\nQuery: two", "Action: Search\nQuery: three", "Action: Answer"},
+		synth:       []string{"k1", "k2", "k3"},
+		final:       []string{"draft after two searches", "final answer"},
+		costPerCall: 0.01,
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	var drafts []ProvisionalAnswer
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithProgressiveAnswers(2, func(p ProvisionalAnswer) { drafts = append(drafts, p) }),
+	)
+
+	res, err := agent.Answer(context.Background(), "Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "final answer" {
+		t.Fatalf("unexpected final answer: %q", res.Answer)
+	}
+	if len(drafts) != 1 || drafts[0].Iteration != 2 || drafts[0].Answer != "draft after two searches" {
+		t.Fatalf("unexpected provisional answers: %+v", drafts)
+	}
+	// planner x4, synthesizer x3, provisional + final finalizer calls.
+	if res.Cost < 0.089 || res.Cost > 0.091 {
+		t.Fatalf("expected provisional cost to be included, got %f", res.Cost)
+	}
+}
+
+func TestSynthesizerPromptFallsBackToContent(t *testing.T) {
+	content := strings.Repeat("x", maxSynthesizerContentLen+100)
+	results := []SearchResult{
+		{Title: "snippet", URL: "https://a.example", Snippet: "the snippet", Content: "ignored page text"},
+		{Title: "content", URL: "https://b.example", Content: "  " + content},
+	}
+	prompt := buildSynthesizerUserPrompt(Scratchpad{}, "q", results, nil, false)
+	if !strings.Contains(prompt, "| the snippet\n") || strings.Contains(prompt, "ignored page text") {
+		t.Fatalf("snippet not preferred:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "| "+content[:maxSynthesizerContentLen]+"...\n") {
+		t.Fatalf("content not used or not capped:\n%s", prompt)
+	}
+}
+
+func TestTruncateAnswer(t *testing.T) {
+	cases := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"Short answer.", 10, "Short answer."},
+		{"One two three. Four five six. Seven eight.", 7, "One two three. Four five six."},
+		{"One two three four five", 3, "One two three..."},
+		{"First line.\nSecond line here now.", 4, "First line."},
+		{"Paris has 2.1 million residents and many museums", 4, "Paris has 2.1 million..."},
+		{"It grew. Version 2.1 added more", 4, "It grew."},
+	}
+	for _, c := range cases {
+		if got := truncateAnswer(c.in, c.max); got != c.want {
+			t.Errorf("truncateAnswer(%q, %d) = %q, want %q", c.in, c.max, got, c.want)
+		}
+	}
+}
+
+func TestMaxAnswerWords(t *testing.T) {
+	scripted := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: sky", "Action: Answer"},
+		synth:   []string{"Rayleigh scattering"},
+		final:   []string{"The sky is blue. Rayleigh scattering favors short wavelengths of light strongly."},
+	}
+	var finalPrompt string
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == finalizerSystemPrompt {
+			finalPrompt = userPrompt
+		}
+		return scripted.Generate(ctx, systemPrompt, userPrompt)
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithMaxAnswerWords(8),
+	)
+	res, err := agent.Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(finalPrompt, "under 8 words") {
+		t.Fatalf("finalizer prompt lacks length instruction: %q", finalPrompt)
+	}
+	if res.Answer != "The sky is blue." {
+		t.Fatalf("expected truncated answer, got %q", res.Answer)
+	}
+}
+
+func TestStopReasonScratchpad(t *testing.T) {
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+	cases := []struct {
+		name    string
+		planner []string
+		opts    []Option
+		want    StopReason
+	}{
+		{"answered", []string{"Action: Search\nQuery: q", "Action: Answer"}, nil, StopAnswered},
+		{"max iterations", []string{"Action: Search\nQuery: a", "Action: Search\nQuery: b"}, []Option{WithMaxIterations(2)}, StopMaxIterations},
+		{"budget", []string{"Action: Search\nQuery: a", "Action: Search\nQuery: b"}, []Option{WithMaxLLMCalls(2)}, StopBudgetExceeded},
+	}
+	for _, c := range cases {
+		llm := &scriptedLLM{planner: c.planner, synth: []string{"k1", "k2"}, final: []string{"answer"}}
+		opts := append([]Option{WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher)}, c.opts...)
+		res, _ := New(opts...).Answer(context.Background(), "Q")
+		if res.StopReason != c.want {
+			t.Errorf("%s: StopReason = %q, want %q", c.name, res.StopReason, c.want)
+		}
+	}
+
+	res, err := New().Answer(context.Background(), "Q")
+	if err == nil || res.StopReason != StopError {
+		t.Errorf("misconfigured agent: StopReason = %q, err = %v", res.StopReason, err)
+	}
+}
+
+func TestIncludeReasoning(t *testing.T) {
+	scripted := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: sky", "Action: Answer"},
+		synth:   []string{"Rayleigh scattering"},
+	}
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == finalizerSystemPrompt {
+			return LLMResponse{Text: "<think>Short wavelengths scatter more.</think>Rayleigh scattering.", Reasoning: ""}, nil
+		}
+		return scripted.Generate(ctx, systemPrompt, userPrompt)
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	for _, enabled := range []bool{false, true} {
+		scripted.plannerIdx, scripted.synthIdx = 0, 0
+		agent := New(
+			WithPlannerModel(llm),
+			WithSynthesizerModel(llm),
+			WithSearchProvider(searcher),
+			WithIncludeReasoning(enabled),
+		)
+		res, err := agent.Answer(context.Background(), "Why is the sky blue?")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Answer != "Rayleigh scattering." {
+			t.Fatalf("reasoning must not leak into the answer: %q", res.Answer)
+		}
+		want := ""
+		if enabled {
+			want = "Short wavelengths scatter more."
+		}
+		if res.Reasoning != want {
+			t.Fatalf("enabled=%v: Reasoning = %q, want %q", enabled, res.Reasoning, want)
+		}
+	}
+}
+
+func TestSynthesizerResultFields(t *testing.T) {
+	results := []SearchResult{{Title: "Sky", URL: "https://example.com/sky", Snippet: "Rayleigh scattering"}}
+	pad := NewScratchpad("Why is the sky blue?")
+
+	def := buildSynthesizerUserPrompt(pad, "q", results, nil, false)
+	if !strings.Contains(def, "1. Sky | https://example.com/sky | Rayleigh scattering") {
+		t.Fatalf("default prompt should list title, url, snippet:\n%s", def)
+	}
+
+	agent := New(WithSynthesizerResultFields([]string{"Title", "bogus", "snippet"}))
+	custom := buildSynthesizerUserPrompt(pad, "q", results, agent.synthesizerFields, false)
+	if strings.Contains(custom, "example.com") {
+		t.Fatalf("url should be omitted:\n%s", custom)
+	}
+	if !strings.Contains(custom, "(title | snippet)") || !strings.Contains(custom, "1. Sky | Rayleigh scattering") {
+		t.Fatalf("unexpected custom prompt:\n%s", custom)
+	}
+}
+
+func TestResultQueries(t *testing.T) {
+	searcher := searchFunc(func(_ context.Context, query string) ([]SearchResult, error) {
+		if query == "obscure phrasing" {
+			return nil, nil
+		}
+		return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+	})
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: first query", "Action: Search\nQuery: obscure phrasing", "Action: Answer"},
+		synth:   []string{"k1", "k2"},
+		reform:  []string{"plain phrasing"},
+		final:   []string{"answer"},
+	}
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithReformulateOnEmpty(true))
+	res, err := agent.Answer(context.Background(), "Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "first query|obscure phrasing|plain phrasing"
+	if got := strings.Join(res.Queries, "|"); got != want {
+		t.Fatalf("Queries = %q, want %q", got, want)
+	}
+}
+
+func TestResultSources(t *testing.T) {
+	searcher := searchFunc(func(_ context.Context, query string) ([]SearchResult, error) {
+		return []SearchResult{
+			{Title: "t", URL: "https://example.com/" + query, Snippet: "s"},
+			{Title: "shared", URL: "https://example.com/shared", Snippet: "s"},
+		}, nil
+	})
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: a", "Action: Search\nQuery: b", "Action: Answer"},
+		synth:   []string{"k1", "k2"},
+		final:   []string{"answer"},
+	}
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher))
+	res, err := agent.Answer(context.Background(), "Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "https://example.com/a|https://example.com/shared|https://example.com/b"
+	if got := strings.Join(res.Sources, "|"); got != want {
+		t.Fatalf("Sources = %q, want %q", got, want)
+	}
+}
+
+func TestInitialSearchQuery(t *testing.T) {
+	var searched []string
+	searcher := searchFunc(func(_ context.Context, query string) ([]SearchResult, error) {
+		searched = append(searched, query)
+		return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+	})
+	run := func(opts ...AnswerOption) Result {
+		llm := &scriptedLLM{
+			planner: []string{"Action: Answer", "Action: Answer"},
+			synth:   []string{"k"},
+			final:   []string{"answer"},
+		}
+		agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher))
+		res, err := agent.Answer(context.Background(), "So I was wondering, what is the tallest mountain?", opts...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res
+	}
+
+	res := run(WithInitialSearchQuery("tallest mountain on earth"))
+	if len(searched) != 1 || searched[0] != "tallest mountain on earth" {
+		t.Fatalf("forced search used %q", searched)
+	}
+	if len(res.Queries) != 1 || res.Queries[0] != "tallest mountain on earth" {
+		t.Fatalf("Queries = %q", res.Queries)
+	}
+
+	searched = nil
+	run()
+	if len(searched) != 1 || searched[0] != "So I was wondering, what is the tallest mountain?" {
+		t.Fatalf("default forced search should use the question, got %q", searched)
+	}
+}
+
+// TestConcurrentAnswersKeepOwnKnowledge runs Answer calls with different
+// WithKnowledge values on one Agent. Run with -race to check for shared
+// per-call state.
+func TestConcurrentAnswersKeepOwnKnowledge(t *testing.T) {
+	llm := llmFunc(func(_ context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch systemPrompt {
+		case plannerSystemPrompt:
+			return LLMResponse{Text: "Action: Answer"}, nil
+		case finalizerSystemPrompt:
+			// Echo the knowledge so the test can see which run it came from.
+			return LLMResponse{Text: userPrompt}, nil
+		}
+		return LLMResponse{}, errors.New("unexpected prompt")
+	})
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(fakeSearch{}))
+
+	const runs = 8
+	answers := make([]string, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := agent.Answer(context.Background(), "Q", WithKnowledge(fmt.Sprintf("known-%d;", i)))
+			if err != nil {
+				t.Errorf("run %d: %v", i, err)
+			}
+			answers[i] = res.Answer
+		}(i)
+	}
+	wg.Wait()
+	for i, answer := range answers {
+		for j := 0; j < runs; j++ {
+			if has := strings.Contains(answer, fmt.Sprintf("known-%d;", j)); has != (i == j) {
+				t.Fatalf("run %d answer has knowledge %d = %v:\n%s", i, j, has, answer)
+			}
+		}
+	}
+}
+
+func TestSearchProviderFor(t *testing.T) {
+	var used []string
+	provider := func(name string) SearchProvider {
+		return searchFunc(func(_ context.Context, _ string) ([]SearchResult, error) {
+			used = append(used, name)
+			return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+		})
+	}
+	for _, strategy := range []string{"scratchpad", "graph-reader"} {
+		used = nil
+		scripted := &scriptedLLM{
+			planner: []string{"Action: Search\nQuery: q", "Action: Answer"},
+			synth:   []string{"k"},
+			final:   []string{"answer"},
+		}
+		graph := defaultGraphScript().llm()
+		llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+			if strategy == "graph-reader" {
+				return graph(ctx, systemPrompt, userPrompt)
+			}
+			return scripted.Generate(ctx, systemPrompt, userPrompt)
+		})
+		agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(provider("default")), WithStrategyName(strategy))
+		if _, err := agent.Answer(context.Background(), "Q", WithSearchProviderFor(provider("override"))); err != nil {
+			t.Fatalf("%s: unexpected error: %v", strategy, err)
+		}
+		if len(used) == 0 || strings.Contains(strings.Join(used, ","), "default") {
+			t.Fatalf("%s: searched with %q, want only the override", strategy, used)
+		}
+	}
+}
+
+func TestMaxIterationsForCall(t *testing.T) {
+	for _, strategy := range []string{"scratchpad", "graph-reader"} {
+		searches := 0
+		searcher := searchFunc(func(_ context.Context, _ string) ([]SearchResult, error) {
+			searches++
+			return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+		})
+		scripted := &scriptedLLM{
+			planner: []string{"Action: Search\nQuery: a", "Action: Search\nQuery: b", "Action: Search\nQuery: c"},
+			synth:   []string{"k1", "k2", "k3"},
+			final:   []string{"answer"},
+		}
+		graph := defaultGraphScript().llm()
+		llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+			if strategy == "graph-reader" {
+				return graph(ctx, systemPrompt, userPrompt)
+			}
+			return scripted.Generate(ctx, systemPrompt, userPrompt)
+		})
+		agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithStrategyName(strategy))
+
+		res, _ := agent.Answer(context.Background(), "Q", WithMaxIterationsForCall(1))
+		if searches != 1 {
+			t.Fatalf("%s: searched %d times, want 1", strategy, searches)
+		}
+		if res.Answer == "" {
+			t.Fatalf("%s: expected an answer", strategy)
+		}
+	}
+}
+
+func TestWithTimeoutAnswersFromGatheredKnowledge(t *testing.T) {
+	for _, strategy := range []string{"scratchpad", "graph-reader"} {
+		searches := 0
+		expire := make(chan struct{})
+		searcher := searchFunc(func(ctx context.Context, _ string) ([]SearchResult, error) {
+			searches++
+			if searches > 1 {
+				close(expire)
+				<-ctx.Done() // hangs until the answer timeout fires
+				return nil, ctx.Err()
+			}
+			return []SearchResult{{Title: "Paris", URL: "https://example.com", Snippet: "Paris is the capital"}}, nil
+		})
+		scripted := &scriptedLLM{
+			planner: []string{"Action: Search\nQuery: a", "Action: Search\nQuery: b"},
+			synth:   []string{"Paris is the capital of France"},
+			final:   []string{"Paris"},
+		}
+		graph := defaultGraphScript().llm()
+		llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+			if strategy == "graph-reader" {
+				return graph(ctx, systemPrompt, userPrompt)
+			}
+			return scripted.Generate(ctx, systemPrompt, userPrompt)
+		})
+		agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithStrategyName(strategy))
+		agent.withTimeout = func(ctx context.Context, _ time.Duration) (context.Context, context.CancelFunc) {
+			return expireOn(ctx, expire)
+		}
+
+		res, err := agent.Answer(context.Background(), "What is the capital of France?", WithTimeout(time.Hour))
+		if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s: err = %v, want ErrTimeout", strategy, err)
+		}
+		if res.Answer != "Paris" || res.StopReason != StopTimeout {
+			t.Fatalf("%s: answer %q, stop reason %q", strategy, res.Answer, res.StopReason)
+		}
+		if !strings.Contains(res.Knowledge, "Paris is the capital of France") {
+			t.Fatalf("%s: knowledge %q lacks the gathered fact", strategy, res.Knowledge)
+		}
+	}
+}
+
+func TestWithTimeoutCallerDeadlineFirst(t *testing.T) {
+	expire := make(chan struct{})
+	searcher := searchFunc(func(ctx context.Context, _ string) ([]SearchResult, error) {
+		close(expire)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	llm := &scriptedLLM{planner: []string{"Action: Search\nQuery: a"}}
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher))
+
+	ctx, cancel := expireOn(context.Background(), expire)
+	defer cancel()
+	_, err := agent.Answer(ctx, "Q", WithTimeout(time.Hour))
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout) {
+		t.Fatalf("err = %v, want the caller's deadline", err)
+	}
+}
+
+// expiredContext is a context whose deadline the test decides: it expires
+// with context.DeadlineExceeded when expire is closed.
+type expiredContext struct {
+	context.Context
+	done chan struct{}
+	err  error
+}
+
+// expireOn returns a child of parent that expires when expire is closed,
+// standing in for context.WithTimeout without a wall-clock deadline.
+func expireOn(parent context.Context, expire <-chan struct{}) (context.Context, context.CancelFunc) {
+	c := &expiredContext{Context: parent, done: make(chan struct{})}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-expire:
+			c.err = context.DeadlineExceeded
+		case <-parent.Done():
+			c.err = parent.Err()
+		case <-stop:
+			c.err = context.Canceled
+		}
+		close(c.done)
+	}()
+	var once sync.Once
+	return c, func() { once.Do(func() { close(stop) }) }
+}
+
+func (c *expiredContext) Done() <-chan struct{} { return c.done }
+
+func (c *expiredContext) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// seededLLM records the seed of every GenerateWithParams call.
+type seededLLM struct {
+	*scriptedLLM
+	seeds []int64
+}
+
+func (s *seededLLM) GenerateWithParams(ctx context.Context, systemPrompt, userPrompt string, params GenParams) (LLMResponse, error) {
+	if params.Seed != nil {
+		s.seeds = append(s.seeds, *params.Seed)
+	}
+	return s.Generate(ctx, systemPrompt, userPrompt)
+}
+
+func TestWithSeed(t *testing.T) {
+	llm := &seededLLM{scriptedLLM: &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: q", "Action: Answer"},
+		synth:   []string{"k"},
+		final:   []string{"answer"},
+	}}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithSeed(42))
+	if _, err := agent.Answer(context.Background(), "Q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(llm.seeds) != 4 {
+		t.Fatalf("seeded calls = %d, want 4", len(llm.seeds))
+	}
+	for _, s := range llm.seeds {
+		if s != 42 {
+			t.Fatalf("seed = %d, want 42", s)
+		}
+	}
+}
+
+func TestSearchResultLimit(t *testing.T) {
+	var results []SearchResult
+	for _, u := range []string{"https://a.example", "https://b.example", "https://c.example"} {
+		results = append(results, SearchResult{Title: u, URL: u, Snippet: "s"})
+	}
+	var prompt string
+	llm := llmFunc(func(_ context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch systemPrompt {
+		case plannerSystemPrompt:
+			if prompt == "" {
+				return LLMResponse{Text: "Action: Search\nQuery: q"}, nil
+			}
+			return LLMResponse{Text: "Action: Answer"}, nil
+		case synthesizerSystemPrompt:
+			prompt = userPrompt
+			return LLMResponse{Text: "k"}, nil
+		}
+		return LLMResponse{Text: "answer"}, nil
+	})
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(fakeSearch{results: results}), WithSearchResultLimit(2))
+	if _, err := agent.Answer(context.Background(), "Q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(prompt, "https://b.example") || strings.Contains(prompt, "https://c.example") {
+		t.Fatalf("synthesizer should see only the top 2 results:\n%s", prompt)
+	}
+}
+
+func TestQuestionPremises(t *testing.T) {
+	cases := map[string]string{
+		"Given that Acme acquired Beta last year, how does that affect Acme's revenue?": "Acme acquired Beta last year",
+		"Acme raised $1.5B in 2023. Who led the round?":                                 "Acme raised $1.5B in 2023",
+		"Why is the sky blue?":                 "",
+		"Explain how photosynthesis works.":    "",
+		"Assuming rates stay flat, what next?": "rates stay flat",
+	}
+	for q, want := range cases {
+		if got := strings.Join(questionPremises(q), "|"); got != want {
+			t.Errorf("questionPremises(%q) = %q, want %q", q, got, want)
+		}
+	}
+}
+
+func TestQuestionContextSeedsKnowledge(t *testing.T) {
+	var plannerPrompt string
+	llm := llmFunc(func(_ context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == plannerSystemPrompt {
+			plannerPrompt = userPrompt
+			return LLMResponse{Text: "Action: Answer"}, nil
+		}
+		return LLMResponse{Text: "answer"}, nil
+	})
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithQuestionContext(true))
+	res, err := agent.Answer(context.Background(), "Given that Acme acquired Beta last year, how big is Acme now?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(plannerPrompt, "Acme acquired Beta last year") || !strings.Contains(res.Knowledge, "Acme acquired Beta last year") {
+		t.Fatalf("premise missing from knowledge:\n%s", plannerPrompt)
+	}
+}
+
+func TestSynthesizerHistory(t *testing.T) {
+	var prompts []string
+	llm := llmFunc(func(_ context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch systemPrompt {
+		case plannerSystemPrompt:
+			if len(prompts) < 2 {
+				return LLMResponse{Text: fmt.Sprintf("Action: Search\nQuery: query %d", len(prompts)+1)}, nil
+			}
+			return LLMResponse{Text: "Action: Answer"}, nil
+		case synthesizerSystemPrompt:
+			prompts = append(prompts, userPrompt)
+			return LLMResponse{Text: "k"}, nil
+		}
+		return LLMResponse{Text: "answer"}, nil
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithSynthesizerHistory(true))
+	if _, err := agent.Answer(context.Background(), "Q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[1], "Research History (already covered):\nsearch[1]: query 1\n") {
+		t.Fatalf("second synthesizer prompt lacks history:\n%s", prompts[len(prompts)-1])
+	}
+	if strings.Contains(buildSynthesizerUserPrompt(Scratchpad{History: []string{"search[1]: q"}}, "q", nil, nil, false), "Research History") {
+		t.Fatal("history shown without the option")
+	}
+}
+
+func TestParsePlannerDecisionQueries(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want []string
+	}{
+		{"Action: Search\nQuery: Acme revenue", []string{"Acme revenue"}},
+		{"Action: Search\nQuery: Acme revenue\n- Beta revenue\n\nThese cover both companies.", []string{"Acme revenue", "Beta revenue"}},
+		{"Action: Search\nQuery: Acme revenue\nThis should find the annual report.", []string{"Acme revenue"}},
+		{"Action: Search\nQuery:\n1. Acme revenue\n2. Beta revenue", []string{"Acme revenue", "Beta revenue"}},
+		{"Action: Search\nQuery: [\"Acme revenue\", \"Beta revenue\", \"acme revenue\"]", []string{"Acme revenue", "Beta revenue"}},
+		{"Action: Search\nQuery: a\n- b\n* c\n- d\n- e", []string{"a", "b", "c", "d"}},
+	} {
+		d, err := parsePlannerDecision(tc.raw)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.raw, err)
+		}
+		if !reflect.DeepEqual(d.Queries, tc.want) || d.Query != tc.want[0] {
+			t.Errorf("%q: Query %q, Queries %q; want %q", tc.raw, d.Query, d.Queries, tc.want)
+		}
+	}
+}
+
+func TestScratchpadSearchesPlannerQueriesConcurrently(t *testing.T) {
+	llm := &scriptedLLM{
+		planner:     []string{"Action: Search\nQuery: Acme revenue\n- Beta revenue", "Action: Answer"},
+		synth:       []string{"Acme made $5B; Beta made $2B."},
+		final:       []string{"Acme."},
+		costPerCall: 0.01,
+	}
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	both := make(chan struct{})
+	searcher := searchFunc(func(_ context.Context, query string) ([]SearchResult, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		if inFlight == 2 {
+			close(both)
+		}
+		mu.Unlock()
+		select {
+		case <-both:
+		case <-time.After(time.Second):
+		}
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return []SearchResult{
+			{Title: query, URL: "https://example.com/" + strings.Fields(query)[0]},
+			{Title: "shared", URL: "https://example.com/shared"},
+		}, nil
+	})
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithSearchCost(0.1),
+	)
+	res, err := agent.Answer(context.Background(), "Which made more, Acme or Beta?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxInFlight != 2 {
+		t.Fatalf("searches in flight = %d, want both queries at once", maxInFlight)
+	}
+	if llm.synthIdx != 1 {
+		t.Fatalf("synthesizer calls = %d, want one for both queries", llm.synthIdx)
+	}
+	if got := strings.Join(res.Queries, "|"); got != "Acme revenue|Beta revenue" {
+		t.Fatalf("Queries = %q", got)
+	}
+	if got := strings.Join(res.Sources, " "); got != "https://example.com/Acme https://example.com/shared https://example.com/Beta" {
+		t.Fatalf("Sources = %q", got)
+	}
+	if want := 2*0.1 + 4*0.01; math.Abs(res.Cost-want) > 1e-9 {
+		t.Fatalf("Cost = %v, want %v for two searches and four LLM calls", res.Cost, want)
+	}
+}
+
+func TestScratchpadCountsSearchesThatRanBeforeAFailure(t *testing.T) {
+	llm := &scriptedLLM{planner: []string{"Action: Search\nQuery: Acme revenue\n- Beta revenue"}}
+	searcher := searchFunc(func(_ context.Context, query string) ([]SearchResult, error) {
+		if query == "Acme revenue" {
+			return nil, errors.New("backend down")
+		}
+		return []SearchResult{{Title: query, URL: "https://example.com/beta"}}, nil
+	})
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithSearchCost(0.1),
+		WithReturnPartialOnError(true),
+	)
+	res, err := agent.Answer(context.Background(), "Which made more, Acme or Beta?")
+	if err == nil {
+		t.Fatal("expected the failed search to fail the run")
+	}
+	if math.Abs(res.Cost-0.1) > 1e-9 {
+		t.Fatalf("Cost = %v, want 0.1 for the search that succeeded", res.Cost)
+	}
+}
+
+func TestCancelledContextStopsBeforeAnyWork(t *testing.T) {
+	for _, strategy := range []string{"scratchpad", "graph-reader"} {
+		calls := 0
+		llm := llmFunc(func(context.Context, string, string) (LLMResponse, error) {
+			calls++
+			return LLMResponse{Text: "Action: Search\nQuery: q"}, nil
+		})
+		searcher := searchFunc(func(context.Context, string) ([]SearchResult, error) {
+			calls++
+			return nil, nil
+		})
+		agent := New(
+			WithPlannerModel(llm),
+			WithSynthesizerModel(llm),
+			WithSearchProvider(searcher),
+			WithStrategyName(strategy),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		res, err := agent.Answer(ctx, "Why is the sky blue?")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: err = %v, want context.Canceled", strategy, err)
+		}
+		if calls != 0 {
+			t.Fatalf("%s: %d model or search calls after cancellation, want 0", strategy, calls)
+		}
+		if res.StopReason != StopError {
+			t.Fatalf("%s: StopReason = %q, want %q", strategy, res.StopReason, StopError)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package laconic
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// maxCachedAnswers bounds the WithAnswerCacheSimilarity cache; the oldest
+// answers are forgotten first.
+const maxCachedAnswers = 100
+
+// QuestionSimilarity returns how alike two questions are, from 0 to 1: the
+// Jaccard similarity of their lowercase word sets, ignoring punctuation.
+// Unlike TitleSimilarity a question that adds words to another scores below
+// 1, so "population of Paris" and "population of Paris in 1900" differ.
+func QuestionSimilarity(a, b string) float64 {
+	ta, tb := titleTokens(a), titleTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for tok := range ta {
+		if tb[tok] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+type cachedAnswer struct {
+	question string
+	result   Result
+}
+
+// answerCache remembers an Agent's recent successful answers for
+// WithAnswerCacheSimilarity.
+type answerCache struct {
+	mu      sync.Mutex
+	entries []cachedAnswer
+}
+
+// lookup returns the cached result whose question is most similar to
+// question, if that similarity reaches threshold.
+func (c *answerCache) lookup(question string, threshold float64, similarity func(a, b string) float64) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	best, bestScore := -1, threshold
+	for i, e := range c.entries {
+		if score := similarity(question, e.question); score >= bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return Result{}, false
+	}
+	return cloneResult(c.entries[best].result), true
+}
+
+// store remembers res for question when the run finished with a complete
+// answer; runs cut short by a budget or deadline, or salvaged from raw
+// knowledge, are not reused.
+func (c *answerCache) store(question string, res Result) {
+	switch res.StopReason {
+	case StopAnswered, StopAnswerCheckPassed, StopMaxSteps, StopQueueExhausted:
+	default:
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedAnswers {
+		c.entries = c.entries[1:]
+	}
+	c.entries = append(c.entries, cachedAnswer{question: question, result: cloneResult(res)})
+}
+
+// cloneResult copies res deeply enough that the cache and its callers never
+// share slices or maps.
+func cloneResult(res Result) Result {
+	res.Unsupported = slices.Clone(res.Unsupported)
+	res.Queries = slices.Clone(res.Queries)
+	res.Sources = slices.Clone(res.Sources)
+	res.CostBreakdown = maps.Clone(res.CostBreakdown)
+	res.Timings.Stages = maps.Clone(res.Timings.Stages)
+	if res.RawResponses != nil {
+		raw := make(map[string][]string, len(res.RawResponses))
+		for stage, texts := range res.RawResponses {
+			raw[stage] = slices.Clone(texts)
+		}
+		res.RawResponses = raw
+	}
+	if res.Summary != nil {
+		summary := *res.Summary
+		summary.KeyFacts = slices.Clone(summary.KeyFacts)
+		summary.Entities = slices.Clone(summary.Entities)
+		res.Summary = &summary
+	}
+	return res
+}
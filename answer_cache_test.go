@@ -0,0 +1,113 @@
+package laconic
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQuestionSimilarity(t *testing.T) {
+	if got := QuestionSimilarity("What is the population of Paris?", "what is the population of paris"); got != 1 {
+		t.Errorf("same question scored %v, want 1", got)
+	}
+	if got := QuestionSimilarity("population of Paris", "population of Paris in 1900"); got >= 0.8 {
+		t.Errorf("narrower question scored %v, want below 0.8", got)
+	}
+	if got := QuestionSimilarity("", "anything"); got != 0 {
+		t.Errorf("empty question scored %v, want 0", got)
+	}
+}
+
+func TestAnswerCacheSimilarity(t *testing.T) {
+	var searches atomic.Int32
+	searcher := searchFunc(func(_ context.Context, _ string) ([]SearchResult, error) {
+		searches.Add(1)
+		return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+	})
+	llm := batchLLM([]string{"Paris", "Berlin"})
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher),
+		WithAnswerCacheSimilarity(0.8))
+	ctx := context.Background()
+
+	first, err := agent.Answer(ctx, "What is the population of Paris?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Cached || first.Cost == 0 {
+		t.Fatalf("first answer: cached=%v cost=%v, want a fresh, costed run", first.Cached, first.Cost)
+	}
+
+	again, err := agent.Answer(ctx, "what is the population of Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Cached || again.Cost != 0 || again.Answer != first.Answer {
+		t.Errorf("similar question: got %+v, want the cached answer %q at no cost", again, first.Answer)
+	}
+	if n := searches.Load(); n != 1 {
+		t.Errorf("searched %d times, want 1", n)
+	}
+
+	other, err := agent.Answer(ctx, "What is the population of Berlin?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Cached || !strings.Contains(other.Answer, "Berlin") {
+		t.Errorf("different question: got %+v, want a fresh answer about Berlin", other)
+	}
+}
+
+func TestAnswerCacheSimilarityFunc(t *testing.T) {
+	var searches atomic.Int32
+	searcher := searchFunc(func(_ context.Context, _ string) ([]SearchResult, error) {
+		searches.Add(1)
+		return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+	})
+	llm := batchLLM([]string{"Paris", "France"})
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher),
+		WithAnswerCacheSimilarity(0.5),
+		WithAnswerCacheSimilarityFunc(func(a, b string) float64 { return 1 }))
+
+	first, _ := agent.Answer(context.Background(), "Capital of France?")
+	res, err := agent.Answer(context.Background(), "Where is Paris?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Cached || res.Answer != first.Answer {
+		t.Errorf("got %+v, want the custom similarity to reuse %q", res, first.Answer)
+	}
+	if n := searches.Load(); n != 1 {
+		t.Errorf("searched %d times, want 1", n)
+	}
+}
+
+func TestAnswerCacheCopiesResults(t *testing.T) {
+	var c answerCache
+	res := Result{Answer: "Paris", StopReason: StopAnswered, Queries: []string{"q"}, RawResponses: map[string][]string{"planner": {"p"}}}
+	c.store("Q", res)
+	res.Queries[0] = "changed"
+
+	hit, ok := c.lookup("Q", 1, QuestionSimilarity)
+	if !ok || hit.Queries[0] != "q" {
+		t.Fatalf("hit = %+v, want the stored queries", hit)
+	}
+	hit.Queries[0] = "changed"
+	hit.RawResponses["planner"][0] = "changed"
+	hit.Sources = append(hit.Sources, "u")
+
+	again, _ := c.lookup("Q", 1, QuestionSimilarity)
+	if again.Queries[0] != "q" || again.RawResponses["planner"][0] != "p" || len(again.Sources) != 0 {
+		t.Fatalf("editing a hit changed the cache: %+v", again)
+	}
+}
+
+func TestAnswerCacheSkipsPartialAnswers(t *testing.T) {
+	var c answerCache
+	for _, reason := range []StopReason{StopBudgetExceeded, StopTimeout, StopSalvaged, StopMaxIterations} {
+		c.store("Q", Result{Answer: "partial", StopReason: reason})
+	}
+	if hit, ok := c.lookup("Q", 1, QuestionSimilarity); ok {
+		t.Fatalf("cached a partial answer: %+v", hit)
+	}
+}
@@ -0,0 +1,93 @@
+package laconic
+
+import (
+	"strings"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+// answerCheckStopwords are words ignored when matching key elements to facts.
+var answerCheckStopwords = map[string]bool{ //nolint:gochecknoglobals
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true, "was": true,
+	"were": true, "what": true, "when": true, "where": true, "which": true,
+	"who": true, "with": true,
+}
+
+// questionStopwords are interrogative words that never appear in an answer,
+// ignored on top of answerCheckStopwords when matching a whole question.
+var questionStopwords = map[string]bool{ //nolint:gochecknoglobals
+	"can": true, "did": true, "do": true, "does": true, "has": true,
+	"have": true, "how": true, "many": true, "much": true, "whom": true,
+	"whose": true, "why": true,
+}
+
+// KeywordCoverage is a GraphReaderConfig.AnswerCheck that reports whether
+// every key element of the plan is covered by some fact, meaning one fact
+// contains all of the element's significant words. It makes no LLM call. A
+// plan without key elements is never considered answered, so research
+// continues until MaxSteps or the queue runs out.
+func KeywordCoverage(state *graph.AgentState) bool {
+	if len(state.Plan.KeyElements) == 0 {
+		return false
+	}
+	facts := make([]map[string]bool, len(state.Notebook.Clues))
+	for i, c := range state.Notebook.Clues {
+		facts[i] = titleTokens(c.Content)
+	}
+	for _, element := range state.Plan.KeyElements {
+		if !elementCovered(element, facts) {
+			return false
+		}
+	}
+	return true
+}
+
+// elementCovered reports whether one fact's words include every
+// significant word of element.
+func elementCovered(element string, facts []map[string]bool) bool {
+	var words []string
+	for w := range titleTokens(element) {
+		if !answerCheckStopwords[w] {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return true
+	}
+	for _, fact := range facts {
+		covered := true
+		for _, w := range words {
+			if !fact[w] {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// knowledgeCoversQuestion is the WithEarlyExit check: it reports whether a
+// single line of knowledge contains every significant word of question.
+func knowledgeCoversQuestion(question, knowledge string) bool {
+	var element []string
+	for w := range titleTokens(question) {
+		if !questionStopwords[w] && !answerCheckStopwords[w] {
+			element = append(element, w)
+		}
+	}
+	if len(element) == 0 {
+		return false
+	}
+	var lines []map[string]bool
+	for _, line := range strings.Split(knowledge, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, titleTokens(line))
+		}
+	}
+	return elementCovered(strings.Join(element, " "), lines)
+}
@@ -0,0 +1,47 @@
+package laconic
+
+import (
+	"testing"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+func TestKeywordCoverage(t *testing.T) {
+	state := graph.NewAgentState("Q")
+	state.Plan.KeyElements = []string{"Population of Paris", "Area of the city"}
+	state.Notebook.Clues = []graph.AtomicFact{
+		{Content: "Paris had a population of 2.1 million in 2020."},
+		{Content: "The city covers 105 square km."},
+	}
+	if KeywordCoverage(state) {
+		t.Fatal("\"area\" appears in no fact")
+	}
+	state.Notebook.Clues = append(state.Notebook.Clues, graph.AtomicFact{Content: "The area of the city is 105 km2."})
+	if !KeywordCoverage(state) {
+		t.Fatal("every key element is covered")
+	}
+	// Words must appear together in one fact.
+	state.Plan.KeyElements = []string{"Paris area"}
+	if KeywordCoverage(state) {
+		t.Fatal("\"Paris\" and \"area\" are in different facts")
+	}
+	state.Plan.KeyElements = nil
+	if KeywordCoverage(state) {
+		t.Fatal("a plan without key elements is never covered")
+	}
+}
+
+func TestKnowledgeCoversQuestion(t *testing.T) {
+	knowledge := "Paris is the capital of France.\nIts population is 2.1 million."
+	cases := map[string]bool{
+		"What is the capital of France?":   true,
+		"How many people live in France?":  false, // "people", "live" missing
+		"What is the population of Paris?": false, // split across lines
+		"Who is it?":                       false, // nothing significant to match
+	}
+	for q, want := range cases {
+		if got := knowledgeCoversQuestion(q, knowledge); got != want {
+			t.Errorf("knowledgeCoversQuestion(%q) = %v, want %v", q, got, want)
+		}
+	}
+}
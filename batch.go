@@ -0,0 +1,64 @@
+package laconic
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchResult is the outcome of one question answered by AnswerBatch.
+type BatchResult struct {
+	Question string
+	Result   Result
+	Err      error
+}
+
+// AnswerBatch answers questions with up to concurrency runs in flight and
+// returns one BatchResult per question, in the same order. Each run has its
+// own call budget; the providers' rate limits are shared as usual. When ctx
+// is cancelled or its deadline passes, runs in flight stop with the context
+// error and questions not yet started are returned with that error, so the
+// completed answers are kept.
+func (a *Agent) AnswerBatch(ctx context.Context, questions []string, concurrency int) []BatchResult {
+	out := make([]BatchResult, len(questions))
+	for i, q := range questions {
+		out[i].Question = q
+	}
+	strategy, err := a.resolveStrategy()
+	if err != nil {
+		for i := range out {
+			out[i].Result, out[i].Err = Result{StopReason: StopError}, err
+		}
+		return out
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(questions); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				out[i].Result, out[i].Err = a.run(ctx, strategy, questions[i], answerConfig{})
+			}
+		}()
+	}
+	started := 0
+feed:
+	for ; started < len(questions); started++ {
+		select {
+		case next <- started:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	for i := started; i < len(questions); i++ {
+		out[i].Result, out[i].Err = Result{StopReason: stopReasonFor(ctx.Err())}, ctx.Err()
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package laconic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchLLM answers every question in one search without shared state, so it
+// is safe for concurrent runs. The finalizer echoes the question's tag.
+func batchLLM(tags []string) llmFunc {
+	return func(_ context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch systemPrompt {
+		case plannerSystemPrompt:
+			if strings.Contains(userPrompt, "KNOWN") {
+				return LLMResponse{Text: "Action: Answer"}, nil
+			}
+			return LLMResponse{Text: "Action: Search\nQuery: q"}, nil
+		case synthesizerSystemPrompt:
+			return LLMResponse{Text: "KNOWN"}, nil
+		case finalizerSystemPrompt:
+			for _, tag := range tags {
+				if strings.Contains(userPrompt, tag) {
+					return LLMResponse{Text: "answer " + tag, Cost: 0.01}, nil
+				}
+			}
+		}
+		return LLMResponse{}, errors.New("unexpected prompt")
+	}
+}
+
+func TestAnswerBatch(t *testing.T) {
+	var questions []string
+	for i := 0; i < 6; i++ {
+		questions = append(questions, fmt.Sprintf("tag-%c?", 'a'+i))
+	}
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	// Searches wait until three run at once, so the limit must be reached
+	// for the batch to make progress.
+	full := make(chan struct{})
+	var once sync.Once
+	searcher := searchFunc(func(_ context.Context, _ string) ([]SearchResult, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		if inFlight == 3 {
+			once.Do(func() { close(full) })
+		}
+		mu.Unlock()
+		select {
+		case <-full:
+		case <-time.After(10 * time.Second):
+			t.Error("three questions never ran at once")
+		}
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+	})
+	llm := batchLLM(questions)
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher))
+
+	results := agent.AnswerBatch(context.Background(), questions, 3)
+	if len(results) != len(questions) {
+		t.Fatalf("got %d results, want %d", len(results), len(questions))
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("question %d: %v", i, r.Err)
+		}
+		if r.Question != questions[i] || r.Result.Answer != "answer "+questions[i] {
+			t.Fatalf("result %d = %q / %q, out of order", i, r.Question, r.Result.Answer)
+		}
+	}
+	if maxInFlight != 3 {
+		t.Fatalf("max concurrent runs = %d, want 3", maxInFlight)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range agent.AnswerBatch(ctx, questions, 2) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Fatalf("cancelled batch: %q got %v", r.Question, r.Err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package laconic
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+// minTitleTokens is the smallest title, in words, that may be matched by
+// overlap. Shorter titles ("Home", "About us") only match exactly.
+const minTitleTokens = 3
+
+// TitleSimilarity returns how alike two result titles are, from 0 to 1. Titles
+// are lowercased and split into words, ignoring punctuation; the score is the
+// share of the shorter title's words that also appear in the longer one, so a
+// syndicated headline with a " - Site Name" suffix still scores highly.
+func TitleSimilarity(a, b string) float64 {
+	ta, tb := titleTokens(a), titleTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	if len(ta) > len(tb) {
+		ta, tb = tb, ta
+	}
+	shared := 0
+	for tok := range ta {
+		if tb[tok] {
+			shared++
+		}
+	}
+	if len(ta) < minTitleTokens && (shared < len(ta) || len(ta) != len(tb)) {
+		return 0
+	}
+	return float64(shared) / float64(len(ta))
+}
+
+// DedupeByTitle drops results whose title is at least threshold similar (see
+// TitleSimilarity) to an earlier result's. Each group of near-duplicates keeps
+// the instance with the highest Score, in the position of the group's first
+// member. A threshold outside (0, 1] returns results unchanged.
+func DedupeByTitle(results []SearchResult, threshold float64) []SearchResult {
+	if threshold <= 0 || threshold > 1 || len(results) < 2 {
+		return results
+	}
+	kept := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		dup := -1
+		for i := range kept {
+			if TitleSimilarity(kept[i].Title, r.Title) >= threshold {
+				dup = i
+				break
+			}
+		}
+		switch {
+		case dup < 0:
+			kept = append(kept, r)
+		case r.Score > kept[dup].Score:
+			kept[dup] = r
+		}
+	}
+	return kept
+}
+
+// titleTokens returns the set of lowercase words in title.
+func titleTokens(title string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// FactSimilarity returns how alike two facts are, from 0 to 1: the Jaccard
+// similarity of their sets of lowercase words, ignoring punctuation around
+// words. Facts that mention different numbers score 0 however much else they
+// share, so "Acme revenue $5B" never matches "Acme revenue $6B in 2024", and
+// a short fact is not swallowed by a longer one that merely contains it.
+func FactSimilarity(a, b string) float64 {
+	return tokenSimilarity(graph.FactTokens(a), graph.FactTokens(b))
+}
+
+// tokenSimilarity is FactSimilarity over word sets from graph.FactTokens.
+func tokenSimilarity(ta, tb map[string]bool) float64 {
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for tok := range ta {
+		if tb[tok] {
+			shared++
+		} else if hasDigit(tok) {
+			return 0
+		}
+	}
+	for tok := range tb {
+		if !ta[tok] && hasDigit(tok) {
+			return 0
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// hasDigit reports whether s contains a digit.
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+// prepareResults tags each result's URL kind, drops near-duplicate titles
+// when WithTitleDedup is set, and keeps the top WithSearchResultLimit results
+// before they reach the models.
+func (a *Agent) prepareResults(results []SearchResult) []SearchResult {
+	tagResults(results)
+	results = DedupeByTitle(results, a.titleDedup)
+	if a.searchResultLimit > 0 && len(results) > a.searchResultLimit {
+		results = results[:a.searchResultLimit]
+	}
+	return results
+}
+
+// diverseResults keeps at most perDomain results from each domain and
+// reorders them so every domain's best result comes before any domain's
+// second, letting a prolific site fill only the budget others leave unused.
+// Results with no domain are kept in their own slots.
+func diverseResults(results []SearchResult, perDomain int) []SearchResult {
+	byDomain := make(map[string]int)
+	rounds := make([][]SearchResult, perDomain)
+	for _, r := range results {
+		round := 0
+		if r.Domain != "" {
+			round = byDomain[r.Domain]
+			if round >= perDomain {
+				continue
+			}
+			byDomain[r.Domain]++
+		}
+		rounds[round] = append(rounds[round], r)
+	}
+	out := make([]SearchResult, 0, len(results))
+	for _, round := range rounds {
+		out = append(out, round...)
+	}
+	return out
+}
+
+// appendSources adds the URLs of results not already in sources.
+func appendSources(sources []string, results []SearchResult) []string {
+	for _, r := range results {
+		sources = appendSource(sources, r.URL)
+	}
+	return sources
+}
+
+// factSources returns the distinct source URLs of facts in order.
+func factSources(facts []graph.AtomicFact) []string {
+	var sources []string
+	for _, f := range facts {
+		sources = appendSource(sources, f.SourceURL)
+	}
+	return sources
+}
+
+func appendSource(sources []string, url string) []string {
+	url = strings.TrimSpace(url)
+	if url == "" || slices.Contains(sources, url) {
+		return sources
+	}
+	return append(sources, url)
+}
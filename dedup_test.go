@@ -0,0 +1,100 @@
+package laconic
+
+import (
+	"testing"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+func TestDedupeByTitle(t *testing.T) {
+	results := []SearchResult{
+		{Title: "Storm batters coast, thousands without power", URL: "https://a.example/1", Score: 0.4},
+		{Title: "Home", URL: "https://b.example/"},
+		{Title: "Storm batters coast; thousands without power - Daily Herald", URL: "https://c.example/2", Score: 0.9},
+		{Title: "Home page", URL: "https://d.example/"},
+		{Title: "Markets rally after rate decision", URL: "https://e.example/3"},
+	}
+	got := DedupeByTitle(results, 0.8)
+	var urls []string
+	for _, r := range got {
+		urls = append(urls, r.URL)
+	}
+	want := []string{"https://c.example/2", "https://b.example/", "https://d.example/", "https://e.example/3"}
+	if len(urls) != len(want) {
+		t.Fatalf("urls = %v, want %v", urls, want)
+	}
+	for i := range want {
+		if urls[i] != want[i] {
+			t.Fatalf("urls = %v, want %v", urls, want)
+		}
+	}
+
+	if n := len(DedupeByTitle(results, 0)); n != len(results) {
+		t.Fatalf("threshold 0 should disable dedup, got %d results", n)
+	}
+}
+
+func TestFactSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		dup  bool
+	}{
+		{"Acme revenue $5B", "Acme revenue $6B in 2024", false},
+		{"Acme revenue $5B", "Acme revenue $5B in 2024", false},
+		{"Revenue grew", "Revenue grew 12% in the third quarter", false},
+		{"Revenue grew", "Revenue grew 12%", false},
+		{"Acme revenue was $5B", "acme revenue was $5B.", true},
+		{"Paris has 2.1 million residents", "paris  has 2.1 million\tresidents", true},
+		{"The Seine crosses Paris", "The Seine river crosses Paris", true},
+	}
+	for _, tt := range tests {
+		if got := FactSimilarity(tt.a, tt.b) >= defaultFactDedupThreshold; got != tt.dup {
+			t.Errorf("FactSimilarity(%q, %q) = %.2f, duplicate %v, want %v", tt.a, tt.b, FactSimilarity(tt.a, tt.b), got, tt.dup)
+		}
+	}
+}
+
+func TestFactDedupKeepsDistinctFigures(t *testing.T) {
+	clues := []graph.AtomicFact{{Content: "Acme revenue $5B"}, {Content: "Acme revenue $6B in 2024"}}
+	if got := deduplicateFactTexts(clues, defaultFactDedupThreshold); len(got) != 2 {
+		t.Fatalf("deduplicateFactTexts = %q, want both facts", got)
+	}
+
+	strategy, err := newGraphReaderStrategy(New(WithPlannerModel(llmFunc(nil))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state := graph.NewAgentState("Q")
+	strategy.(*graphReaderStrategy).addFacts(state, 0, clues)
+	if len(state.Notebook.Clues) != 2 {
+		t.Fatalf("addFacts kept %+v, want both facts", state.Notebook.Clues)
+	}
+}
+
+func TestDiverseResults(t *testing.T) {
+	var results []SearchResult
+	for _, u := range []string{
+		"https://www.big.example/1", "https://big.example/2", "https://big.example/3",
+		"https://small.example/a", "https://big.example/4", "not a url", "https://other.example/x",
+	} {
+		results = append(results, SearchResult{URL: u})
+	}
+	tagResults(results)
+	got := diverseResults(results, 2)
+	var urls []string
+	for _, r := range got {
+		urls = append(urls, r.URL)
+	}
+	want := []string{
+		"https://www.big.example/1", "https://small.example/a", "not a url", "https://other.example/x",
+		"https://big.example/2",
+	}
+	if len(urls) != len(want) {
+		t.Fatalf("urls = %v, want %v", urls, want)
+	}
+	for i := range want {
+		if urls[i] != want[i] {
+			t.Fatalf("urls = %v, want %v", urls, want)
+		}
+	}
+}
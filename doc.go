@@ -57,7 +57,8 @@
 //	    Search(ctx context.Context, query string) ([]SearchResult, error)
 //	}
 //
-// Strategy options include the default scratchpad loop and the graph-based
-// "graph-reader" strategy.
+// Strategy options include the default scratchpad loop, the graph-based
+// "graph-reader" strategy, and "deep-read", which reads the top result of each
+// planned query in full.
 // See the examples/basic directory for a complete example.
 package laconic
@@ -0,0 +1,81 @@
+// Command fixtures runs the graph-reader entirely offline: a scripted model,
+// a canned search provider and fetch.Static pages. Use the same pieces to
+// write deterministic tests of your own configuration.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/smhanov/laconic"
+	"github.com/smhanov/laconic/fetch"
+)
+
+const pageURL = "https://example.com/paris"
+
+// cannedSearch returns the same result for every query.
+type cannedSearch struct{}
+
+func (cannedSearch) Search(_ context.Context, _ string) ([]laconic.SearchResult, error) {
+	return []laconic.SearchResult{
+		{Title: "Paris - facts and figures", URL: pageURL, Snippet: "Paris is the capital of France."},
+	}, nil
+}
+
+// scriptedLLM answers each graph-reader stage with fixed JSON, recognizing
+// the stage from its system prompt.
+type scriptedLLM struct{}
+
+func (scriptedLLM) Generate(_ context.Context, systemPrompt, userPrompt string) (laconic.LLMResponse, error) {
+	var text string
+	switch {
+	case strings.Contains(systemPrompt, "research planner") && strings.Contains(userPrompt, "User Question:"):
+		text = `{"research_goal": "Find the population of Paris", "strategy": ["search"], "key_elements": ["Paris population"]}`
+	case strings.Contains(systemPrompt, "research planner"):
+		text = `["population of Paris"]`
+	case strings.Contains(systemPrompt, "data extraction") && strings.Contains(userPrompt, pageURL+"\n"):
+		// The full page read from fetch.Static.
+		text = `{"new_facts": [{"content": "Paris had 2,102,650 residents in 2023", "source_url": "` + pageURL + `"}]}`
+	case strings.Contains(systemPrompt, "data extraction"):
+		// The snippet only names the capital, so ask to read the page.
+		text = `{"new_facts": [{"content": "Paris is the capital of France", "source_url": "` + pageURL + `"}], "read_more_urls": ["` + pageURL + `"]}`
+	case strings.Contains(systemPrompt, "research navigator"):
+		text = `[]`
+	case strings.Contains(systemPrompt, "research validator"):
+		text = `{"can_answer": true}`
+	default:
+		text = "Paris had about 2.1 million residents in 2023."
+	}
+	return laconic.LLMResponse{Text: text}, nil
+}
+
+func main() {
+	pages := fetch.Static{
+		pageURL: "Paris is the capital and most populous city of France. " +
+			"According to the 2023 census the city proper had 2,102,650 residents, " +
+			"while the wider metropolitan area was home to more than 13 million people. " +
+			"The city covers 105 square kilometres along the Seine.",
+	}
+	// fetch.Func wraps any function; here it logs each page read.
+	fetcher := fetch.Func(func(ctx context.Context, url string) (string, error) {
+		fmt.Println("fetching", url)
+		return pages.Fetch(ctx, url)
+	})
+
+	agent := laconic.New(
+		laconic.WithPlannerModel(scriptedLLM{}),
+		laconic.WithSynthesizerModel(scriptedLLM{}),
+		laconic.WithSearchProvider(cannedSearch{}),
+		laconic.WithFetchProvider(fetcher),
+		laconic.WithStrategyName("graph-reader"),
+	)
+
+	result, err := agent.Answer(context.Background(), "How many people live in Paris?")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.Answer)
+	fmt.Println(result.Knowledge)
+}
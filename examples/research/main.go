@@ -84,7 +84,7 @@ func main() {
 	model := flag.String("model", "", "Model name to use (required)")
 	promptFile := flag.String("prompt", "", "Path to prompt file (required)")
 	maxIterations := flag.Int("max-iterations", 5, "Maximum search iterations")
-	strategy := flag.String("strategy", "scratchpad", "Strategy to use: scratchpad or graph-reader")
+	strategy := flag.String("strategy", "scratchpad", "Strategy to use: "+strings.Join(laconic.New().Strategies(), ", "))
 	graphSteps := flag.Int("graph-max-steps", 8, "Maximum steps for graph-reader strategy")
 	searchProvider := flag.String("search", "duckduckgo", "Search provider: duckduckgo or brave")
 	braveKey := flag.String("brave-key", "", "Brave Search API key (required when -search=brave)")
@@ -154,6 +154,13 @@ func main() {
 		searcher = search.NewDuckDuckGo()
 	}
 
+	// Fail fast on bad API keys rather than on the first real query.
+	if v, ok := searcher.(laconic.SearchValidator); ok {
+		if err := v.Validate(context.Background()); err != nil {
+			log.Fatalf("Error validating search provider: %v", err)
+		}
+	}
+
 	agent := laconic.New(
 		laconic.WithPlannerModel(llm),
 		laconic.WithSynthesizerModel(llm),
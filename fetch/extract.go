@@ -0,0 +1,104 @@
+package fetch
+
+import (
+	"mime"
+	"strings"
+)
+
+// Extractor turns a response body into the plain text handed to the model.
+// contentType is the response's Content-Type header, parameters included.
+type Extractor interface {
+	Extract(contentType string, body []byte) (string, error)
+}
+
+// ExtractorFunc adapts a function to the Extractor interface.
+type ExtractorFunc func(contentType string, body []byte) (string, error)
+
+// Extract calls f.
+func (f ExtractorFunc) Extract(contentType string, body []byte) (string, error) {
+	return f(contentType, body)
+}
+
+var (
+	// HTMLExtractor strips scripts, styles, navigation and markup from an
+	// HTML page.
+	HTMLExtractor Extractor = ExtractorFunc(func(_ string, body []byte) (string, error) {
+		return stripHTML(string(body)), nil
+	})
+	// TextExtractor returns the body unchanged apart from surrounding
+	// whitespace.
+	TextExtractor Extractor = ExtractorFunc(func(_ string, body []byte) (string, error) {
+		return strings.TrimSpace(string(body)), nil
+	})
+)
+
+// WithExtractor makes the fetcher read responses of contentType with e,
+// replacing any default. contentType is a media type such as
+// "application/pdf", a wildcard such as "text/*" (or "*/*" for every typed
+// response), a structured-syntax suffix such as "+json", or "" for responses
+// without a Content-Type. A nil e removes the registration; matching
+// responses then fall back to a broader entry or fail with
+// ErrUnsupportedContent. An extractor set here for an HTML type takes
+// precedence over WithReadability, whatever the order of the options.
+func WithExtractor(contentType string, e Extractor) HTTPOption {
+	key := strings.ToLower(strings.TrimSpace(contentType))
+	return func(f *HTTPFetcher) {
+		if f.custom == nil {
+			f.custom = make(map[string]bool)
+		}
+		f.custom[key] = true
+		if e == nil {
+			delete(f.extractors, key)
+			return
+		}
+		f.extractors[key] = e
+	}
+}
+
+// defaultExtractors reads HTML (and untyped bodies) with HTMLExtractor, PDFs
+// with PDFExtractor, and other textual types with TextExtractor.
+func defaultExtractors() map[string]Extractor {
+	return map[string]Extractor{
+		"":                      HTMLExtractor,
+		"text/html":             HTMLExtractor,
+		"application/xhtml+xml": HTMLExtractor,
+		"text/*":                TextExtractor,
+		"application/json":      TextExtractor,
+		"application/xml":       TextExtractor,
+		"+xml":                  TextExtractor,
+		"application/pdf":       PDFExtractor,
+		"application/x-pdf":     PDFExtractor,
+	}
+}
+
+// extractor finds the Extractor for contentType, trying the exact media
+// type, then its structured-syntax suffix, then its "type/*" wildcard, then
+// "*/*". It also returns the media type for error messages. An HTTPFetcher
+// built as a struct literal uses the defaults.
+func (f *HTTPFetcher) extractor(contentType string) (Extractor, string) {
+	extractors := f.extractors
+	if extractors == nil {
+		extractors = defaultExtractors()
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+	if e, ok := extractors[mediaType]; ok {
+		return e, mediaType
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		if e, ok := extractors[mediaType[i:]]; ok {
+			return e, mediaType
+		}
+	}
+	if i := strings.Index(mediaType, "/"); i >= 0 {
+		if e, ok := extractors[mediaType[:i]+"/*"]; ok {
+			return e, mediaType
+		}
+		if e, ok := extractors["*/*"]; ok {
+			return e, mediaType
+		}
+	}
+	return nil, mediaType
+}
@@ -0,0 +1,86 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// serveTyped serves body with the Content-Type given in the "type" query
+// parameter, or with none when it is absent.
+func serveTyped(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.URL.Query().Get("type"); ct != "" {
+			w.Header().Set("Content-Type", ct)
+		} else {
+			w.Header()["Content-Type"] = nil // keep net/http from sniffing one
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// labelled returns an Extractor that reports its label, to show which
+// registration handled a response.
+func labelled(label string) Extractor {
+	return ExtractorFunc(func(string, []byte) (string, error) { return label, nil })
+}
+
+func TestWithExtractorDispatch(t *testing.T) {
+	srv := serveTyped(t, "<p>body</p>")
+	f := NewHTTP(
+		WithExtractor("application/vnd.acme", labelled("exact")),
+		WithExtractor("+json", labelled("suffix")),
+		WithExtractor("image/*", labelled("wildcard")),
+		WithExtractor("*/*", labelled("any")),
+		WithExtractor("text/plain", labelled("params ignored")),
+	)
+	for ct, want := range map[string]string{
+		"application/vnd.acme":          "exact",
+		"APPLICATION/VND.ACME":          "exact",
+		"application/problem+json":      "suffix",
+		"application/json":              "<p>body</p>", // the exact default wins over the suffix
+		"application/atom+xml":          "<p>body</p>", // the default "+xml" entry
+		"image/png":                     "wildcard",
+		"video/mp4":                     "any",
+		"text/plain; charset=utf-8":     "params ignored",
+		"text/html; charset=iso-8859-1": "body",
+	} {
+		got, err := f.Fetch(context.Background(), srv.URL+"?type="+url.QueryEscape(ct))
+		if err != nil {
+			t.Errorf("%s: %v", ct, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", ct, got, want)
+		}
+	}
+}
+
+func TestWithExtractorNilRemoves(t *testing.T) {
+	srv := serveTyped(t, "<p>body</p>")
+	ctx := context.Background()
+
+	// Removing an exact entry falls back to a broader one.
+	f := NewHTTP(WithExtractor("text/*", labelled("wildcard")), WithExtractor("text/html", nil))
+	if got, err := f.Fetch(ctx, srv.URL+"?type=text/html"); err != nil || got != "wildcard" {
+		t.Fatalf("text/html: got %q, %v; want the text/* extractor", got, err)
+	}
+
+	// With nothing left to fall back on the type is unsupported.
+	f = NewHTTP(WithExtractor("application/pdf", nil))
+	if _, err := f.Fetch(ctx, srv.URL+"?type=application/pdf"); !errors.Is(err, ErrUnsupportedContent) {
+		t.Fatalf("application/pdf: err = %v, want ErrUnsupportedContent", err)
+	}
+
+	// Untyped responses use the "" entry.
+	f = NewHTTP(WithExtractor("", labelled("untyped")))
+	if got, err := f.Fetch(ctx, srv.URL); err != nil || got != "untyped" {
+		t.Fatalf("untyped: got %q, %v", got, err)
+	}
+}
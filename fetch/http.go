@@ -4,64 +4,160 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/smhanov/laconic/internal/useragent"
 )
 
-const maxFetchBytes = 32 * 1024 // 32KB limit to avoid overwhelming LLM context
+// maxFetchBytes is the default cap on returned text: 32KB, to avoid
+// overwhelming LLM context. See WithMaxBytes.
+const maxFetchBytes = 32 * 1024
+
+// ErrUnsupportedContent is returned when a URL serves a document type the
+// fetcher cannot turn into text, such as an office file or a scanned PDF.
+var ErrUnsupportedContent = errors.New("fetch: unsupported content type")
 
 // HTTPFetcher retrieves raw text from a URL.
 type HTTPFetcher struct {
-	client *http.Client
+	client     *http.Client
+	agents     *useragent.Pool
+	extractors map[string]Extractor
+	custom     map[string]bool // content types set with WithExtractor
+	maxBytes   int
+	maxRetries int
+	retryDelay time.Duration // first backoff delay; doubles on each retry
+	robots     *robotsCache  // nil unless WithRobots is set
+}
+
+// HTTPOption configures an HTTPFetcher.
+type HTTPOption func(*HTTPFetcher)
+
+// WithUserAgentPool makes each request use a User-Agent picked at random
+// from agents. Without this option a small built-in pool of desktop browser
+// User-Agents is used. The pool is not used with WithRobots.
+func WithUserAgentPool(agents []string) HTTPOption {
+	return func(f *HTTPFetcher) {
+		deterministic := f.agents.Deterministic
+		f.agents = useragent.New(agents)
+		f.agents.Deterministic = deterministic
+	}
+}
+
+// WithDeterministic replaces random User-Agent choice with a fixed rotation
+// so tests see the same requests on every run.
+func WithDeterministic(enabled bool) HTTPOption {
+	return func(f *HTTPFetcher) { f.agents.Deterministic = enabled }
+}
+
+// WithMaxBytes sets how many bytes of extracted text Fetch returns before
+// cutting the rest and appending a "[TRUNCATED]" marker. The default is
+// 32KB; raise it (to 128KB, say) for models with larger context so the
+// graph-reader can read long articles in full. Everything returned is sent
+// to the model, so larger values raise token cost downstream. Values of zero
+// or less keep the default.
+func WithMaxBytes(n int) HTTPOption {
+	return func(f *HTTPFetcher) { f.maxBytes = n }
 }
 
 // NewHTTP creates a HTTP fetcher with a modest timeout.
-func NewHTTP() *HTTPFetcher {
-	return &HTTPFetcher{client: &http.Client{Timeout: 15 * time.Second}}
+func NewHTTP(opts ...HTTPOption) *HTTPFetcher {
+	return NewHTTPWithClient(&http.Client{Timeout: 15 * time.Second}, opts...)
 }
 
 // NewHTTPWithClient creates a HTTP fetcher using the supplied HTTP client.
 // This is useful for overriding the default timeout.
-func NewHTTPWithClient(client *http.Client) *HTTPFetcher {
-	return &HTTPFetcher{client: client}
+func NewHTTPWithClient(client *http.Client, opts ...HTTPOption) *HTTPFetcher {
+	f := &HTTPFetcher{
+		client:     client,
+		agents:     useragent.New(nil),
+		extractors: defaultExtractors(),
+		maxRetries: defaultFetchMaxRetries,
+		retryDelay: defaultFetchRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// NewHTTPWithJar creates a HTTP fetcher whose requests carry cookies from
+// jar, for pages behind simple cookie-based logins. Populating the jar with
+// session cookies is the caller's responsibility; cookies set by responses
+// are stored back into the jar and reused by later fetches.
+func NewHTTPWithJar(jar http.CookieJar, opts ...HTTPOption) *HTTPFetcher {
+	return NewHTTPWithClient(&http.Client{Timeout: 15 * time.Second, Jar: jar}, opts...)
 }
 
-// Fetch downloads the URL content, strips HTML to plain text, and truncates.
+// Fetch downloads the URL content, converts it to plain text with the
+// Extractor registered for its Content-Type, and truncates. Bodies that are
+// PDFs, by signature or by a .pdf URL served as octet-stream, are read as
+// application/pdf whatever their Content-Type. Rate-limited, unavailable
+// and transiently failed requests are retried; see WithMaxRetries.
+// With WithRobots, URLs robots.txt disallows fail with
+// ErrDisallowedByRobots without being requested.
 func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (string, error) {
 	trimmed := strings.TrimSpace(url)
 	if trimmed == "" {
 		return "", errors.New("fetch url is empty")
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, trimmed, nil)
+	if f.robots != nil {
+		ok, err := f.allowed(ctx, trimmed)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrDisallowedByRobots, trimmed)
+		}
+	}
+	body, contentType, err := f.get(ctx, trimmed)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-
-	resp, err := f.client.Do(req)
+	if isPDF(trimmed, contentType, body) {
+		contentType = "application/pdf"
+	}
+	text, err := f.extractText(contentType, body)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
+	if limit := f.limit(); len(text) > limit {
+		text = text[:limit] + "\n[TRUNCATED]"
+	}
+	return text, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("fetch http %d: %s", resp.StatusCode, string(body))
+// limit returns the configured text limit, or the default.
+func (f *HTTPFetcher) limit() int {
+	if f.maxBytes <= 0 {
+		return maxFetchBytes
 	}
+	return f.maxBytes
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+// userAgent returns the User-Agent for the next request: RobotsUserAgent
+// when robots.txt is honored, otherwise one from the pool.
+func (f *HTTPFetcher) userAgent() string {
+	if f.robots != nil {
+		return RobotsUserAgent
 	}
+	if f.agents == nil {
+		return useragent.Default[0]
+	}
+	return f.agents.Pick()
+}
 
-	text := stripHTML(string(body))
-	if len(text) > maxFetchBytes {
-		text = text[:maxFetchBytes] + "\n[TRUNCATED]"
+// extractText converts a response body to plain text with the Extractor
+// registered for its Content-Type. Types without one, such as office files
+// by default, are rejected.
+func (f *HTTPFetcher) extractText(contentType string, body []byte) (string, error) {
+	e, mediaType := f.extractor(contentType)
+	if e == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedContent, mediaType)
 	}
-	return text, nil
+	return e.Extract(contentType, body)
 }
 
 var (
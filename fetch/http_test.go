@@ -0,0 +1,230 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smhanov/laconic/internal/useragent"
+)
+
+func TestFetchMaxBytes(t *testing.T) {
+	page := strings.Repeat("word ", 20*1024) // 100KB of text
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name string
+		opts []HTTPOption
+		want int
+	}{
+		{"default", nil, maxFetchBytes},
+		{"raised", []HTTPOption{WithMaxBytes(64 * 1024)}, 64 * 1024},
+		{"non-positive keeps default", []HTTPOption{WithMaxBytes(0)}, maxFetchBytes},
+	} {
+		got, err := NewHTTP(tc.opts...).Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !strings.HasSuffix(got, "\n[TRUNCATED]") {
+			t.Errorf("%s: missing truncation marker", tc.name)
+		}
+		if n := len(strings.TrimSuffix(got, "\n[TRUNCATED]")); n != tc.want {
+			t.Errorf("%s: kept %d bytes, want %d", tc.name, n, tc.want)
+		}
+	}
+
+	got, err := NewHTTP(WithMaxBytes(len(page))).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "[TRUNCATED]") {
+		t.Error("text within the limit was truncated")
+	}
+}
+
+func TestFetchRetriesUnavailable(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) <= 2 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("finally"))
+	}))
+	defer srv.Close()
+
+	f := NewHTTP()
+	f.retryDelay = time.Millisecond
+	got, err := f.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "finally" || requests.Load() != 3 {
+		t.Fatalf("got %q after %d requests, want \"finally\" after 3", got, requests.Load())
+	}
+
+	requests.Store(0)
+	f = NewHTTP(WithMaxRetries(1))
+	f.retryDelay = time.Millisecond
+	if _, err := f.Fetch(context.Background(), srv.URL); err == nil || !strings.Contains(err.Error(), "fetch http 503") {
+		t.Fatalf("err = %v, want the second 503", err)
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("%d requests with one retry, want 2", requests.Load())
+	}
+}
+
+func TestFetchHonorsRetryAfter(t *testing.T) {
+	var first time.Time
+	var gap time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if first.IsZero() {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		gap = time.Since(first)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewHTTP()
+	f.retryDelay = time.Millisecond
+	if _, err := f.Fetch(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if gap < time.Second {
+		t.Fatalf("retried after %v, want at least the 1s Retry-After", gap)
+	}
+}
+
+func TestFetchDoesNotRetryPermanentErrors(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, nil)
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTP().Fetch(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected an error for 404")
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("%d requests for a 404, want 1", requests.Load())
+	}
+}
+
+func TestFetchRetryStopsWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	f := NewHTTP(WithMaxRetries(5))
+	f.retryDelay = time.Second
+	if _, err := f.Fetch(ctx, srv.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("backoff ignored the context for %v", elapsed)
+	}
+}
+
+func TestNewHTTPWithJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "secret" {
+			http.Error(w, "login required", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		if c, err := r.Cookie("visited"); err == nil && c.Value == "yes" {
+			w.Write([]byte("welcome back"))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "visited", Value: "yes", Path: "/"})
+		w.Write([]byte("members only"))
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTP().Fetch(context.Background(), srv.URL); err == nil {
+		t.Fatal("fetched a members-only page without cookies")
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(srv.URL)
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "secret", Path: "/"}})
+	f := NewHTTPWithJar(jar)
+	// The second fetch also carries the cookie set by the first response.
+	for _, want := range []string{"members only", "welcome back"} {
+		got, err := f.Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("fetch with jar: %v", err)
+		}
+		if got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestFetchUserAgentPool(t *testing.T) {
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			agents = append(agents, r.UserAgent())
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	fetchAll := func(f *HTTPFetcher, n int) []string {
+		agents = nil
+		for i := 0; i < n; i++ {
+			if _, err := f.Fetch(context.Background(), srv.URL); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return agents
+	}
+
+	// WithDeterministic holds whichever order the options come in.
+	for _, opts := range [][]HTTPOption{
+		{WithUserAgentPool([]string{"agent-a", "", "agent-b"}), WithDeterministic(true)},
+		{WithDeterministic(true), WithUserAgentPool([]string{"agent-a", "", "agent-b"})},
+	} {
+		if got := strings.Join(fetchAll(NewHTTP(opts...), 3), ","); got != "agent-a,agent-b,agent-a" {
+			t.Fatalf("pool rotation = %s", got)
+		}
+	}
+
+	for _, ua := range fetchAll(NewHTTP(), 3) {
+		if !slices.Contains(useragent.Default, ua) {
+			t.Fatalf("default pool sent %q", ua)
+		}
+	}
+
+	// Honoring robots.txt identifies the fetcher instead of using the pool.
+	if got := fetchAll(NewHTTP(WithUserAgentPool([]string{"agent-a"}), WithRobots(true)), 1); got[0] != RobotsUserAgent {
+		t.Fatalf("with robots sent %q, want %q", got[0], RobotsUserAgent)
+	}
+}
@@ -0,0 +1,181 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDFExtractor pulls the text out of a PDF's pages with
+// github.com/ledongthuc/pdf. Text is decoded through each font's encoding,
+// including /Differences tables and ToUnicode CMaps, so embedded subset and
+// CID (Type0) fonts read correctly. Scanned PDFs have no text at all and,
+// like encrypted or malformed files, fail with ErrUnsupportedContent.
+var PDFExtractor Extractor = ExtractorFunc(func(_ string, body []byte) (string, error) {
+	text, err := extractPDFText(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedContent, err)
+	}
+	if text == "" {
+		return "", fmt.Errorf("%w: no extractable text in PDF", ErrUnsupportedContent)
+	}
+	return text, nil
+})
+
+var pdfMagic = []byte("%PDF-")
+
+// isPDF reports whether a response is a PDF: its body starts with the PDF
+// signature, or its URL ends in .pdf and the server gave only a generic
+// Content-Type.
+func isPDF(rawURL, contentType string, body []byte) bool {
+	if bytes.HasPrefix(bytes.TrimLeft(body, " \t\r\n"), pdfMagic) {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "" && mediaType != "application/octet-stream" && mediaType != "binary/octet-stream" {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	return err == nil && strings.EqualFold(path.Ext(u.Path), ".pdf")
+}
+
+// extractPDFText returns the text of every page in data, in page order,
+// one line per text line.
+func extractPDFText(data []byte) (text string, err error) {
+	// The library reports damage it finds while reading by panicking.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	var out pdfText
+	for i := 1; i <= r.NumPage(); i++ {
+		if p := r.Page(i); !p.V.IsNull() {
+			out.readPage(p)
+		}
+	}
+	return out.String(), nil
+}
+
+// pdfText accumulates the text shown by content stream operators.
+type pdfText struct {
+	b     strings.Builder
+	enc   pdf.TextEncoding // encoding of the current font; nil shows raw bytes
+	lastY float64          // y of the last Tm, to tell new lines from new words
+}
+
+// readPage runs the text operators of p's content streams. A page the
+// library cannot read keeps the text shown before the damage.
+func (t *pdfText) readPage(p pdf.Page) {
+	defer func() {
+		recover()
+		t.newline()
+	}()
+	fonts := make(map[string]pdf.TextEncoding)
+	t.enc = nil
+	contents := p.V.Key("Contents")
+	streams := []pdf.Value{contents}
+	if contents.Kind() == pdf.Array {
+		streams = streams[:0]
+		for i := 0; i < contents.Len(); i++ {
+			streams = append(streams, contents.Index(i))
+		}
+	}
+	for _, strm := range streams {
+		pdf.Interpret(strm, func(stk *pdf.Stack, op string) {
+			args := make([]pdf.Value, stk.Len())
+			for i := len(args) - 1; i >= 0; i-- {
+				args[i] = stk.Pop()
+			}
+			if op == "Tf" && len(args) == 2 {
+				name := args[0].Name()
+				enc, ok := fonts[name]
+				if !ok {
+					enc = p.Font(name).Encoder()
+					fonts[name] = enc
+				}
+				t.enc = enc
+				return
+			}
+			t.operator(op, args)
+		})
+	}
+}
+
+// operator applies one content stream operator.
+func (t *pdfText) operator(op string, args []pdf.Value) {
+	last := func() pdf.Value {
+		if len(args) == 0 {
+			return pdf.Value{}
+		}
+		return args[len(args)-1]
+	}
+	switch op {
+	case "Tj":
+		t.show(last())
+	case "'", "\"":
+		t.newline()
+		t.show(last())
+	case "TJ":
+		arr := last()
+		for i := 0; i < arr.Len(); i++ {
+			el := arr.Index(i)
+			if el.Kind() == pdf.String {
+				t.show(el)
+				continue
+			}
+			// A large negative adjustment is a gap between words.
+			if el.Float64() < -200 {
+				t.space()
+			}
+		}
+	case "Td", "TD":
+		if len(args) >= 2 && args[1].Float64() != 0 {
+			t.newline()
+		} else {
+			t.space()
+		}
+	case "Tm":
+		if len(args) >= 6 {
+			if y := args[5].Float64(); y != t.lastY {
+				t.lastY = y
+				t.newline()
+				return
+			}
+		}
+		t.space()
+	case "T*", "ET":
+		t.newline()
+	}
+}
+
+func (t *pdfText) show(s pdf.Value) {
+	raw := s.RawString()
+	if t.enc == nil {
+		t.b.WriteString(raw)
+		return
+	}
+	t.b.WriteString(t.enc.Decode(raw))
+}
+
+func (t *pdfText) space() {
+	t.b.WriteByte(' ')
+}
+
+func (t *pdfText) newline() {
+	t.b.WriteByte('\n')
+}
+
+// String returns the text with spaces collapsed and blank lines removed.
+func (t *pdfText) String() string {
+	return tidyText(t.b.String())
+}
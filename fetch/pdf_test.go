@@ -0,0 +1,119 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestFetchPDF(t *testing.T) {
+	pdf, err := os.ReadFile("testdata/report.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/typed":
+			w.Header().Set("Content-Type", "application/pdf")
+		case "/download/report.pdf":
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		w.Write(pdf)
+	}))
+	defer srv.Close()
+
+	want := "Quarterly report: Acme revenue rose to $5.2B.\n" +
+		"Margins (net) improved.\n" +
+		"Second page."
+	for _, path := range []string{"/typed", "/download/report.pdf"} {
+		got, err := NewHTTP().Fetch(context.Background(), srv.URL+path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+// servePDF serves the named testdata file as application/pdf.
+func servePDF(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	pdf, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchPDFWithEmbeddedCIDFont(t *testing.T) {
+	// unicode.pdf sets its text in a subset TrueType font (Type0,
+	// Identity-H) whose codes are glyph IDs mapped by a ToUnicode CMap.
+	srv := servePDF(t, "unicode.pdf")
+
+	got, err := NewHTTP().Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Zürich café: 4,50 € per cup.\nΕλληνικά και русский текст."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFetchPDFTruncates(t *testing.T) {
+	srv := servePDF(t, "report.pdf")
+
+	got, err := NewHTTP(WithMaxBytes(20)).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Quarterly report: Ac\n[TRUNCATED]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFetchPDFWithoutText(t *testing.T) {
+	srv := servePDF(t, "scanned.pdf")
+
+	_, err := NewHTTP().Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrUnsupportedContent) {
+		t.Fatalf("err = %v, want ErrUnsupportedContent", err)
+	}
+}
+
+func TestFetchMalformedPDF(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4\n1 0 obj\n<< /Length 999 >>\nstream\nBT (cut short"))
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTP().Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrUnsupportedContent) {
+		t.Fatalf("err = %v, want ErrUnsupportedContent", err)
+	}
+}
+
+func TestFetchHTMLUnaffectedByPDFDetection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Plain page</p></body></html>"))
+	}))
+	defer srv.Close()
+
+	got, err := NewHTTP().Fetch(context.Background(), srv.URL+"/paper.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Plain page" {
+		t.Errorf("got %q, want %q", got, "Plain page")
+	}
+}
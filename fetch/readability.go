@@ -0,0 +1,317 @@
+package fetch
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// ReadabilityExtractor isolates the main article of an HTML page before
+// converting it to text, dropping cookie banners, sidebars, related-link
+// lists and comment threads that HTMLExtractor keeps. It scores each block
+// by how much comma-rich prose it holds, discounts blocks made mostly of
+// links or marked as page furniture by their class or id, and keeps the best
+// block with any similar siblings. Pages where no block holds enough prose
+// to be an article, such as indexes and search pages, fall back to
+// HTMLExtractor's full-page text.
+var ReadabilityExtractor Extractor = ExtractorFunc(func(_ string, body []byte) (string, error) {
+	return readableText(string(body)), nil
+})
+
+// WithReadability makes the fetcher read HTML with ReadabilityExtractor
+// instead of HTMLExtractor, which improves the signal-to-noise ratio of the
+// text facts are extracted from. Disabling it restores HTMLExtractor. HTML
+// types given their own extractor with WithExtractor keep it, before or
+// after this option.
+func WithReadability(enabled bool) HTTPOption {
+	e := HTMLExtractor
+	if enabled {
+		e = ReadabilityExtractor
+	}
+	return func(f *HTTPFetcher) {
+		for _, contentType := range []string{"", "text/html", "application/xhtml+xml"} {
+			if !f.custom[contentType] {
+				f.extractors[contentType] = e
+			}
+		}
+	}
+}
+
+const (
+	// minParagraphChars is the shortest block scored as prose.
+	minParagraphChars = 25
+	// minArticleChars is the least text the chosen article must hold for
+	// the extraction to be trusted over the full-page text.
+	minArticleChars = 250
+)
+
+var (
+	reHTMLToken = regexp.MustCompile(`(?s)<!--.*?-->|<(/?)([a-zA-Z][a-zA-Z0-9]*)([^>]*)>|<[!?][^>]*>`)
+	reClassID   = regexp.MustCompile(`(?i)\b(?:class|id)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+	reNoScript  = regexp.MustCompile(`(?is)<(noscript|template|svg)[^>]*>.*?</(?:noscript|template|svg)>`)
+	// rePositiveClass and reNegativeClass match class and id names that
+	// mark article content and page furniture.
+	rePositiveClass = regexp.MustCompile(`article|body|content|entry|main|page|post|story|text|blog`)
+	reNegativeClass = regexp.MustCompile(`comment|meta|foot|sidebar|banner|cookie|consent|gdpr|share|social|promo|related|sponsor|advert|\bads?\b|nav|menu|subscribe|newsletter|popup|modal|widget|breadcrumb`)
+)
+
+var (
+	voidTags = map[string]bool{ //nolint:gochecknoglobals
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+	}
+	// blockTags start a new line when rendered.
+	blockTags = map[string]bool{ //nolint:gochecknoglobals
+		"address": true, "article": true, "aside": true, "blockquote": true, "dd": true, "div": true,
+		"dl": true, "dt": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+		"hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+		"section": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+	}
+	// selfClosingTags implicitly close an open element of the same tag.
+	selfClosingTags = map[string]bool{"p": true, "li": true, "td": true, "th": true, "tr": true, "dt": true, "dd": true, "option": true} //nolint:gochecknoglobals
+)
+
+// htmlNode is an element or, when tag is empty, a run of text.
+type htmlNode struct {
+	tag      string
+	attrs    string // lowercased class and id values
+	text     string
+	parent   *htmlNode
+	children []*htmlNode
+
+	textLen int // characters of text below the node
+	linkLen int // characters of that text inside links
+	score   float64
+	scored  bool
+}
+
+// readableText returns the text of the main article in page, or the
+// full-page text when none is found with confidence.
+func readableText(page string) string {
+	root := parseHTML(page)
+	root.measure(false)
+	top := pickArticle(root)
+	if top == nil || top.textLen < minArticleChars {
+		return stripHTML(page)
+	}
+
+	var b strings.Builder
+	threshold := top.score * 0.2
+	if threshold < 10 {
+		threshold = 10
+	}
+	for _, sib := range top.parent.children {
+		switch {
+		case sib == top,
+			sib.scored && sib.score >= threshold,
+			sib.tag == "p" && sib.textLen > 80 && sib.linkDensity() < 0.25:
+			sib.render(&b, true)
+		}
+	}
+	return tidyText(b.String())
+}
+
+// parseHTML builds a loose element tree from page, tolerating unclosed and
+// stray tags. Scripts, styles and the elements stripHTML removes are dropped
+// first.
+func parseHTML(page string) *htmlNode {
+	for _, re := range []*regexp.Regexp{reScript, reStyle, reNoScript, reNav, reHeader, reFooter} {
+		page = re.ReplaceAllString(page, "")
+	}
+	root := &htmlNode{tag: "#root"}
+	cur := root
+	addText := func(s string) {
+		if s = html.UnescapeString(s); strings.TrimSpace(s) != "" {
+			cur.children = append(cur.children, &htmlNode{text: strings.Join(strings.Fields(s), " "), parent: cur})
+		}
+	}
+	last := 0
+	for _, m := range reHTMLToken.FindAllStringSubmatchIndex(page, -1) {
+		addText(page[last:m[0]])
+		last = m[1]
+		if m[4] < 0 {
+			continue // comment, doctype or processing instruction
+		}
+		tag := strings.ToLower(page[m[4]:m[5]])
+		if m[3] > m[2] {
+			for n := cur; n != root; n = n.parent {
+				if n.tag == tag {
+					cur = n.parent
+					break
+				}
+			}
+			continue
+		}
+		if selfClosingTags[tag] && cur.tag == tag {
+			cur = cur.parent
+		}
+		var attrs []string
+		for _, a := range reClassID.FindAllStringSubmatch(page[m[6]:m[7]], -1) {
+			attrs = append(attrs, strings.ToLower(strings.Trim(a[1], `"'`)))
+		}
+		n := &htmlNode{tag: tag, attrs: strings.Join(attrs, " "), parent: cur}
+		cur.children = append(cur.children, n)
+		if !voidTags[tag] && !strings.HasSuffix(page[m[6]:m[7]], "/") {
+			cur = n
+		}
+	}
+	addText(page[last:])
+	return root
+}
+
+// measure fills in textLen and linkLen for n and its descendants.
+func (n *htmlNode) measure(inLink bool) {
+	inLink = inLink || n.tag == "a"
+	if n.tag == "" {
+		n.textLen = len(n.text)
+		if inLink {
+			n.linkLen = n.textLen
+		}
+		return
+	}
+	for _, c := range n.children {
+		c.measure(inLink)
+		n.textLen += c.textLen
+		n.linkLen += c.linkLen
+	}
+}
+
+func (n *htmlNode) linkDensity() float64 {
+	if n.textLen == 0 {
+		return 0
+	}
+	return float64(n.linkLen) / float64(n.textLen)
+}
+
+// isParagraph reports whether n is scored as a unit of prose: a paragraph
+// element, or a container with no block children, as on pages that set
+// text in bare divs.
+func (n *htmlNode) isParagraph() bool {
+	switch n.tag {
+	case "p", "pre", "td", "blockquote":
+		return true
+	case "div", "section", "article":
+		for _, c := range n.children {
+			if blockTags[c.tag] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// pickArticle scores every paragraph's parent and grandparent and returns
+// the best-scoring container, or nil when the page has no prose.
+func pickArticle(root *htmlNode) *htmlNode {
+	var candidates []*htmlNode
+	addScore := func(n *htmlNode, s float64) {
+		if n == nil || n == root {
+			return
+		}
+		if !n.scored {
+			n.scored = true
+			n.score = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		n.score += s
+	}
+	var walk func(n *htmlNode)
+	walk = func(n *htmlNode) {
+		if n.tag != "" && n.isParagraph() && n.textLen >= minParagraphChars {
+			text := n.plainText()
+			s := 1 + float64(strings.Count(text, ","))
+			if bonus := float64(len(text) / 100); bonus < 3 {
+				s += bonus
+			} else {
+				s += 3
+			}
+			addScore(n.parent, s)
+			if n.parent != nil {
+				addScore(n.parent.parent, s/2)
+			}
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	var top *htmlNode
+	for _, c := range candidates {
+		c.score *= 1 - c.linkDensity()
+		if top == nil || c.score > top.score {
+			top = c
+		}
+	}
+	return top
+}
+
+// initialScore weighs a candidate by its tag and its class and id names.
+func initialScore(n *htmlNode) float64 {
+	var s float64
+	switch n.tag {
+	case "article", "main":
+		s = 10
+	case "div":
+		s = 5
+	case "pre", "td", "blockquote":
+		s = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		s = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		s = -5
+	}
+	if n.attrs != "" {
+		if reNegativeClass.MatchString(n.attrs) {
+			s -= 25
+		}
+		if rePositiveClass.MatchString(n.attrs) {
+			s += 25
+		}
+	}
+	return s
+}
+
+// plainText returns the text below n, without line structure.
+func (n *htmlNode) plainText() string {
+	var b strings.Builder
+	n.render(&b, true)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// render writes the text below n, one line per block. Nested page furniture
+// (negative class names or mostly links) is skipped unless n is the chosen
+// article itself.
+func (n *htmlNode) render(b *strings.Builder, top bool) {
+	if n.tag == "" {
+		b.WriteString(n.text)
+		b.WriteByte(' ')
+		return
+	}
+	if !top && blockTags[n.tag] && n.tag != "p" &&
+		(reNegativeClass.MatchString(n.attrs) && !rePositiveClass.MatchString(n.attrs) || n.linkDensity() > 0.5) {
+		return
+	}
+	if blockTags[n.tag] || n.tag == "br" {
+		b.WriteByte('\n')
+	}
+	for _, c := range n.children {
+		c.render(b, false)
+	}
+	if blockTags[n.tag] {
+		b.WriteByte('\n')
+	}
+}
+
+// tidyText collapses spaces and drops blank lines.
+func tidyText(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
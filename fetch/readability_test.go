@@ -0,0 +1,111 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html><head><title>Acme results</title><script>var x = 1;</script></head>
+<body>
+<div class="cookie-banner">We use cookies to improve your experience. By continuing, you accept our cookie policy and terms.</div>
+<div id="wrapper">
+  <div class="sidebar">
+    <h3>Trending</h3>
+    <ul>
+      <li><a href="/a">Ten gadgets you need this summer, ranked by our editors</a></li>
+      <li><a href="/b">Why everyone is talking about the new phone, explained</a></li>
+    </ul>
+  </div>
+  <div class="article-body">
+    <h1>Acme posts record quarter</h1>
+    <p>Acme Corp reported revenue of $5.2 billion for the third quarter, up 12% from a year earlier, beating analyst estimates.</p>
+    <p>The company said demand for its industrial sensors, which account for roughly half of sales, remained strong in Europe, Asia and North America.</p>
+    <p>Chief executive Jane Roe said margins improved as supply costs eased, and the board raised the full-year outlook to $21 billion.</p>
+    <div class="share-buttons"><a href="/tw">Share on Twitter</a> <a href="/fb">Share on Facebook</a></div>
+  </div>
+  <div class="comments">
+    <p>Great news, I have owned the stock for years, and it keeps going up, up, up!</p>
+  </div>
+</div>
+</body></html>`
+
+func TestReadabilityExtractsArticle(t *testing.T) {
+	got, err := ReadabilityExtractor.Extract("text/html", []byte(articlePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Acme posts record quarter",
+		"Acme Corp reported revenue of $5.2 billion",
+		"raised the full-year outlook to $21 billion.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	for _, noise := range []string{"cookies", "Trending", "gadgets", "Share on", "owned the stock", "var x"} {
+		if strings.Contains(got, noise) {
+			t.Errorf("kept %q in:\n%s", noise, got)
+		}
+	}
+}
+
+func TestReadabilityFallsBackWithoutArticle(t *testing.T) {
+	page := `<html><body><ul><li><a href="/1">Home</a></li><li><a href="/2">Products</a></li></ul><p>Short note.</p></body></html>`
+	got, err := ReadabilityExtractor.Extract("text/html", []byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := stripHTML(page); got != want {
+		t.Errorf("got %q, want full-page text %q", got, want)
+	}
+}
+
+func TestWithReadability(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articlePage))
+	}))
+	defer srv.Close()
+
+	plain, err := NewHTTP().Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plain, "cookies") {
+		t.Error("default fetcher should keep the full page")
+	}
+	readable, err := NewHTTP(WithReadability(true)).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(readable, "cookies") || !strings.Contains(readable, "Acme Corp reported revenue") {
+		t.Errorf("readability fetch returned:\n%s", readable)
+	}
+}
+
+func TestWithExtractorOverridesReadability(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(articlePage))
+	}))
+	defer srv.Close()
+
+	custom := ExtractorFunc(func(string, []byte) (string, error) { return "custom", nil })
+	for name, opts := range map[string][]HTTPOption{
+		"extractor first": {WithExtractor("text/html", custom), WithReadability(true)},
+		"extractor last":  {WithReadability(true), WithExtractor("text/html", custom)},
+	} {
+		got, err := NewHTTP(opts...).Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != "custom" {
+			t.Errorf("%s: got %q, want the WithExtractor result", name, got)
+		}
+	}
+}
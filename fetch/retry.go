@@ -0,0 +1,146 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultFetchMaxRetries is how many times a failed fetch is retried by
+	// default.
+	defaultFetchMaxRetries = 2
+	// defaultFetchRetryDelay is the first backoff delay; it doubles on each
+	// retry up to maxFetchRetryDelay.
+	defaultFetchRetryDelay = 500 * time.Millisecond
+	// maxFetchRetryDelay caps any single backoff, including one asked for
+	// by Retry-After, so a page never stalls a research step for long.
+	maxFetchRetryDelay = 10 * time.Second
+)
+
+// WithMaxRetries sets how many times a fetch is retried after HTTP 429,
+// 502, 503 or 504, or a transient network error such as a reset
+// connection (default 2). Delays start at half a second and double, unless
+// the server sends Retry-After; no delay exceeds 10 seconds, and a done
+// context stops the retries. Zero fails on the first error.
+func WithMaxRetries(n int) HTTPOption {
+	return func(f *HTTPFetcher) {
+		if n >= 0 {
+			f.maxRetries = n
+		}
+	}
+}
+
+// statusError is a non-200 response.
+type statusError struct {
+	code       int
+	body       string
+	retryAfter time.Duration // from the Retry-After header; zero if absent
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("fetch http %d: %s", e.code, e.body)
+}
+
+// get downloads url, retrying transient failures, and returns the body and
+// Content-Type of the 200 response.
+func (f *HTTPFetcher) get(ctx context.Context, url string) ([]byte, string, error) {
+	delay := f.retryDelay
+	if delay <= 0 {
+		delay = defaultFetchRetryDelay
+	}
+	for retries := 0; ; retries++ {
+		body, contentType, err := f.attempt(ctx, url)
+		if err == nil || retries >= f.maxRetries || !retryable(ctx, err) {
+			return body, contentType, err
+		}
+		wait := delay
+		var se *statusError
+		if errors.As(err, &se) && se.retryAfter > 0 {
+			wait = se.retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(wait):
+		}
+		if delay < maxFetchRetryDelay {
+			delay = min(2*delay, maxFetchRetryDelay)
+		}
+	}
+}
+
+// attempt makes one request for url.
+func (f *HTTPFetcher) attempt(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", f.userAgent())
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, "", &statusError{code: resp.StatusCode, body: string(body), retryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// retryable reports whether err is worth retrying: a rate-limit or gateway
+// status, a timeout, or a connection cut short. Nothing is retried once ctx
+// is done.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		switch se.code {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP
+// date, capped at maxFetchRetryDelay. It returns zero when the header is
+// absent or unreadable.
+func retryAfter(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	var d time.Duration
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		d = time.Duration(seconds) * time.Second
+	} else if when, err := http.ParseTime(raw); err == nil {
+		d = time.Until(when)
+	}
+	if d < 0 {
+		return 0
+	}
+	return min(d, maxFetchRetryDelay)
+}
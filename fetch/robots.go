@@ -0,0 +1,232 @@
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrDisallowedByRobots is returned by a fetcher that honors robots.txt
+// (see WithRobots) for a URL the site's robots.txt disallows.
+var ErrDisallowedByRobots = errors.New("fetch: disallowed by robots.txt")
+
+// RobotsAgent is the product token matched against robots.txt User-agent
+// lines. Rules for "laconic" take precedence over the "*" group.
+const RobotsAgent = "laconic"
+
+// RobotsUserAgent is the User-Agent a fetcher honoring robots.txt sends in
+// place of its browser pool, so sites see the crawler their rules were
+// matched for.
+const RobotsUserAgent = RobotsAgent + "/1.0 (+https://github.com/smhanov/laconic)"
+
+// maxRobotsBytes is the most of a robots.txt that is read (RFC 9309 asks
+// crawlers to parse at least 500 KiB).
+const maxRobotsBytes = 500 * 1024
+
+// WithRobots makes the fetcher read each host's /robots.txt before its first
+// fetch there and fail with ErrDisallowedByRobots for paths it disallows to
+// RobotsAgent. Every request then identifies itself as RobotsUserAgent
+// instead of using the User-Agent pool. Rules are cached per host for the
+// life of the fetcher. Following RFC 9309, a robots.txt that answers with a
+// 4xx status allows everything, while a server error or a failed request
+// disallows everything until a later fetch to the host reads it.
+func WithRobots(enabled bool) HTTPOption {
+	return func(f *HTTPFetcher) {
+		f.robots = nil
+		if enabled {
+			f.robots = &robotsCache{hosts: make(map[string]*robotsEntry)}
+		}
+	}
+}
+
+// NewHTTPWithRobots creates a HTTP fetcher that honors robots.txt, for
+// polite crawling of sites with crawl restrictions.
+func NewHTTPWithRobots(opts ...HTTPOption) *HTTPFetcher {
+	return NewHTTP(append([]HTTPOption{WithRobots(true)}, opts...)...)
+}
+
+// robotsCache holds the parsed robots.txt of every host fetched so far.
+type robotsCache struct {
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry // keyed by scheme and host
+}
+
+// robotsEntry is one host's rules. Its lock is held while they load, so
+// concurrent fetches to a new host request robots.txt once.
+type robotsEntry struct {
+	mu     sync.Mutex
+	loaded bool
+	rules  []robotsRule
+}
+
+// robotsRule is one Allow or Disallow line of the group that applies to us.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// allowed reports whether robots.txt permits fetching rawURL, loading the
+// host's rules on first use. It fails with ctx's error when the context ends
+// before the rules are read.
+func (f *HTTPFetcher) allowed(ctx context.Context, rawURL string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true, nil
+	}
+	key := u.Scheme + "://" + u.Host
+	c := f.robots
+	c.mu.Lock()
+	entry, ok := c.hosts[key]
+	if !ok {
+		entry = &robotsEntry{}
+		c.hosts[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if !entry.loaded {
+		// An unreachable robots.txt, or a cancelled request, says nothing
+		// lasting about the site; try again on the next fetch.
+		entry.rules, entry.loaded = f.loadRobots(ctx, key+"/robots.txt")
+		if err := ctx.Err(); err != nil {
+			entry.loaded = false
+			return false, err
+		}
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return robotsAllows(entry.rules, path), nil
+}
+
+// disallowAll is the rule set for a host whose robots.txt is unreachable.
+var disallowAll = []robotsRule{{allow: false, pattern: "/"}}
+
+// loadRobots fetches and parses robotsURL. A 4xx response yields no rules
+// (allow all). A server error or failed request yields disallowAll and
+// false, so the result is not cached.
+func (f *HTTPFetcher) loadRobots(ctx context.Context, robotsURL string) ([]robotsRule, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, true
+	}
+	req.Header.Set("User-Agent", f.userAgent())
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return disallowAll, false
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll, false
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, true
+	}
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsBytes), RobotsAgent), true
+}
+
+// parseRobots returns the rules of the group for agent, or of the "*" group
+// when no group names agent. Consecutive User-agent lines share a group.
+func parseRobots(r io.Reader, agent string) []robotsRule {
+	agent = strings.ToLower(agent)
+	var own, star []robotsRule
+	var forOwn, forStar, foundOwn bool
+	inAgents := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+		switch field {
+		case "user-agent":
+			if !inAgents {
+				forOwn, forStar = false, false
+				inAgents = true
+			}
+			name := strings.ToLower(value)
+			if name == "*" {
+				forStar = true
+			} else if name == agent {
+				forOwn, foundOwn = true, true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue // an empty Disallow allows everything
+			}
+			rule := robotsRule{allow: field == "allow", pattern: value}
+			if forOwn {
+				own = append(own, rule)
+			}
+			if forStar {
+				star = append(star, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+	if foundOwn {
+		return own
+	}
+	return star
+}
+
+// robotsAllows applies the longest matching rule to path; Allow wins ties
+// and a path no rule matches is allowed.
+func robotsAllows(rules []robotsRule, path string) bool {
+	allow, best := true, -1
+	for _, r := range rules {
+		if !robotsMatch(r.pattern, path) {
+			continue
+		}
+		if n := len(r.pattern); n > best || (n == best && r.allow) {
+			allow, best = r.allow, n
+		}
+	}
+	return allow
+}
+
+// robotsMatch reports whether path starts with pattern, where "*" matches
+// any run of characters and a trailing "$" anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	if rest == "" {
+		return true
+	}
+	// The last part must end the path: retry it against the path's end.
+	last := parts[len(parts)-1]
+	return len(parts) > 1 && strings.HasSuffix(path, last)
+}
@@ -0,0 +1,171 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRobotsRules(t *testing.T) {
+	robots := `
+# Everyone else
+User-agent: *
+Disallow: /
+
+User-agent: Googlebot
+User-agent: laconic
+Disallow: /private/
+Allow: /private/press
+Disallow: /*.json$
+`
+	rules := parseRobots(strings.NewReader(robots), RobotsAgent)
+	for path, want := range map[string]bool{
+		"/":                      true,
+		"/articles/1":            true,
+		"/private/":              false,
+		"/private/notes":         false,
+		"/private/press-release": true,
+		"/data.json":             false,
+		"/data.json?x=1":         true,
+		"/data.jsonl":            true,
+	} {
+		if got := robotsAllows(rules, path); got != want {
+			t.Errorf("%s: allowed = %v, want %v", path, got, want)
+		}
+	}
+
+	star := parseRobots(strings.NewReader(robots), "otherbot")
+	if robotsAllows(star, "/articles/1") {
+		t.Error("the * group should disallow everything for other agents")
+	}
+}
+
+func TestFetchHonorsRobots(t *testing.T) {
+	var robotsReqs, pageReqs atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsReqs.Add(1)
+			w.Write([]byte("User-agent: *\nDisallow: /admin\n"))
+			return
+		}
+		pageReqs.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("page " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	f := NewHTTPWithRobots()
+	ctx := context.Background()
+	if _, err := f.Fetch(ctx, srv.URL+"/admin/users"); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Fatalf("err = %v, want ErrDisallowedByRobots", err)
+	}
+	for _, path := range []string{"/news", "/about"} {
+		got, err := f.Fetch(ctx, srv.URL+path)
+		if err != nil || got != "page "+path {
+			t.Fatalf("%s: got %q, %v", path, got, err)
+		}
+	}
+	if robotsReqs.Load() != 1 {
+		t.Errorf("robots.txt requested %d times, want 1", robotsReqs.Load())
+	}
+	if pageReqs.Load() != 2 {
+		t.Errorf("%d page requests, want 2 (the disallowed page must not be requested)", pageReqs.Load())
+	}
+
+	// Without the option robots.txt is ignored.
+	if _, err := NewHTTP().Fetch(ctx, srv.URL+"/admin/users"); err != nil {
+		t.Fatalf("default fetcher: %v", err)
+	}
+}
+
+func TestFetchRobotsMissingAllowsAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTPWithRobots().Fetch(context.Background(), srv.URL+"/admin"); err != nil {
+		t.Fatalf("missing robots.txt should allow everything: %v", err)
+	}
+}
+
+func TestFetchRobotsServerErrorDisallowsUntilReadable(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if down.Load() {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("User-agent: *\nDisallow: /admin\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewHTTPWithRobots(WithMaxRetries(0))
+	ctx := context.Background()
+	if _, err := f.Fetch(ctx, srv.URL+"/news"); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Fatalf("err = %v, want ErrDisallowedByRobots while robots.txt fails", err)
+	}
+	down.Store(false)
+	if _, err := f.Fetch(ctx, srv.URL+"/news"); err != nil {
+		t.Fatalf("robots.txt should be read again once it is available: %v", err)
+	}
+}
+
+func TestFetchRobotsSendsItsOwnUserAgent(t *testing.T) {
+	var agents []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents = append(agents, r.UserAgent())
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewHTTPWithRobots(WithUserAgentPool([]string{"BrowserBot"}))
+	if _, err := f.Fetch(context.Background(), srv.URL+"/news"); err != nil {
+		t.Fatal(err)
+	}
+	if len(agents) != 2 || agents[0] != RobotsUserAgent || agents[1] != RobotsUserAgent {
+		t.Fatalf("User-Agents = %q, want %q for robots.txt and the page", agents, RobotsUserAgent)
+	}
+}
+
+func TestFetchRobotsCancelledReportsContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var pageReqs atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			cancel()
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		pageReqs.Add(1)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	f := NewHTTPWithRobots(WithMaxRetries(0))
+	_, err := f.Fetch(ctx, srv.URL+"/news")
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrDisallowedByRobots) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if pageReqs.Load() != 0 {
+		t.Fatalf("page requested %d times after cancellation", pageReqs.Load())
+	}
+}
@@ -0,0 +1,33 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by Static for a URL it has no page for.
+var ErrNotFound = errors.New("fetch: not found")
+
+// Static serves canned page text keyed by URL, for deterministic tests of
+// the graph-reader's page reading without a live server:
+//
+//	fetcher := fetch.Static{"https://example.com/paris": "Paris is ..."}
+type Static map[string]string
+
+// Fetch returns the page stored for url, or ErrNotFound.
+func (s Static) Fetch(_ context.Context, url string) (string, error) {
+	page, ok := s[url]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, url)
+	}
+	return page, nil
+}
+
+// Func adapts an ordinary function to a fetch provider.
+type Func func(ctx context.Context, url string) (string, error)
+
+// Fetch calls f.
+func (f Func) Fetch(ctx context.Context, url string) (string, error) {
+	return f(ctx, url)
+}
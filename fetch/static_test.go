@@ -0,0 +1,45 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smhanov/laconic"
+)
+
+var (
+	_ laconic.FetchProvider = Static{}
+	_ laconic.FetchProvider = Func(nil)
+)
+
+func TestStatic(t *testing.T) {
+	s := Static{"https://example.com/paris": "Paris is the capital of France."}
+	got, err := s.Fetch(context.Background(), "https://example.com/paris")
+	if err != nil || got != "Paris is the capital of France." {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := s.Fetch(context.Background(), "https://example.com/rome"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFunc(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	f := Func(func(ctx context.Context, url string) (string, error) {
+		if ctx.Value(key{}) != "value" {
+			t.Error("context not passed through")
+		}
+		if url == "https://example.com/fail" {
+			return "", errors.New("boom")
+		}
+		return "page at " + url, nil
+	})
+	if got, err := f.Fetch(ctx, "https://example.com/a"); err != nil || got != "page at https://example.com/a" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := f.Fetch(ctx, "https://example.com/fail"); err == nil || err.Error() != "boom" {
+		t.Fatalf("err = %v, want the function's error", err)
+	}
+}
@@ -0,0 +1,365 @@
+package laconic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCallBudgetExceeded is returned when a run reaches the limit set with
+// WithMaxLLMCalls. The Result still carries the answer the finalizer produced
+// from the knowledge gathered before the budget ran out.
+var ErrCallBudgetExceeded = errors.New("LLM call budget exceeded")
+
+// ErrCostBudgetExceeded is returned when a run's spending reaches the limit
+// set with WithMaxCost. Like ErrCallBudgetExceeded, the Result still carries
+// the finalized answer.
+var ErrCostBudgetExceeded = errors.New("cost budget exceeded")
+
+// ErrTimeout is returned when the WithTimeout deadline ends a run's
+// research. The Result carries the best-effort answer written from what was
+// gathered. It wraps context.DeadlineExceeded.
+var ErrTimeout = fmt.Errorf("answer timeout: %w", context.DeadlineExceeded)
+
+// Pipeline stages that call a model. Final stages (finalizer, condense,
+// validator, summary and JSON repair) are exempt from the call budget so a
+// run can always produce an answer.
+const (
+	stagePlanner     = "planner"
+	stageSynthesizer = "synthesizer"
+	stageFinalizer   = "finalizer"
+	stageReformulate = "reformulate"
+	stageExtractor   = "extractor"
+	stageNeighbor    = "neighbor"
+	stageAnswerCheck = "answer-check"
+	stageCondense    = "condense"
+	stageValidator   = "validator"
+	stageSummary     = "summary"
+	stageProgressive = "progressive"
+	stageJSONRepair  = "json-repair"
+
+	// costSearch is the CostBreakdown key for WithSearchCost charges.
+	costSearch = "search"
+)
+
+// runState holds bookkeeping for a single Answer call. It travels in the
+// context so concurrent runs on one Agent never share counters.
+type runState struct {
+	maxCalls int
+	maxCost  float64
+	calls    atomic.Int64
+	opts     answerConfig // per-call AnswerOptions
+
+	mu             sync.Mutex
+	finalReasoning string              // reasoning of the last finalizer call
+	rawResponses   map[string][]string // by stage, with WithCaptureRawResponses
+	costs          map[string]float64  // by stage, for Result.CostBreakdown
+	start          time.Time           // when the run began, for Timings.Total
+	timings        Timings             // call durations, for Result.Timings
+
+	// parent is the run's context without the WithTimeout deadline, used
+	// to finish a run whose research timed out.
+	parent context.Context
+}
+
+type runStateKey struct{}
+
+// startRun attaches fresh per-run state, including the call's
+// AnswerOptions, to ctx.
+func (a *Agent) startRun(ctx context.Context, opts answerConfig) context.Context {
+	rs := &runState{maxCalls: a.maxLLMCalls, maxCost: a.maxCost, opts: opts, start: time.Now()}
+	ctx = context.WithValue(ctx, runStateKey{}, rs)
+	rs.parent = ctx
+	return ctx
+}
+
+func runStateFrom(ctx context.Context) *runState {
+	rs, _ := ctx.Value(runStateKey{}).(*runState)
+	return rs
+}
+
+// answerOptions returns the AnswerOptions of the run in ctx.
+func answerOptions(ctx context.Context) answerConfig {
+	if rs := runStateFrom(ctx); rs != nil {
+		return rs.opts
+	}
+	return answerConfig{}
+}
+
+// searchProvider returns the search provider for the run in ctx: the
+// WithSearchProviderFor override, or the Agent's own.
+func (a *Agent) searchProvider(ctx context.Context) SearchProvider {
+	if p := answerOptions(ctx).searcher; p != nil {
+		return p
+	}
+	return a.searcher
+}
+
+// iterationLimit returns the run's iteration or step limit: the
+// WithMaxIterationsForCall override, or def.
+func iterationLimit(ctx context.Context, def int) int {
+	if n := answerOptions(ctx).maxIterations; n > 0 {
+		return n
+	}
+	return def
+}
+
+// timedOut reports whether the WithTimeout deadline has ended the run's
+// research while the caller's context is still live. The strategy should
+// then answer from what it has, using finishContext.
+func timedOut(ctx context.Context) bool {
+	rs := runStateFrom(ctx)
+	return rs != nil && rs.opts.timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) && rs.parent.Err() == nil
+}
+
+// finishContext returns the context to write the answer with: the run's
+// context without its WithTimeout deadline once that has passed, or ctx.
+func finishContext(ctx context.Context) context.Context {
+	if timedOut(ctx) {
+		return runStateFrom(ctx).parent
+	}
+	return ctx
+}
+
+// budgetExceeded returns the error for a run that has used every LLM call
+// allowed by WithMaxLLMCalls or spent the WithMaxCost budget, or nil.
+func budgetExceeded(ctx context.Context) error {
+	rs := runStateFrom(ctx)
+	if rs == nil {
+		return nil
+	}
+	if rs.maxCalls > 0 && rs.calls.Load() >= int64(rs.maxCalls) {
+		return ErrCallBudgetExceeded
+	}
+	if rs.maxCost > 0 && rs.spent() >= rs.maxCost {
+		return ErrCostBudgetExceeded
+	}
+	return nil
+}
+
+// isBudgetError reports whether err comes from either run budget.
+func isBudgetError(err error) bool {
+	return errors.Is(err, ErrCallBudgetExceeded) || errors.Is(err, ErrCostBudgetExceeded)
+}
+
+// spent returns the run's cost so far.
+func (rs *runState) spent() float64 {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var total float64
+	for _, c := range rs.costs {
+		total += c
+	}
+	return total
+}
+
+// generate sends one prompt to llm on behalf of stage, counting the call
+// against the run's budgets. Non-final stages fail with
+// ErrCallBudgetExceeded or ErrCostBudgetExceeded once a budget is used up.
+func (a *Agent) generate(ctx context.Context, stage string, llm LLMProvider, systemPrompt, userPrompt string) (LLMResponse, error) {
+	if !isFinalStage(stage) {
+		if err := budgetExceeded(ctx); err != nil {
+			return LLMResponse{}, err
+		}
+	}
+	rs := runStateFrom(ctx)
+	if rs != nil {
+		rs.calls.Add(1)
+	}
+	spanCtx, end := a.startSpan(ctx, stageSpans[stage])
+	var resp LLMResponse
+	var err error
+	start := time.Now()
+	if sp, ok := llm.(LLMStreamProvider); ok && stage == stageFinalizer && a.streamHandler != nil {
+		resp, err = a.stream(spanCtx, sp, systemPrompt, userPrompt)
+	} else {
+		resp, err = a.call(spanCtx, llm, systemPrompt, userPrompt)
+	}
+	addRunTiming(ctx, stage, time.Since(start))
+	if a.tracer != nil {
+		end(withError(map[string]any{
+			"laconic.stage":             stage,
+			"laconic.cost":              resp.Cost,
+			"laconic.prompt_tokens":     a.countTokens(systemPrompt) + a.countTokens(userPrompt),
+			"laconic.completion_tokens": a.countTokens(resp.Text) + a.countTokens(resp.Reasoning),
+		}, err))
+	}
+	addRunCost(ctx, stage, resp.Cost)
+	if err == nil && rs != nil && stage == stageFinalizer && a.includeReasoning {
+		rs.mu.Lock()
+		rs.finalReasoning = responseReasoning(resp)
+		rs.mu.Unlock()
+	}
+	if err == nil && rs != nil && a.captureRawResponses {
+		rs.mu.Lock()
+		if rs.rawResponses == nil {
+			rs.rawResponses = make(map[string][]string)
+		}
+		rs.rawResponses[stage] = append(rs.rawResponses[stage], resp.Text)
+		rs.mu.Unlock()
+	}
+	return resp, err
+}
+
+// call invokes llm, passing the agent's sampling parameters when the
+// provider accepts them.
+func (a *Agent) call(ctx context.Context, llm LLMProvider, systemPrompt, userPrompt string) (LLMResponse, error) {
+	if a.seed != nil {
+		if pg, ok := llm.(ParamGenerator); ok {
+			return pg.GenerateWithParams(ctx, systemPrompt, userPrompt, GenParams{Seed: a.seed})
+		}
+	}
+	return llm.Generate(ctx, systemPrompt, userPrompt)
+}
+
+// stream invokes sp, forwarding the visible answer text to the
+// WithStreamHandler callback as it arrives, and returns the complete
+// response. Nothing is forwarded until text outside <think> blocks appears,
+// so an attempt that produces only reasoning streams nothing.
+func (a *Agent) stream(ctx context.Context, sp LLMStreamProvider, systemPrompt, userPrompt string) (LLMResponse, error) {
+	var chunks <-chan string
+	var final <-chan LLMResponse
+	var err error
+	if ps, ok := sp.(ParamStreamProvider); ok && a.seed != nil {
+		chunks, final, err = ps.GenerateStreamWithParams(ctx, systemPrompt, userPrompt, GenParams{Seed: a.seed})
+	} else {
+		chunks, final, err = sp.GenerateStream(ctx, systemPrompt, userPrompt)
+	}
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	var text strings.Builder
+	sent := 0
+	forward := func(done bool) {
+		visible := streamVisible(text.String(), done)
+		if strings.TrimSpace(visible) == "" || len(visible) <= sent {
+			return
+		}
+		a.streamHandler(visible[sent:])
+		sent = len(visible)
+	}
+read:
+	for {
+		select {
+		case <-ctx.Done():
+			return LLMResponse{}, ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				break read
+			}
+			text.WriteString(chunk)
+			forward(false)
+		}
+	}
+	forward(true)
+	var resp LLMResponse
+	select {
+	case <-ctx.Done():
+		return LLMResponse{}, ctx.Err()
+	case r, ok := <-final:
+		if !ok {
+			return LLMResponse{}, errStreamInterrupted
+		}
+		resp = r
+	}
+	if resp.Text == "" {
+		resp.Text = text.String()
+	}
+	return resp, nil
+}
+
+// errStreamInterrupted is returned when a provider closes its final channel
+// without sending a response, which LLMStreamProvider reserves for a failure
+// mid-stream.
+var errStreamInterrupted = errors.New("stream ended without a final response")
+
+// streamVisible returns the part of the streamed text s that may be shown:
+// leading whitespace, <think> blocks and an unclosed trailing block are
+// left out and, until the stream is done, so is a partial "<think>" tag at
+// the end. The result only grows as more text arrives.
+func streamVisible(s string, done bool) string {
+	s = thinkRegex.ReplaceAllString(s, "")
+	if i := strings.Index(s, "<think>"); i >= 0 {
+		s = s[:i]
+	}
+	if !done {
+		for n := len("<think>") - 1; n > 0; n-- {
+			if strings.HasSuffix(s, "<think>"[:n]) {
+				s = s[:len(s)-n]
+				break
+			}
+		}
+	}
+	return strings.TrimLeft(s, " \t\r\n")
+}
+
+// finalReasoning returns the reasoning recorded from the run's last
+// finalizer call.
+func finalReasoning(ctx context.Context) string {
+	rs := runStateFrom(ctx)
+	if rs == nil {
+		return ""
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.finalReasoning
+}
+
+// rawResponses returns the raw model output recorded for the run by stage.
+func rawResponses(ctx context.Context) map[string][]string {
+	rs := runStateFrom(ctx)
+	if rs == nil {
+		return nil
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.rawResponses
+}
+
+// addRunCost records cost against phase for the run's CostBreakdown.
+func addRunCost(ctx context.Context, phase string, cost float64) {
+	rs := runStateFrom(ctx)
+	if rs == nil || cost == 0 {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.costs == nil {
+		rs.costs = make(map[string]float64)
+	}
+	rs.costs[phase] += cost
+}
+
+// costBreakdown returns the run's costs by phase.
+func costBreakdown(ctx context.Context) map[string]float64 {
+	rs := runStateFrom(ctx)
+	if rs == nil {
+		return nil
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.costs
+}
+
+// responseReasoning returns the model's reasoning for resp: the Reasoning
+// field when set, otherwise the contents of any <think> blocks in Text.
+func responseReasoning(resp LLMResponse) string {
+	if r := strings.TrimSpace(resp.Reasoning); r != "" {
+		return r
+	}
+	var parts []string
+	for _, m := range thinkBlockRegex.FindAllStringSubmatch(resp.Text, -1) {
+		if r := strings.TrimSpace(m[1]); r != "" {
+			parts = append(parts, r)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func isFinalStage(stage string) bool {
+	return stage == stageFinalizer || stage == stageCondense || stage == stageValidator || stage == stageSummary || stage == stageJSONRepair
+}
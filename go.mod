@@ -2,4 +2,7 @@ module github.com/smhanov/laconic
 
 go 1.21
 
-require github.com/smhanov/llmhub v0.0.0-20260211233119-48b59a9ec6f1
+require (
+	github.com/ledongthuc/pdf v0.0.0-20240201131950-da5b75280b06
+	github.com/smhanov/llmhub v0.0.0-20260211233119-48b59a9ec6f1
+)
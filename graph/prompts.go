@@ -52,10 +52,11 @@ Search Snippets:
 {{end}}
 
 Example output:
-{"new_facts": [{"content": "Acme Corp reported Q3 2025 revenue of $5.2B, up 12% YoY", "source_url": "https://example.com/article"}, {"content": "Acme Corp stock price is $142.50 as of Oct 2025", "source_url": "https://example.com/quote"}], "read_more_urls": ["https://example.com/full-report"]}
+{"new_facts": [{"content": "Acme Corp reported Q3 2025 revenue of $5.2B, up 12% YoY", "source_url": "https://example.com/article", "confidence": 0.95}, {"content": "Acme Corp stock price is $142.50 as of Oct 2025", "source_url": "https://example.com/quote", "confidence": 0.8}], "read_more_urls": ["https://example.com/full-report"]}
 
 Rules:
 - Only include facts with specific entities, numbers, or dates from the snippets.
+- Give each fact a "confidence" from 0 to 1: near 1 when a snippet states it plainly, lower when it is implied, partial, or ambiguous.
 - If a snippet is cut off or only has a title, add its URL to read_more_urls.
 - If nothing is relevant, return {"new_facts": [], "read_more_urls": []}.
 
@@ -77,10 +78,11 @@ Content:
 {{.Content}}
 
 Example output:
-{"new_facts": [{"content": "Acme Corp net income was $800M in Q3 2025", "source_url": "https://example.com/page"}]}
+{"new_facts": [{"content": "Acme Corp net income was $800M in Q3 2025", "source_url": "https://example.com/page", "confidence": 0.95}]}
 
 Rules:
 - Only include facts with specific entities, numbers, or dates.
+- Give each fact a "confidence" from 0 to 1: near 1 when the page states it plainly, lower when it is implied, partial, or ambiguous.
 - If nothing is relevant, return {"new_facts": []}.
 
 Now output your JSON:
@@ -1,14 +1,34 @@
 package graph
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode"
+)
 
 // AtomicFact represents a single piece of verified information
 // extracted from a search result or web page.
 type AtomicFact struct {
-	ID        string `json:"id"`
-	Content   string `json:"content"`
-	SourceURL string `json:"source_url,omitempty"`
-	Timestamp int64  `json:"timestamp"`
+	ID         string  `json:"id"`
+	Content    string  `json:"content"`
+	SourceURL  string  `json:"source_url,omitempty"`
+	Timestamp  int64   `json:"timestamp"`
+	Confidence float64 `json:"confidence"` // 0-1; defaults to 1.0 when absent from JSON
+}
+
+// UnmarshalJSON decodes a fact, defaulting Confidence to 1.0 when the field
+// is absent so that facts from older sessions and terse models are kept.
+func (f *AtomicFact) UnmarshalJSON(data []byte) error {
+	type plain AtomicFact
+	p := plain{Confidence: 1}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*f = AtomicFact(p)
+	return nil
 }
 
 // Node represents a search topic or query in the exploration graph.
@@ -21,6 +41,90 @@ type Node struct {
 // Notebook acts as the agent's short-term memory, highly compressed.
 type Notebook struct {
 	Clues []AtomicFact `json:"clues"`
+
+	index    map[string]bool   // normalized contents of Clues[:indexed]
+	tokens   []map[string]bool // FactTokens of Clues[:indexed]
+	contents []string          // contents of Clues[:indexed]
+	indexed  int
+	base     *AtomicFact // &Clues[0] when last indexed
+}
+
+// HasContent reports whether a clue with the same content, ignoring case and
+// whitespace, is already in the notebook. The lookup index is extended with
+// clues appended since the last call, so each check is O(1) amortized.
+// Assigning a slice with different clues to Clues rebuilds the index; clues
+// already in the notebook must not be edited in place.
+func (n *Notebook) HasContent(content string) bool {
+	n.refresh()
+	return n.index[normalizeContent(content)]
+}
+
+// ClueTokens returns FactTokens(n.Clues[i].Content), computed once per clue
+// and kept with the HasContent index. The set must not be modified.
+func (n *Notebook) ClueTokens(i int) map[string]bool {
+	n.refresh()
+	return n.tokens[i]
+}
+
+// refresh indexes the clues appended since the last call. When Clues has
+// moved to a new array, which append also does as it grows, the indexed
+// clues are compared with the ones indexed before and the index starts over
+// if any differ.
+func (n *Notebook) refresh() {
+	if n.index == nil || n.indexed > len(n.Clues) || n.indexed > 0 && &n.Clues[0] != n.base && !n.sameIndexed() {
+		n.index = make(map[string]bool, len(n.Clues))
+		n.tokens = n.tokens[:0]
+		n.contents = n.contents[:0]
+		n.indexed = 0
+	}
+	if len(n.Clues) > 0 {
+		n.base = &n.Clues[0]
+	}
+	for ; n.indexed < len(n.Clues); n.indexed++ {
+		content := n.Clues[n.indexed].Content
+		n.index[normalizeContent(content)] = true
+		n.tokens = append(n.tokens, FactTokens(content))
+		n.contents = append(n.contents, content)
+	}
+}
+
+// sameIndexed reports whether Clues still starts with the indexed clues.
+func (n *Notebook) sameIndexed() bool {
+	for i, content := range n.contents {
+		if n.Clues[i].Content != content {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeContent(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// FactTokens returns the set of lowercase words in fact, trimmed of
+// surrounding punctuation so "$5B," and "5b" match while "2.1" stays whole.
+func FactTokens(fact string) map[string]bool {
+	words := strings.Fields(strings.ToLower(fact))
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		w = strings.TrimFunc(w, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if w != "" {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+// FactID returns the stable ID of a fact with the given content: "fact-"
+// and the first 8 hex digits of the SHA-256 of the content, ignoring case
+// and whitespace. The same fact gets the same ID in every session, so facts
+// with equal IDs are duplicates; HasContent matches exactly those.
+func FactID(content string) string {
+	sum := sha256.Sum256([]byte(normalizeContent(content)))
+	return "fact-" + hex.EncodeToString(sum[:4])
 }
 
 // RationalPlan defines the strategy.
@@ -52,8 +156,9 @@ func NewAgentState(question string) *AgentState {
 // NewAtomicFact creates a fact with a timestamp.
 func NewAtomicFact(content, sourceURL string) AtomicFact {
 	return AtomicFact{
-		Content:   content,
-		SourceURL: sourceURL,
-		Timestamp: time.Now().Unix(),
+		Content:    content,
+		SourceURL:  sourceURL,
+		Timestamp:  time.Now().Unix(),
+		Confidence: 1,
 	}
 }
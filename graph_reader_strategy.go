@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -22,15 +24,28 @@ const (
 	graphFinalizerSystemPrompt   = "Write the answer using only the provided knowledge. Think briefly, keep reasoning under 200 words. Then write a thorough answer."
 	graphCondenserSystemPrompt   = "Condense these facts into one brief paragraph. Keep all numbers, dates, and names. Remove duplicates. Think briefly, keep reasoning under 50 words. Output only the paragraph."
 
+	// graphCondenserSourcesSystemPrompt is the condenser prompt used when
+	// GraphReaderConfig.PreserveSources is set.
+	graphCondenserSourcesSystemPrompt = "Condense these facts into one brief paragraph. Keep all numbers, dates, and names. Keep each fact's [n] source markers next to the claims they support. Remove duplicates. Think briefly, keep reasoning under 50 words. Output only the paragraph."
+
+	// Finalizer variants for the non-strict grounding modes.
+	graphFinalizerAugmentSystemPrompt = "Write the answer using the provided knowledge, supplemented by your own knowledge where needed. Make clear which claims come from internal knowledge. Think briefly, keep reasoning under 200 words. Then write a thorough answer."
+	graphFinalizerOffSystemPrompt     = "Write the answer using the provided knowledge and your own knowledge. Think briefly, keep reasoning under 200 words. Then write a thorough answer."
+
 	// graphFinalizerRetrySystemPrompt is the simplified system prompt used
 	// when the primary finalizer attempt returns empty content (model spent
 	// all output tokens on thinking). It avoids mentioning thinking at all,
 	// which may help some models allocate more tokens to the answer.
 	graphFinalizerRetrySystemPrompt = "Answer the question using the provided knowledge. Be concise."
 
-	// maxExtractContentLen limits the page content sent to the extractor.
-	// Prevents overwhelming the model's context window with huge pages.
-	maxExtractContentLen = 8000
+	// maxExtractContentTokens limits the page content sent to the extractor
+	// (about 8000 characters). Prevents overwhelming the model's context
+	// window with huge pages.
+	maxExtractContentTokens = 2000
+
+	// maxExtractPerDomain is how many snippets from one domain reach the
+	// extractor with GraphReaderConfig.DiverseExtraction.
+	maxExtractPerDomain = 2
 
 	// maxDirectFacts is the maximum number of deduplicated facts sent
 	// directly to the finalizer. Above this threshold, facts are compressed
@@ -41,9 +56,10 @@ const (
 	// factCondenseBatch is the number of facts per condensation LLM call.
 	factCondenseBatch = 25
 
-	// maxRetryKnowledgeLen caps the knowledge block length on finalizer
-	// retry attempts. Shorter input leaves more output-token budget.
-	maxRetryKnowledgeLen = 1500
+	// maxRetryKnowledgeTokens caps the knowledge block on finalizer retry
+	// attempts (about 1500 characters). Shorter input leaves more
+	// output-token budget.
+	maxRetryKnowledgeTokens = 375
 
 	// maxFinalizerRetries is how many retry attempts to make if the
 	// finalizer returns empty content.
@@ -53,6 +69,10 @@ const (
 type graphReaderStrategy struct {
 	agent *Agent
 	cfg   GraphReaderConfig
+
+	// deepRead selects the "deep-read" variant: each step reads the top
+	// search result in full and no neighbor queries are added.
+	deepRead bool
 }
 
 // stripThinking removes <think> blocks from the response, logging the reasoning
@@ -68,7 +88,7 @@ func (s *graphReaderStrategy) stripThinking(label, text string) string {
 				if len(reasoning) > 2000 {
 					reasoning = reasoning[:2000] + "... [truncated]"
 				}
-				fmt.Printf("[LACONIC DEBUG] %s Reasoning (%d chars):\n%s\n", label, len(m[1]), reasoning)
+				s.agent.debugf("%s Reasoning (%d chars):\n%s", label, len(m[1]), reasoning)
 			}
 		}
 	}
@@ -92,7 +112,7 @@ func (s *graphReaderStrategy) getResponseContent(label string, resp LLMResponse)
 			if len(r) > 2000 {
 				r = r[:2000] + "... [truncated]"
 			}
-			fmt.Printf("[LACONIC DEBUG] %s: Text empty, falling back to reasoning (%d chars):\n%s\n",
+			s.agent.debugf("%s: Text empty, falling back to reasoning (%d chars):\n%s",
 				label, len(resp.Reasoning), r)
 		}
 		// Strip any <think> blocks that might appear within reasoning too.
@@ -108,6 +128,31 @@ func newGraphReaderStrategy(a *Agent) (Strategy, error) {
 	if cfg.MaxSteps <= 0 {
 		cfg.MaxSteps = defaultGraphReaderSteps
 	}
+	if cfg.MaxNeighborsPerStep <= 0 {
+		cfg.MaxNeighborsPerStep = defaultMaxNeighborsPerStep
+	}
+	if cfg.MinPageChars <= 0 {
+		cfg.MinPageChars = defaultMinPageChars
+	}
+	if cfg.CondenseConcurrency <= 0 {
+		cfg.CondenseConcurrency = defaultCondenseConcurrency
+	}
+	if cfg.ReadConcurrency <= 0 {
+		cfg.ReadConcurrency = defaultReadConcurrency
+	}
+	if cfg.DedupThreshold <= 0 {
+		cfg.DedupThreshold = defaultFactDedupThreshold
+	}
+	switch cfg.DedupScope {
+	case "":
+		cfg.DedupScope = DedupGlobal
+	case DedupGlobal, DedupPerNode, DedupOff:
+	default:
+		return nil, fmt.Errorf("unknown graph-reader dedup scope %q", cfg.DedupScope)
+	}
+	if cfg.FixedPlan != nil && strings.TrimSpace(cfg.FixedPlan.ResearchGoal) == "" {
+		return nil, errors.New("graph-reader fixed plan has an empty research goal")
+	}
 	if cfg.Planner == nil {
 		cfg.Planner = a.planner
 	}
@@ -120,32 +165,94 @@ func newGraphReaderStrategy(a *Agent) (Strategy, error) {
 	if cfg.Finalizer == nil {
 		cfg.Finalizer = a.finalizer
 	}
+	cfg.Prompts = cfg.Prompts.withDefaults()
 
 	return &graphReaderStrategy{agent: a, cfg: cfg}, nil
 }
 
+// withDefaults fills unset system prompts (other than the finalizer and
+// condenser, which vary by mode) and templates with the built-in ones.
+func (p GraphPrompts) withDefaults() GraphPrompts {
+	setDefault := func(v *string, def string) {
+		if strings.TrimSpace(*v) == "" {
+			*v = def
+		}
+	}
+	setDefault(&p.PlannerSystem, graphPlannerSystemPrompt)
+	setDefault(&p.ExtractorSystem, graphExtractorSystemPrompt)
+	setDefault(&p.NeighborSystem, graphNeighborSystemPrompt)
+	setDefault(&p.AnswerCheckSystem, graphAnswerCheckSystemPrompt)
+	p.FinalizerSystem = strings.TrimSpace(p.FinalizerSystem)
+	p.CondenserSystem = strings.TrimSpace(p.CondenserSystem)
+	for _, t := range []struct {
+		tmpl **template.Template
+		def  *template.Template
+	}{
+		{&p.Plan, graph.TmplPlan},
+		{&p.Init, graph.TmplInit},
+		{&p.Extract, graph.TmplExtract},
+		{&p.ExtractText, graph.TmplExtractText},
+		{&p.Neighbors, graph.TmplNeighbors},
+		{&p.AnswerCheck, graph.TmplAnswerCheck},
+	} {
+		if *t.tmpl == nil {
+			*t.tmpl = t.def
+		}
+	}
+	return p
+}
+
+// newDeepReadStrategy builds the "deep-read" strategy: graph-reader without
+// neighbor expansion, where every step reads the top search result in full.
+// It uses GraphReaderConfig and requires a FetchProvider.
+func newDeepReadStrategy(a *Agent) (Strategy, error) {
+	strategy, err := newGraphReaderStrategy(a)
+	if err != nil {
+		return nil, err
+	}
+	g := strategy.(*graphReaderStrategy)
+	g.deepRead = true
+	return g, nil
+}
+
 func (s *graphReaderStrategy) Name() string {
+	if s.deepRead {
+		return "deep-read"
+	}
 	return "graph-reader"
 }
 
-func (s *graphReaderStrategy) Answer(ctx context.Context, question string) (Result, error) {
-	question = strings.TrimSpace(question)
-	if question == "" {
-		return Result{}, errors.New("question is empty")
-	}
+// validateConfig reports a model or provider the traversal needs but was
+// not given. The search provider is checked per call, since
+// WithSearchProviderFor can supply one.
+func (s *graphReaderStrategy) validateConfig() error {
 	if s.cfg.Planner == nil {
-		return Result{}, errors.New("planner model is not configured")
+		return errors.New("planner model is not configured")
 	}
 	if s.cfg.Extractor == nil {
-		return Result{}, errors.New("extractor model is not configured")
+		return errors.New("extractor model is not configured")
 	}
 	if s.cfg.Neighbor == nil {
-		return Result{}, errors.New("neighbor model is not configured")
+		return errors.New("neighbor model is not configured")
 	}
 	if s.cfg.Finalizer == nil {
-		return Result{}, errors.New("finalizer model is not configured")
+		return errors.New("finalizer model is not configured")
 	}
-	if s.agent.searcher == nil {
+	if s.deepRead && s.agent.fetcher == nil {
+		return errors.New("fetch provider is not configured")
+	}
+	return nil
+}
+
+func (s *graphReaderStrategy) Answer(ctx context.Context, question string) (Result, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return Result{}, errors.New("question is empty")
+	}
+	if err := s.validateConfig(); err != nil {
+		return Result{}, err
+	}
+	if s.agent.searchProvider(ctx) == nil {
 		return Result{}, errors.New("search provider is not configured")
 	}
 
@@ -154,36 +261,76 @@ func (s *graphReaderStrategy) Answer(ctx context.Context, question string) (Resu
 	state := graph.NewAgentState(question)
 
 	// Pre-populate notebook from prior knowledge if supplied.
-	if pk := s.agent.priorKnowledge; pk != "" {
-		var priorFacts []graph.AtomicFact
-		if err := json.Unmarshal([]byte(pk), &priorFacts); err == nil {
-			state.Notebook.Clues = append(state.Notebook.Clues, priorFacts...)
-		} else {
-			// Plain text: wrap as a single atomic fact.
-			state.Notebook.Clues = append(state.Notebook.Clues, graph.AtomicFact{
-				ID:      "prior-1",
-				Content: pk,
-			})
+	if pk := answerOptions(ctx).priorKnowledge; pk != "" {
+		priorFacts, err := ParseKnowledge(pk)
+		if err != nil {
+			// Malformed JSON: keep the raw text as a single atomic fact.
+			priorFacts = []graph.AtomicFact{plainKnowledgeFact(pk)}
 		}
+		addPriorFacts(state, priorFacts)
+	}
+	if s.agent.questionContext {
+		s.addQuestionFacts(state, questionContextFacts(questionPremises(question)))
 	}
 
-	plan, cost, err := s.generatePlan(ctx, question)
-	totalCost += cost
-	if err != nil {
-		return Result{}, fmt.Errorf("graph planner: %w", err)
+	var queries []string
+
+	// fail returns err together with the cost and facts accumulated so far
+	// when partial results were requested.
+	fail := func(err error) (Result, error) {
+		if s.agent.returnPartialOnError {
+			return Result{Cost: totalCost, Knowledge: encodeKnowledge(state), Queries: queries, Sources: factSources(state.Notebook.Clues)}, err
+		}
+		return Result{}, err
 	}
-	state.Plan = plan
 
-	initialNodes, cost, err := s.generateInitialNodes(ctx, state.Plan)
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+	// budgetHit is set once WithMaxLLMCalls or WithMaxCost stops the
+	// traversal; the run then goes straight to the finalizer.
+	plan, cost, err := s.plan(ctx, question)
 	totalCost += cost
-	if err != nil {
-		return Result{}, fmt.Errorf("graph init nodes: %w", err)
+	budgetHit := isBudgetError(err)
+	if err != nil && !budgetHit && !timedOut(ctx) {
+		return fail(fmt.Errorf("graph planner: %w", err))
 	}
-	for _, node := range initialNodes {
-		state.Queue = append(state.Queue, node)
+	if !budgetHit {
+		state.Plan = plan
+
+		initialNodes, cost, err := s.initialNodes(ctx, state.Plan)
+		totalCost += cost
+		budgetHit = isBudgetError(err)
+		if err != nil && !budgetHit && !timedOut(ctx) {
+			return fail(fmt.Errorf("graph init nodes: %w", err))
+		}
+		for _, node := range initialNodes {
+			state.Queue = append(state.Queue, node)
+		}
 	}
 
-	for step := 0; step < s.cfg.MaxSteps && len(state.Queue) > 0; step++ {
+	// reason records why the traversal ended; it is refined below.
+	reason := StopMaxSteps
+	maxSteps := iterationLimit(ctx, s.cfg.MaxSteps)
+steps:
+	for step := 0; !budgetHit && step < maxSteps; step++ {
+		// Stop promptly on cancellation even if a provider ignores ctx.
+		select {
+		case <-ctx.Done():
+			if timedOut(ctx) {
+				break steps
+			}
+			return fail(ctx.Err())
+		default:
+		}
+		if len(state.Queue) == 0 {
+			reason = StopQueueExhausted
+			break
+		}
+		if budgetExceeded(ctx) != nil {
+			budgetHit = true
+			break
+		}
 		current := state.Queue[0]
 		state.Queue = state.Queue[1:]
 
@@ -191,95 +338,290 @@ func (s *graphReaderStrategy) Answer(ctx context.Context, question string) (Resu
 			continue
 		}
 		state.Visited[current.Name] = true
+		queries = append(queries, current.Name)
 
-		results, err := s.agent.searcher.Search(ctx, current.Name)
+		stepCtx, cancel := s.stepContext(ctx)
+		results, err := s.agent.searchQuery(stepCtx, current.Name)
+		cancel()
 		if err != nil {
-			return Result{}, fmt.Errorf("search: %w", err)
+			if timedOut(ctx) {
+				break steps
+			}
+			if s.stepTimedOut(ctx, err) {
+				s.agent.debugf("Search timed out, skipping node: %s", current.Name)
+				continue
+			}
+			return fail(fmt.Errorf("search: %w", err))
 		}
+		results = s.agent.prepareResults(results)
 		totalCost += s.agent.searchCost
 
-		extraction, cost, err := s.extractFacts(ctx, state.Plan, current.Name, results)
-		totalCost += cost
-		if err != nil {
-			if s.agent.debug {
-				fmt.Printf("[LACONIC DEBUG] Fact extraction failed: %v\n", err)
-			}
-		}
-		if err == nil {
-			s.addFacts(state, extraction.NewFacts)
-			for _, url := range extraction.ReadMoreURLs {
-				if s.agent.fetcher == nil {
-					continue
-				}
-				if isAdOrTrackerURL(url) {
-					if s.agent.debug {
-						fmt.Printf("[LACONIC DEBUG] Skipping ad/tracker URL: %s\n", url)
-					}
-					continue
-				}
-				content, err := s.agent.fetcher.Fetch(ctx, url)
-				if err != nil {
-					continue
-				}
-				// Skip trivially short pages (titles only, JS-rendered, etc.)
-				if len(strings.TrimSpace(content)) < 200 {
-					if s.agent.debug {
-						fmt.Printf("[LACONIC DEBUG] Skipping too-short page content (%d chars): %s\n", len(content), url)
-					}
-					continue
-				}
-				deepFacts, cost, err := s.extractFactsFromText(ctx, state.Plan, url, content)
-				totalCost += cost
-				if err != nil {
-					continue
-				}
-				s.addFacts(state, deepFacts)
-			}
+		if s.deepRead {
+			totalCost += s.readTopResult(ctx, state, current.Name, results)
+		} else {
+			totalCost += s.readResults(ctx, state, current.Name, results)
 		}
 
+		totalCost += s.emitProgress(ctx, step+1, state)
+
+		answered := false
 		if len(state.Notebook.Clues) == 0 {
-			if s.agent.debug {
-				fmt.Println("[LACONIC DEBUG] Notebook still empty, skipping answer check")
-			}
+			s.agent.debugf("Notebook still empty, skipping answer check")
 		} else if len(state.Notebook.Clues) < 5 {
-			if s.agent.debug {
-				fmt.Printf("[LACONIC DEBUG] Only %d facts collected, skipping answer check (need ≥5)\n", len(state.Notebook.Clues))
-			}
+			s.agent.debugf("Only %d facts collected, skipping answer check (need ≥5)", len(state.Notebook.Clues))
+		} else if s.cfg.AnswerCheck != nil {
+			answered = s.cfg.AnswerCheck(state)
+			s.agent.debugf("Custom answer check: %v", answered)
 		} else {
-			canAnswer, cost, err := s.canAnswer(ctx, state)
+			stepCtx, cancel := s.stepContext(ctx)
+			canAnswer, cost, err := s.canAnswer(stepCtx, state)
+			cancel()
 			totalCost += cost
-			if err == nil && canAnswer {
-				break
-			}
+			answered = err == nil && canAnswer
 		}
 
-		neighbors, cost, err := s.findNeighbors(ctx, state, current.Name)
-		totalCost += cost
-		if err != nil {
+		// Deep-read follows the planned queries only.
+		if !answered && !s.deepRead {
+			totalCost += s.expandNeighbors(ctx, state, current.Name)
+		}
+		s.agent.emitIteration(IterationEvent{
+			Iteration: step + 1,
+			Decision:  PlannerDecision{Action: PlannerActionSearch, Query: current.Name},
+			Query:     current.Name,
+			Results:   len(results),
+			Cost:      totalCost,
+		})
+		if answered {
+			reason = StopAnswerCheckPassed
+			break
+		}
+	}
+
+	if budgetHit {
+		reason = StopBudgetExceeded
+	}
+	// After a WithTimeout deadline the answer is written on the caller's
+	// context from the facts gathered so far.
+	timeout := !budgetHit && timedOut(ctx)
+	if timeout {
+		reason = StopTimeout
+	}
+	ctx = finishContext(ctx)
+	answer, salvaged, cost, err := s.finalize(ctx, state)
+	totalCost += cost
+	if err != nil {
+		if budgetHit {
+			err = fmt.Errorf("%w: %w", budgetExceeded(ctx), err)
+		} else if timeout {
+			err = fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return fail(err)
+	}
+	if salvaged {
+		reason = StopSalvaged
+	}
+	res := Result{Answer: answer, Cost: totalCost, Knowledge: encodeKnowledge(state), StopReason: reason, Queries: queries, Sources: factSources(state.Notebook.Clues)}
+	if budgetHit {
+		return res, budgetExceeded(ctx)
+	}
+	if timeout {
+		return res, ErrTimeout
+	}
+	return res, nil
+}
+
+// expandNeighbors queues the follow-up nodes the model suggests after
+// searching name, up to MaxNeighborsPerStep. It returns the cost.
+func (s *graphReaderStrategy) expandNeighbors(ctx context.Context, state *graph.AgentState, name string) float64 {
+	stepCtx, cancel := s.stepContext(ctx)
+	neighbors, cost, err := s.findNeighbors(stepCtx, state, name)
+	cancel()
+	if err != nil {
+		return cost
+	}
+	added := 0
+	for _, node := range neighbors {
+		if added >= s.cfg.MaxNeighborsPerStep {
+			s.agent.debugf("Neighbor limit (%d) reached, dropping remaining suggestions", s.cfg.MaxNeighborsPerStep)
+			break
+		}
+		if state.Visited[node.Name] || s.isQueued(state, node.Name) {
 			continue
 		}
-		for _, node := range neighbors {
-			if state.Visited[node.Name] || s.isQueued(state, node.Name) {
-				continue
-			}
-			state.Queue = append(state.Queue, node)
+		state.Queue = append(state.Queue, node)
+		added++
+	}
+	return cost
+}
+
+// readResults extracts facts from the search results' snippets, then reads
+// the pages the extractor asked to see in full. It returns the cost.
+func (s *graphReaderStrategy) readResults(ctx context.Context, state *graph.AgentState, query string, results []SearchResult) float64 {
+	snippets := results
+	if s.cfg.DiverseExtraction {
+		snippets = diverseResults(results, maxExtractPerDomain)
+	}
+	nodeStart := len(state.Notebook.Clues)
+	stepCtx, cancel := s.stepContext(ctx)
+	extraction, totalCost, err := s.extractFacts(stepCtx, state.Plan, query, snippets)
+	cancel()
+	if err != nil {
+		s.agent.debugf("Fact extraction failed: %v", err)
+		return totalCost
+	}
+	s.addFacts(state, nodeStart, extraction.NewFacts)
+
+	// Pages are read concurrently, but their facts join the notebook in the
+	// order the extractor listed them so runs stay reproducible.
+	pageFacts := make([][]graph.AtomicFact, len(extraction.ReadMoreURLs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.cfg.ReadConcurrency)
+	for i, url := range extraction.ReadMoreURLs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, url string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			facts, cost := s.readPage(ctx, state.Plan, results, url)
+			mu.Lock()
+			pageFacts[i] = facts
+			totalCost += cost
+			mu.Unlock()
+		}(i, url)
+	}
+	wg.Wait()
+	for _, facts := range pageFacts {
+		s.addFacts(state, nodeStart, facts)
+	}
+	return totalCost
+}
+
+// readPage reads url in full and extracts facts from it, or returns its
+// snippet as a fact when the page is too short and SnippetFallback is set.
+// It returns the facts and the cost.
+func (s *graphReaderStrategy) readPage(ctx context.Context, plan graph.RationalPlan, results []SearchResult, url string) ([]graph.AtomicFact, float64) {
+	content, tooShort := s.pageContent(ctx, results, url)
+	if tooShort && s.cfg.SnippetFallback {
+		return snippetFacts(results, url), 0
+	}
+	if content == "" {
+		return nil, 0
+	}
+	stepCtx, cancel := s.stepContext(ctx)
+	facts, cost, err := s.extractFactsFromText(stepCtx, plan, url, content)
+	cancel()
+	if err != nil {
+		s.agent.debugf("Fact extraction failed for %s: %v", url, err)
+		return nil, cost
+	}
+	return facts, cost
+}
+
+// readTopResult is the deep-read step: it reads the first result whose page
+// can be read in full and extracts facts from it. When no page is readable
+// it falls back to the snippets so the step is not wasted. It returns the
+// cost.
+func (s *graphReaderStrategy) readTopResult(ctx context.Context, state *graph.AgentState, query string, results []SearchResult) float64 {
+	nodeStart := len(state.Notebook.Clues)
+	for _, r := range results {
+		content, _ := s.pageContent(ctx, results, r.URL)
+		if content == "" {
+			continue
+		}
+		s.agent.debugf("Deep-reading %s", r.URL)
+		stepCtx, cancel := s.stepContext(ctx)
+		facts, cost, err := s.extractFactsFromText(stepCtx, state.Plan, r.URL, content)
+		cancel()
+		if err != nil {
+			s.agent.debugf("Fact extraction failed: %v", err)
+			return cost
 		}
+		s.addFacts(state, nodeStart, facts)
+		return cost
 	}
 
-	answer, cost, err := s.finalize(ctx, state)
+	s.agent.debugf("No readable page for %q, using snippets", query)
+	stepCtx, cancel := s.stepContext(ctx)
+	extraction, cost, err := s.extractFacts(stepCtx, state.Plan, query, results)
+	cancel()
+	if err == nil {
+		s.addFacts(state, nodeStart, extraction.NewFacts)
+	}
+	return cost
+}
+
+// answerFromText runs the plan → extract → finalize pipeline over docText
+// instead of search results. Long documents are extracted in chunks.
+func (s *graphReaderStrategy) answerFromText(ctx context.Context, question, docText string) (Result, error) {
+	if s.cfg.Planner == nil {
+		return Result{}, errors.New("planner model is not configured")
+	}
+	if s.cfg.Extractor == nil {
+		return Result{}, errors.New("extractor model is not configured")
+	}
+	if s.cfg.Finalizer == nil {
+		return Result{}, errors.New("finalizer model is not configured")
+	}
+
+	var totalCost float64
+	state := graph.NewAgentState(question)
+
+	plan, cost, err := s.plan(ctx, question)
 	totalCost += cost
 	if err != nil {
-		return Result{}, err
+		return Result{Cost: totalCost}, fmt.Errorf("graph planner: %w", err)
 	}
+	state.Plan = plan
 
-	// Encode collected knowledge as JSON.
-	knowledge := ""
-	if len(state.Notebook.Clues) > 0 {
-		if kb, err := json.Marshal(state.Notebook.Clues); err == nil {
-			knowledge = string(kb)
+	for i, chunk := range s.agent.splitTokens(docText, maxExtractContentTokens) {
+		facts, cost, err := s.extractFactsFromText(ctx, state.Plan, "provided document", chunk)
+		totalCost += cost
+		if err != nil {
+			s.agent.debugf("Document extraction failed for chunk %d: %v", i+1, err)
+			continue
 		}
+		s.addFacts(state, 0, facts)
+	}
+
+	answer, salvaged, cost, err := s.finalize(ctx, state)
+	totalCost += cost
+	if err != nil {
+		return Result{Cost: totalCost, Knowledge: encodeKnowledge(state)}, err
 	}
-	return Result{Answer: answer, Cost: totalCost, Knowledge: knowledge}, nil
+	reason := StopAnswered
+	if salvaged {
+		reason = StopSalvaged
+	}
+	return Result{Answer: answer, Cost: totalCost, Knowledge: encodeKnowledge(state), StopReason: reason, Sources: factSources(state.Notebook.Clues)}, nil
+}
+
+// stepContext derives a context bounded by StepTimeout for a single
+// traversal operation. The caller must call the returned cancel function.
+func (s *graphReaderStrategy) stepContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.cfg.StepTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.cfg.StepTimeout)
+}
+
+// stepTimedOut reports whether err came from a StepTimeout expiring rather
+// than from the caller's own context being cancelled.
+func (s *graphReaderStrategy) stepTimedOut(ctx context.Context, err error) bool {
+	return s.cfg.StepTimeout > 0 && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded)
+}
+
+// encodeKnowledge serializes the notebook facts as a JSON array, or returns
+// an empty string when no facts were collected.
+func encodeKnowledge(state *graph.AgentState) string {
+	if len(state.Notebook.Clues) == 0 {
+		return ""
+	}
+	kb, err := json.Marshal(state.Notebook.Clues)
+	if err != nil {
+		return ""
+	}
+	return string(kb)
 }
 
 type planResponse struct {
@@ -297,22 +639,47 @@ type answerCheckResponse struct {
 	CanAnswer bool `json:"can_answer"`
 }
 
+// plan returns the configured FixedPlan for question, or asks the planner
+// model for one.
+func (s *graphReaderStrategy) plan(ctx context.Context, question string) (graph.RationalPlan, float64, error) {
+	if s.cfg.FixedPlan == nil {
+		return s.generatePlan(ctx, question)
+	}
+	plan := *s.cfg.FixedPlan
+	plan.OriginalQuestion = question
+	plan.Strategy = trimStrings(plan.Strategy)
+	plan.KeyElements = trimStrings(plan.KeyElements)
+	return plan, 0, nil
+}
+
+// initialNodes returns the configured SeedQueries as queue nodes, or asks the
+// planner model for the first queries.
+func (s *graphReaderStrategy) initialNodes(ctx context.Context, plan graph.RationalPlan) ([]graph.Node, float64, error) {
+	if len(s.cfg.SeedQueries) == 0 {
+		return s.generateInitialNodes(ctx, plan)
+	}
+	var nodes []graph.Node
+	for _, q := range trimStrings(s.cfg.SeedQueries) {
+		nodes = append(nodes, graph.Node{Name: q, Rationale: "seed", Depth: 0})
+	}
+	return nodes, 0, nil
+}
+
 func (s *graphReaderStrategy) generatePlan(ctx context.Context, question string) (graph.RationalPlan, float64, error) {
-	user, err := renderTemplate(graph.TmplPlan, map[string]any{"Question": question})
+	user, err := renderTemplate(s.cfg.Prompts.Plan, map[string]any{"Question": question})
 	if err != nil {
 		return graph.RationalPlan{}, 0, err
 	}
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Plan System Prompt:\n%s\n", graphPlannerSystemPrompt)
-		fmt.Printf("[LACONIC DEBUG] Graph Plan User Prompt:\n%s\n", user)
-	}
-	resp, err := s.cfg.Planner.Generate(ctx, graphPlannerSystemPrompt, user)
+	s.agent.debugf("Graph Plan System Prompt:\n%s", s.cfg.Prompts.PlannerSystem)
+	s.agent.debugf("Graph Plan User Prompt:\n%s", user)
+	resp, err := s.agent.generate(ctx, stagePlanner, s.cfg.Planner, s.cfg.Prompts.PlannerSystem, user)
 	if err != nil {
 		return graph.RationalPlan{}, 0, err
 	}
 	raw := s.getResponseContent("Graph Plan", resp)
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Plan Response:\n%s\n", raw)
+	s.agent.debugf("Graph Plan Response:\n%s", raw)
+	if strings.TrimSpace(raw) == "" {
+		return graph.RationalPlan{}, resp.Cost, ErrEmptyLLMResponse
 	}
 
 	var parsed planResponse
@@ -347,21 +714,20 @@ func (s *graphReaderStrategy) generatePlan(ctx context.Context, question string)
 }
 
 func (s *graphReaderStrategy) generateInitialNodes(ctx context.Context, plan graph.RationalPlan) ([]graph.Node, float64, error) {
-	user, err := renderTemplate(graph.TmplInit, plan)
+	user, err := renderTemplate(s.cfg.Prompts.Init, plan)
 	if err != nil {
 		return nil, 0, err
 	}
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Init System Prompt:\n%s\n", graphPlannerSystemPrompt)
-		fmt.Printf("[LACONIC DEBUG] Graph Init User Prompt:\n%s\n", user)
-	}
-	resp, err := s.cfg.Planner.Generate(ctx, graphPlannerSystemPrompt, user)
+	s.agent.debugf("Graph Init System Prompt:\n%s", s.cfg.Prompts.PlannerSystem)
+	s.agent.debugf("Graph Init User Prompt:\n%s", user)
+	resp, err := s.agent.generate(ctx, stagePlanner, s.cfg.Planner, s.cfg.Prompts.PlannerSystem, user)
 	if err != nil {
 		return nil, 0, err
 	}
 	raw := s.getResponseContent("Graph Init", resp)
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Init Response:\n%s\n", raw)
+	s.agent.debugf("Graph Init Response:\n%s", raw)
+	if strings.TrimSpace(raw) == "" {
+		return nil, resp.Cost, ErrEmptyLLMResponse
 	}
 
 	var queries []string
@@ -419,6 +785,34 @@ func extractJSON(raw string) string {
 	return raw[start:end]
 }
 
+// partialFacts recovers the complete entries of a "new_facts" array from an
+// extractor response that was cut off, typically by the model's output
+// limit. Entries are decoded one by one until the first incomplete one. It
+// reports false if no entry could be recovered.
+func partialFacts(raw string) ([]graph.AtomicFact, bool) {
+	i := strings.Index(raw, `"new_facts"`)
+	if i < 0 {
+		return nil, false
+	}
+	j := strings.IndexByte(raw[i:], '[')
+	if j < 0 {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(raw[i+j:]))
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	var facts []graph.AtomicFact
+	for dec.More() {
+		var f graph.AtomicFact
+		if err := dec.Decode(&f); err != nil {
+			break
+		}
+		facts = append(facts, f)
+	}
+	return facts, len(facts) > 0
+}
+
 func (s *graphReaderStrategy) extractFacts(ctx context.Context, plan graph.RationalPlan, currentNode string, results []SearchResult) (extractResponse, float64, error) {
 	snippets := make([]map[string]string, 0, len(results))
 	for _, r := range results {
@@ -431,7 +825,7 @@ func (s *graphReaderStrategy) extractFacts(ctx context.Context, plan graph.Ratio
 			"Content": content,
 		})
 	}
-	user, err := renderTemplate(graph.TmplExtract, map[string]any{
+	user, err := renderTemplate(s.cfg.Prompts.Extract, map[string]any{
 		"Plan":        plan,
 		"CurrentNode": currentNode,
 		"Snippets":    snippets,
@@ -439,22 +833,26 @@ func (s *graphReaderStrategy) extractFacts(ctx context.Context, plan graph.Ratio
 	if err != nil {
 		return extractResponse{}, 0, err
 	}
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Extract System Prompt:\n%s\n", graphExtractorSystemPrompt)
-		fmt.Printf("[LACONIC DEBUG] Graph Extract User Prompt:\n%s\n", user)
-	}
-	resp, err := s.cfg.Extractor.Generate(ctx, graphExtractorSystemPrompt, user)
+	s.agent.debugf("Graph Extract System Prompt:\n%s", s.cfg.Prompts.ExtractorSystem)
+	s.agent.debugf("Graph Extract User Prompt:\n%s", user)
+	resp, err := s.agent.generate(ctx, stageExtractor, s.cfg.Extractor, s.cfg.Prompts.ExtractorSystem, user)
 	if err != nil {
 		return extractResponse{}, 0, err
 	}
 	raw := s.getResponseContent("Graph Extract", resp)
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Extract Response:\n%s\n", raw)
+	s.agent.debugf("Graph Extract Response:\n%s", raw)
+	if strings.TrimSpace(raw) == "" {
+		return extractResponse{}, resp.Cost, ErrEmptyLLMResponse
 	}
 
 	var parsed extractResponse
 	if err := json.Unmarshal([]byte(extractJSON(raw)), &parsed); err != nil {
-		return extractResponse{}, resp.Cost, fmt.Errorf("extract JSON parse: %w (raw: %.200s)", err, raw)
+		facts, ok := partialFacts(raw)
+		if !ok {
+			return extractResponse{}, resp.Cost, fmt.Errorf("extract JSON parse: %w (raw: %.200s)", err, raw)
+		}
+		s.agent.debugf("Graph Extract: recovered %d facts from truncated JSON: %v", len(facts), err)
+		parsed.NewFacts = facts
 	}
 
 	return parsed, resp.Cost, nil
@@ -462,13 +860,11 @@ func (s *graphReaderStrategy) extractFacts(ctx context.Context, plan graph.Ratio
 
 func (s *graphReaderStrategy) extractFactsFromText(ctx context.Context, plan graph.RationalPlan, sourceURL, content string) ([]graph.AtomicFact, float64, error) {
 	// Truncate very long page content to avoid overwhelming the model.
-	if len(content) > maxExtractContentLen {
-		if s.agent.debug {
-			fmt.Printf("[LACONIC DEBUG] Truncating page content from %d to %d chars: %s\n", len(content), maxExtractContentLen, sourceURL)
-		}
-		content = content[:maxExtractContentLen]
+	if n := s.agent.countTokens(content); n > maxExtractContentTokens {
+		s.agent.debugf("Truncating page content from %d to %d tokens: %s", n, maxExtractContentTokens, sourceURL)
+		content = s.agent.truncateTokens(content, maxExtractContentTokens)
 	}
-	user, err := renderTemplate(graph.TmplExtractText, map[string]any{
+	user, err := renderTemplate(s.cfg.Prompts.ExtractText, map[string]any{
 		"Plan":      plan,
 		"SourceURL": sourceURL,
 		"Content":   content,
@@ -476,31 +872,35 @@ func (s *graphReaderStrategy) extractFactsFromText(ctx context.Context, plan gra
 	if err != nil {
 		return nil, 0, err
 	}
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph ExtractText System Prompt:\n%s\n", graphExtractorSystemPrompt)
-		fmt.Printf("[LACONIC DEBUG] Graph ExtractText User Prompt:\n%s\n", user)
-	}
-	resp, err := s.cfg.Extractor.Generate(ctx, graphExtractorSystemPrompt, user)
+	s.agent.debugf("Graph ExtractText System Prompt:\n%s", s.cfg.Prompts.ExtractorSystem)
+	s.agent.debugf("Graph ExtractText User Prompt:\n%s", user)
+	resp, err := s.agent.generate(ctx, stageExtractor, s.cfg.Extractor, s.cfg.Prompts.ExtractorSystem, user)
 	if err != nil {
 		return nil, 0, err
 	}
 	raw := s.getResponseContent("Graph ExtractText", resp)
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph ExtractText Response:\n%s\n", raw)
+	s.agent.debugf("Graph ExtractText Response:\n%s", raw)
+	if strings.TrimSpace(raw) == "" {
+		return nil, resp.Cost, ErrEmptyLLMResponse
 	}
 
 	var parsed struct {
 		NewFacts []graph.AtomicFact `json:"new_facts"`
 	}
 	if err := json.Unmarshal([]byte(extractJSON(raw)), &parsed); err != nil {
-		return nil, resp.Cost, fmt.Errorf("extract text JSON parse: %w (raw: %.200s)", err, raw)
+		facts, ok := partialFacts(raw)
+		if !ok {
+			return nil, resp.Cost, fmt.Errorf("extract text JSON parse: %w (raw: %.200s)", err, raw)
+		}
+		s.agent.debugf("Graph ExtractText: recovered %d facts from truncated JSON: %v", len(facts), err)
+		parsed.NewFacts = facts
 	}
 
 	return parsed.NewFacts, resp.Cost, nil
 }
 
 func (s *graphReaderStrategy) findNeighbors(ctx context.Context, state *graph.AgentState, currentNode string) ([]graph.Node, float64, error) {
-	user, err := renderTemplate(graph.TmplNeighbors, map[string]any{
+	user, err := renderTemplate(s.cfg.Prompts.Neighbors, map[string]any{
 		"Plan":        state.Plan,
 		"Notebook":    state.Notebook,
 		"CurrentNode": currentNode,
@@ -508,17 +908,16 @@ func (s *graphReaderStrategy) findNeighbors(ctx context.Context, state *graph.Ag
 	if err != nil {
 		return nil, 0, err
 	}
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Neighbors System Prompt:\n%s\n", graphNeighborSystemPrompt)
-		fmt.Printf("[LACONIC DEBUG] Graph Neighbors User Prompt:\n%s\n", user)
-	}
-	resp, err := s.cfg.Neighbor.Generate(ctx, graphNeighborSystemPrompt, user)
+	s.agent.debugf("Graph Neighbors System Prompt:\n%s", s.cfg.Prompts.NeighborSystem)
+	s.agent.debugf("Graph Neighbors User Prompt:\n%s", user)
+	resp, err := s.agent.generate(ctx, stageNeighbor, s.cfg.Neighbor, s.cfg.Prompts.NeighborSystem, user)
 	if err != nil {
 		return nil, 0, err
 	}
 	raw := s.getResponseContent("Graph Neighbors", resp)
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph Neighbors Response:\n%s\n", raw)
+	s.agent.debugf("Graph Neighbors Response:\n%s", raw)
+	if strings.TrimSpace(raw) == "" {
+		return nil, resp.Cost, ErrEmptyLLMResponse
 	}
 
 	var queries []string
@@ -538,24 +937,23 @@ func (s *graphReaderStrategy) findNeighbors(ctx context.Context, state *graph.Ag
 }
 
 func (s *graphReaderStrategy) canAnswer(ctx context.Context, state *graph.AgentState) (bool, float64, error) {
-	user, err := renderTemplate(graph.TmplAnswerCheck, map[string]any{
+	user, err := renderTemplate(s.cfg.Prompts.AnswerCheck, map[string]any{
 		"Plan":     state.Plan,
 		"Notebook": state.Notebook,
 	})
 	if err != nil {
 		return false, 0, err
 	}
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph AnswerCheck System Prompt:\n%s\n", graphAnswerCheckSystemPrompt)
-		fmt.Printf("[LACONIC DEBUG] Graph AnswerCheck User Prompt:\n%s\n", user)
-	}
-	resp, err := s.cfg.Planner.Generate(ctx, graphAnswerCheckSystemPrompt, user)
+	s.agent.debugf("Graph AnswerCheck System Prompt:\n%s", s.cfg.Prompts.AnswerCheckSystem)
+	s.agent.debugf("Graph AnswerCheck User Prompt:\n%s", user)
+	resp, err := s.agent.generate(ctx, stageAnswerCheck, s.cfg.Planner, s.cfg.Prompts.AnswerCheckSystem, user)
 	if err != nil {
 		return false, 0, err
 	}
 	raw := s.getResponseContent("Graph AnswerCheck", resp)
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Graph AnswerCheck Response:\n%s\n", raw)
+	s.agent.debugf("Graph AnswerCheck Response:\n%s", raw)
+	if strings.TrimSpace(raw) == "" {
+		return false, resp.Cost, ErrEmptyLLMResponse
 	}
 
 	var parsed answerCheckResponse
@@ -576,27 +974,31 @@ func (s *graphReaderStrategy) canAnswer(ctx context.Context, state *graph.AgentS
 //     token consumption since the model doesn't re-process research steps.
 //  3. Generation: produce the answer from the condensed knowledge and
 //     compact question, fitting within the output-token budget.
-func (s *graphReaderStrategy) finalize(ctx context.Context, state *graph.AgentState) (string, float64, error) {
+func (s *graphReaderStrategy) finalize(ctx context.Context, state *graph.AgentState) (answer string, salvaged bool, cost float64, err error) {
+	if s.agent.insufficientText != "" && isStrictGrounding(s.agent.groundingMode) && len(state.Notebook.Clues) == 0 {
+		return s.agent.insufficientText, false, 0, nil
+	}
 	totalCost := 0.0
 
 	// Phase 1: Build a compact knowledge block from notebook facts.
 	knowledgeBlock, cost, err := s.buildKnowledge(ctx, state.Notebook.Clues)
 	totalCost += cost
 	if err != nil {
-		return "", totalCost, err
+		return "", false, totalCost, err
 	}
 
 	// Phase 2: Build a compact question for the finalizer.
 	compactQuestion := s.buildFinalizerQuestion(state)
 
 	// Phase 3: Attempt finalization with full compact question.
-	result, reasoning, cost, err := s.attemptFinalize(ctx, graphFinalizerSystemPrompt, compactQuestion, knowledgeBlock)
+	systemPrompt := s.finalizerSystemPrompt()
+	result, reasoning, cost, err := s.attemptFinalize(ctx, stageFinalizer, systemPrompt, compactQuestion, knowledgeBlock)
 	totalCost += cost
 	if err != nil {
-		return "", totalCost, err
+		return "", false, totalCost, err
 	}
 	if strings.TrimSpace(result) != "" {
-		return result, totalCost, nil
+		return result, false, totalCost, nil
 	}
 
 	// Phase 4: Retry with progressively simpler prompts.
@@ -613,10 +1015,8 @@ func (s *graphReaderStrategy) finalize(ctx context.Context, state *graph.AgentSt
 	}
 
 	for attempt := 1; attempt <= maxFinalizerRetries; attempt++ {
-		if s.agent.debug {
-			fmt.Printf("[LACONIC DEBUG] Finalizer returned empty, retry %d/%d (reasoning=%d chars)\n",
-				attempt, maxFinalizerRetries, len(reasoning))
-		}
+		s.agent.debugf("Finalizer returned empty, retry %d/%d (reasoning=%d chars)",
+			attempt, maxFinalizerRetries, len(reasoning))
 
 		// Build context for retry. If we have reasoning from the previous
 		// attempt, use it as pre-digested analysis to reduce thinking load.
@@ -625,8 +1025,8 @@ func (s *graphReaderStrategy) finalize(ctx context.Context, state *graph.AgentSt
 			// The model already analyzed the facts; use its reasoning as
 			// the knowledge input. Truncate to fit token budget.
 			truncReasoning := reasoning
-			if len(truncReasoning) > maxRetryKnowledgeLen {
-				truncReasoning = truncReasoning[:maxRetryKnowledgeLen]
+			if s.agent.countTokens(truncReasoning) > maxRetryKnowledgeTokens {
+				truncReasoning = s.agent.truncateTokens(truncReasoning, maxRetryKnowledgeTokens)
 				if idx := strings.LastIndex(truncReasoning, ". "); idx > 0 {
 					truncReasoning = truncReasoning[:idx+1]
 				}
@@ -634,41 +1034,82 @@ func (s *graphReaderStrategy) finalize(ctx context.Context, state *graph.AgentSt
 			retryKnowledge = truncReasoning
 		} else {
 			// No reasoning available; truncate raw knowledge further.
-			truncLimit := maxRetryKnowledgeLen / attempt
-			if len(retryKnowledge) > truncLimit {
-				retryKnowledge = retryKnowledge[:truncLimit]
+			truncLimit := maxRetryKnowledgeTokens / attempt
+			if s.agent.countTokens(retryKnowledge) > truncLimit {
+				retryKnowledge = s.agent.truncateTokens(retryKnowledge, truncLimit)
 				if idx := strings.LastIndex(retryKnowledge, ". "); idx > 0 {
 					retryKnowledge = retryKnowledge[:idx+1]
 				}
 			}
 		}
 
-		result, reasoning, cost, err = s.attemptFinalize(ctx, graphFinalizerRetrySystemPrompt, goal, retryKnowledge)
+		result, reasoning, cost, err = s.attemptFinalize(ctx, stageFinalizer, graphFinalizerRetrySystemPrompt, goal, retryKnowledge)
 		totalCost += cost
 		if err != nil {
-			return "", totalCost, err
+			return "", false, totalCost, err
 		}
 		if strings.TrimSpace(result) != "" {
-			return result, totalCost, nil
+			return result, false, totalCost, nil
 		}
 	}
 
 	// Phase 5: All retries exhausted. Return the condensed knowledge itself
 	// as a fallback so the caller gets *something*.
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Finalizer retries exhausted, returning condensed knowledge as fallback\n")
-	}
+	s.agent.debugf("Finalizer retries exhausted, returning condensed knowledge as fallback")
 	if strings.TrimSpace(knowledgeBlock) != "" {
-		return knowledgeBlock, totalCost, nil
+		return knowledgeBlock, true, totalCost, nil
+	}
+	return "", false, totalCost, fmt.Errorf("finalizer produced no output after %d retries: %w", maxFinalizerRetries+1, ErrEmptyLLMResponse)
+}
+
+// finalizerSystemPrompt returns the finalizer prompt: the configured one,
+// or the default for the grounding mode.
+func (s *graphReaderStrategy) finalizerSystemPrompt() string {
+	if s.cfg.Prompts.FinalizerSystem != "" {
+		return s.cfg.Prompts.FinalizerSystem
+	}
+	switch s.agent.groundingMode {
+	case GroundingAugment:
+		return graphFinalizerAugmentSystemPrompt
+	case GroundingOff:
+		return graphFinalizerOffSystemPrompt
+	default:
+		return graphFinalizerSystemPrompt
 	}
-	return "", totalCost, fmt.Errorf("finalizer produced no output after %d retries", maxFinalizerRetries+1)
+}
+
+// emitProgress writes a provisional answer from the facts collected so far
+// when step falls on the WithProgressiveAnswers interval. To stay cheap it
+// makes a single finalizer call over at most maxDirectFacts facts, with no
+// condensation or retries. It returns the cost incurred.
+func (s *graphReaderStrategy) emitProgress(ctx context.Context, step int, state *graph.AgentState) float64 {
+	if !s.agent.progressDue(step) || len(state.Notebook.Clues) == 0 {
+		return 0
+	}
+	facts := deduplicateFactTexts(state.Notebook.Clues, s.cfg.DedupThreshold)
+	if len(facts) > maxDirectFacts {
+		facts = facts[:maxDirectFacts]
+	}
+	var b strings.Builder
+	for _, f := range facts {
+		b.WriteString("- ")
+		b.WriteString(f)
+		b.WriteString("\n")
+	}
+	answer, _, cost, err := s.attemptFinalize(ctx, stageProgressive, s.finalizerSystemPrompt(), s.buildFinalizerQuestion(state), b.String())
+	if err != nil || strings.TrimSpace(answer) == "" {
+		s.agent.debugf("Provisional answer failed at step %d: %v", step, err)
+		return cost
+	}
+	s.agent.progressHandler(ProvisionalAnswer{Iteration: step, Answer: answer, Cost: cost})
+	return cost
 }
 
 // attemptFinalize makes a single finalizer LLM call and returns the
 // answer with think blocks stripped, plus any reasoning content. It
 // returns an empty answer string (not an error) when the model produced
 // only thinking/reasoning content, allowing the caller to retry.
-func (s *graphReaderStrategy) attemptFinalize(ctx context.Context, systemPrompt, question, knowledge string) (answer string, reasoning string, cost float64, err error) {
+func (s *graphReaderStrategy) attemptFinalize(ctx context.Context, stage, systemPrompt, question, knowledge string) (answer string, reasoning string, cost float64, err error) {
 	var b bytes.Buffer
 	b.WriteString("Question:\n")
 	b.WriteString(question)
@@ -678,25 +1119,28 @@ func (s *graphReaderStrategy) attemptFinalize(ctx context.Context, systemPrompt,
 	} else {
 		b.WriteString(knowledge)
 	}
-	b.WriteString("\nAnswer using only the knowledge above.")
+	if isStrictGrounding(s.agent.groundingMode) {
+		b.WriteString("\nAnswer using only the knowledge above.")
+	} else {
+		b.WriteString("\nAnswer using the knowledge above and what you already know.")
+	}
+	b.WriteString(s.agent.answerFormatInstruction(ctx, stage))
 
 	user := b.String()
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Finalizer attempt (%d chars) system: %s\n", len(user), systemPrompt)
-		fmt.Printf("[LACONIC DEBUG] Finalizer user prompt:\n%s\n", user)
-	}
-	resp, err := s.cfg.Finalizer.Generate(ctx, systemPrompt, user)
+	s.agent.debugf("Finalizer attempt (%d chars) system: %s", len(user), systemPrompt)
+	s.agent.debugf("Finalizer user prompt:\n%s", user)
+	resp, err := s.agent.generate(ctx, stage, s.cfg.Finalizer, systemPrompt, user)
 	if err != nil {
 		return "", "", 0, err
 	}
 	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Finalizer raw text (%d chars):\n%s\n", len(resp.Text), resp.Text)
+		s.agent.debugf("Finalizer raw text (%d chars):\n%s", len(resp.Text), resp.Text)
 		if resp.Reasoning != "" {
 			r := resp.Reasoning
 			if len(r) > 2000 {
 				r = r[:2000] + "... [truncated]"
 			}
-			fmt.Printf("[LACONIC DEBUG] Finalizer reasoning (%d chars):\n%s\n", len(resp.Reasoning), r)
+			s.agent.debugf("Finalizer reasoning (%d chars):\n%s", len(resp.Reasoning), r)
 		}
 	}
 	answer = s.stripThinking("Finalizer", resp.Text)
@@ -743,17 +1187,27 @@ func (s *graphReaderStrategy) buildFinalizerQuestion(state *graph.AgentState) st
 // buildKnowledge converts raw notebook clues into a compact knowledge block
 // suitable for the finalizer. For small fact sets, facts are listed directly
 // (without URLs). For larger sets, facts are compressed in batches through
-// LLM condensation calls to stay within context/output token budgets.
+// LLM condensation calls to stay within context/output token budgets. With
+// PreserveSources, facts carry [n] markers that the condenser is asked to
+// keep, and the numbered source URLs are appended to the block.
 func (s *graphReaderStrategy) buildKnowledge(ctx context.Context, clues []graph.AtomicFact) (string, float64, error) {
 	if len(clues) == 0 {
 		return "", 0, nil
 	}
 
-	// Strip URLs and deduplicate.
-	facts := deduplicateFactTexts(clues)
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Finalizer: %d clues deduplicated to %d unique facts\n", len(clues), len(facts))
+	// Strip URLs (or replace them with markers) and deduplicate.
+	var facts, sources []string
+	condenserPrompt := graphCondenserSystemPrompt
+	if s.cfg.PreserveSources {
+		facts, sources = sourcedFactTexts(clues, s.cfg.MaxCitations, s.cfg.DedupThreshold)
+		condenserPrompt = graphCondenserSourcesSystemPrompt
+	} else {
+		facts = deduplicateFactTexts(clues, s.cfg.DedupThreshold)
 	}
+	if s.cfg.Prompts.CondenserSystem != "" {
+		condenserPrompt = s.cfg.Prompts.CondenserSystem
+	}
+	s.agent.debugf("Finalizer: %d clues deduplicated to %d unique facts", len(clues), len(facts))
 
 	// If facts are few enough, list them directly.
 	if len(facts) <= maxDirectFacts {
@@ -763,16 +1217,22 @@ func (s *graphReaderStrategy) buildKnowledge(ctx context.Context, clues []graph.
 			b.WriteString(f)
 			b.WriteString("\n")
 		}
-		return b.String(), 0, nil
+		return b.String() + sourceList(sources), 0, nil
 	}
 
 	// Condense in batches.
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Condensing %d facts in batches of %d\n", len(facts), factCondenseBatch)
-	}
+	s.agent.debugf("Condensing %d facts in batches of %d", len(facts), factCondenseBatch)
+	// Batches are condensed concurrently; each writes its own slot so the
+	// paragraphs keep the order of the facts.
+	numBatches := (len(facts) + factCondenseBatch - 1) / factCondenseBatch
+	paragraphs := make([]string, numBatches)
+	errs := make([]error, numBatches)
 	totalCost := 0.0
-	var condensed []string
-	for i := 0; i < len(facts); i += factCondenseBatch {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.cfg.CondenseConcurrency)
+	for n := 0; n < numBatches; n++ {
+		i := n * factCondenseBatch
 		end := i + factCondenseBatch
 		if end > len(facts) {
 			end = len(facts)
@@ -786,44 +1246,176 @@ func (s *graphReaderStrategy) buildKnowledge(ctx context.Context, clues []graph.
 			b.WriteString("\n")
 		}
 
-		if s.agent.debug {
-			fmt.Printf("[LACONIC DEBUG] Condensing batch %d-%d of %d\n", i+1, end, len(facts))
-		}
-		resp, err := s.cfg.Finalizer.Generate(ctx, graphCondenserSystemPrompt, b.String())
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n, i, end int, user string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.agent.debugf("Condensing batch %d-%d of %d", i+1, end, len(facts))
+			resp, err := s.agent.generate(ctx, stageCondense, s.cfg.Finalizer, condenserPrompt, user)
+			if err != nil {
+				errs[n] = fmt.Errorf("fact condensation batch %d-%d: %w", i+1, end, err)
+				return
+			}
+			mu.Lock()
+			totalCost += resp.Cost
+			mu.Unlock()
+			paragraphs[n] = strings.TrimSpace(s.getResponseContent("Condense", resp))
+		}(n, i, end, b.String())
+	}
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
-			return "", totalCost, fmt.Errorf("fact condensation batch %d-%d: %w", i+1, end, err)
+			return "", totalCost, err
 		}
-		totalCost += resp.Cost
-		text := strings.TrimSpace(s.getResponseContent("Condense", resp))
+	}
+	var condensed []string
+	for _, text := range paragraphs {
 		if text != "" {
 			condensed = append(condensed, text)
 		}
 	}
 
 	result := strings.Join(condensed, "\n\n")
-	if s.agent.debug {
-		fmt.Printf("[LACONIC DEBUG] Condensed %d facts into %d chars across %d paragraphs\n", len(facts), len(result), len(condensed))
+	s.agent.debugf("Condensed %d facts into %d chars across %d paragraphs", len(facts), len(result), len(condensed))
+	if len(sources) > 0 {
+		result += "\n\n" + sourceList(sources)
 	}
 	return result, totalCost, nil
 }
 
+// sourcedFactTexts deduplicates facts like deduplicateFactTexts but replaces
+// each source URL with an [n] marker. A duplicate from a new source adds its
+// marker to the fact already kept. It returns the marked facts and the
+// source URLs, where sources[n-1] is the URL for marker [n]. When maxSources
+// is positive, only that many of the most-referenced sources are kept (see
+// GraphReaderConfig.MaxCitations).
+func sourcedFactTexts(clues []graph.AtomicFact, maxSources int, threshold float64) (facts, sources []string) {
+	var texts []string
+	var markers [][]int
+	sourceIndex := make(map[string]int)
+	for _, c := range clues {
+		text := strings.TrimSpace(c.Content)
+		if text == "" {
+			continue
+		}
+		idx := -1
+		for i, existing := range texts {
+			if sameFact(text, existing, threshold) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			texts = append(texts, text)
+			markers = append(markers, nil)
+			idx = len(texts) - 1
+		}
+		url := strings.TrimSpace(c.SourceURL)
+		if url == "" {
+			continue
+		}
+		n, ok := sourceIndex[url]
+		if !ok {
+			sources = append(sources, url)
+			n = len(sources)
+			sourceIndex[url] = n
+		}
+		if !containsInt(markers[idx], n) {
+			markers[idx] = append(markers[idx], n)
+		}
+	}
+	if maxSources > 0 && len(sources) > maxSources {
+		sources, markers = capSources(sources, markers, maxSources)
+	}
+
+	facts = make([]string, len(texts))
+	for i, text := range texts {
+		var b strings.Builder
+		b.WriteString(text)
+		for _, n := range markers[i] {
+			fmt.Fprintf(&b, " [%d]", n)
+		}
+		facts[i] = b.String()
+	}
+	return facts, sources
+}
+
+// capSources keeps the max sources cited by the most facts, breaking ties by
+// first appearance, and renumbers the markers. Markers for dropped sources
+// are removed; their facts stay.
+func capSources(sources []string, markers [][]int, max int) ([]string, [][]int) {
+	refs := make([]int, len(sources)+1)
+	for _, ms := range markers {
+		for _, n := range ms {
+			refs[n]++
+		}
+	}
+	ranked := make([]int, len(sources))
+	for i := range ranked {
+		ranked[i] = i + 1
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return refs[ranked[i]] > refs[ranked[j]] })
+	keep := make([]bool, len(sources)+1)
+	for _, n := range ranked[:max] {
+		keep[n] = true
+	}
+
+	// Renumber the kept sources in their original order.
+	renumber := make([]int, len(sources)+1)
+	var kept []string
+	for n := 1; n <= len(sources); n++ {
+		if keep[n] {
+			kept = append(kept, sources[n-1])
+			renumber[n] = len(kept)
+		}
+	}
+	out := make([][]int, len(markers))
+	for i, ms := range markers {
+		for _, n := range ms {
+			if renumber[n] > 0 {
+				out[i] = append(out[i], renumber[n])
+			}
+		}
+	}
+	return kept, out
+}
+
+// sourceList formats numbered source URLs for the end of a knowledge block.
+func sourceList(sources []string) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Sources:\n")
+	for i, url := range sources {
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, url)
+	}
+	return b.String()
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
 // deduplicateFactTexts strips source URLs and deduplicates fact content,
-// returning clean text strings. Uses case-insensitive comparison and
-// substring containment to catch near-duplicates.
-func deduplicateFactTexts(clues []graph.AtomicFact) []string {
+// returning clean text strings. Uses the same comparison as isDuplicateFact.
+func deduplicateFactTexts(clues []graph.AtomicFact, threshold float64) []string {
 	var result []string
 	for _, c := range clues {
 		text := strings.TrimSpace(c.Content)
 		if text == "" {
 			continue
 		}
-		lower := strings.ToLower(text)
 		dup := false
 		for _, existing := range result {
-			existingLower := strings.ToLower(existing)
-			if lower == existingLower ||
-				strings.Contains(existingLower, lower) ||
-				strings.Contains(lower, existingLower) {
+			if sameFact(text, existing, threshold) {
 				dup = true
 				break
 			}
@@ -835,41 +1427,165 @@ func deduplicateFactTexts(clues []graph.AtomicFact) []string {
 	return result
 }
 
-func (s *graphReaderStrategy) addFacts(state *graph.AgentState, facts []graph.AtomicFact) {
+// addFacts adds facts found while exploring the node whose first fact is at
+// nodeStart in the notebook.
+func (s *graphReaderStrategy) addFacts(state *graph.AgentState, nodeStart int, facts []graph.AtomicFact) {
+	s.addFactsWithIDs(state, nodeStart, facts, false)
+}
+
+// addQuestionFacts adds premises stated in the question, keeping their
+// question-N IDs so Result.Knowledge tells them apart from researched facts.
+func (s *graphReaderStrategy) addQuestionFacts(state *graph.AgentState, facts []graph.AtomicFact) {
+	s.addFactsWithIDs(state, len(state.Notebook.Clues), facts, true)
+}
+
+// addFactsWithIDs adds facts that pass dedup and the confidence floor. Each
+// is given its content ID unless keepIDs is set and it already has one.
+func (s *graphReaderStrategy) addFactsWithIDs(state *graph.AgentState, nodeStart int, facts []graph.AtomicFact, keepIDs bool) {
 	for _, fact := range facts {
 		content := strings.TrimSpace(fact.Content)
 		if content == "" {
 			continue
 		}
-		// Deduplicate: exact match or one contains the other (case-insensitive)
-		lowerContent := strings.ToLower(content)
-		dup := false
-		for _, existing := range state.Notebook.Clues {
-			lowerExisting := strings.ToLower(strings.TrimSpace(existing.Content))
-			if lowerContent == lowerExisting ||
-				strings.Contains(lowerExisting, lowerContent) ||
-				strings.Contains(lowerContent, lowerExisting) {
-				dup = true
-				break
-			}
+		if s.isDuplicate(state, nodeStart, content) {
+			s.agent.debugf("Skipping duplicate fact: %.80s", content)
+			continue
 		}
-		if dup {
-			if s.agent.debug {
-				fmt.Printf("[LACONIC DEBUG] Skipping duplicate fact: %.80s\n", content)
-			}
+		if fact.Confidence < s.cfg.MinFactConfidence {
+			s.agent.debugf("Skipping low-confidence fact (%.2f): %.80s", fact.Confidence, content)
 			continue
 		}
 		if fact.Timestamp == 0 {
 			fact.Timestamp = time.Now().Unix()
 		}
-		if strings.TrimSpace(fact.ID) == "" {
-			fact.ID = fmt.Sprintf("fact-%d", len(state.Notebook.Clues)+1)
+		if !keepIDs || fact.ID == "" {
+			fact.ID = graph.FactID(content)
+		}
+		fact.Content = content
+		state.Notebook.Clues = append(state.Notebook.Clues, fact)
+	}
+}
+
+// addPriorFacts adds facts from an earlier session to the notebook as they
+// are, apart from giving them content IDs and dropping any whose ID is
+// already present, so merged sessions never repeat a fact.
+func addPriorFacts(state *graph.AgentState, facts []graph.AtomicFact) {
+	for _, fact := range facts {
+		content := strings.TrimSpace(fact.Content)
+		if content == "" || state.Notebook.HasContent(content) {
+			continue
 		}
+		fact.ID = graph.FactID(content)
 		fact.Content = content
 		state.Notebook.Clues = append(state.Notebook.Clues, fact)
 	}
 }
 
+// isDuplicate reports whether content duplicates a fact within the
+// configured DedupScope; per-node dedup compares only the facts from
+// nodeStart on. Global dedup checks the notebook's exact-match index before
+// the containment scan.
+func (s *graphReaderStrategy) isDuplicate(state *graph.AgentState, nodeStart int, content string) bool {
+	switch s.cfg.DedupScope {
+	case DedupOff:
+		return false
+	case DedupPerNode:
+		return isDuplicateClue(&state.Notebook, nodeStart, content, s.cfg.DedupThreshold)
+	default:
+		return state.Notebook.HasContent(content) || isDuplicateClue(&state.Notebook, 0, content, s.cfg.DedupThreshold)
+	}
+}
+
+// isDuplicateFact reports whether content matches an existing fact exactly,
+// ignoring case, or is at least threshold similar to one (see
+// FactSimilarity).
+func isDuplicateFact(clues []graph.AtomicFact, content string, threshold float64) bool {
+	return isDuplicateClue(&graph.Notebook{Clues: clues}, 0, content, threshold)
+}
+
+// isDuplicateClue is isDuplicateFact over the notebook's clues from index
+// from on. Each clue's words come from the notebook's cache, so only content
+// is split into words.
+func isDuplicateClue(nb *graph.Notebook, from int, content string, threshold float64) bool {
+	tokens := graph.FactTokens(content)
+	for i := from; i < len(nb.Clues); i++ {
+		if strings.EqualFold(content, strings.TrimSpace(nb.Clues[i].Content)) || tokenSimilarity(tokens, nb.ClueTokens(i)) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFact reports whether two facts are equal, ignoring case, or at least
+// threshold similar.
+func sameFact(a, b string, threshold float64) bool {
+	return strings.EqualFold(a, b) || FactSimilarity(a, b) >= threshold
+}
+
+// pageContent returns the full text of url: the page text the search
+// provider supplied, or else the fetched page. It returns "" when the URL is
+// skipped (ads, trackers, non-HTML with WithSkipNonHTML), cannot be fetched,
+// or yields fewer than MinPageChars characters, in which case tooShort is
+// true.
+func (s *graphReaderStrategy) pageContent(ctx context.Context, results []SearchResult, url string) (content string, tooShort bool) {
+	// Providers that return full page text save us a fetch.
+	content = resultContent(results, url)
+	if content == "" {
+		if s.agent.fetcher == nil {
+			return "", false
+		}
+		if isAdOrTrackerURL(url) {
+			s.agent.debugf("Skipping ad/tracker URL: %s", url)
+			return "", false
+		}
+		if kind := InferURLKind(url); kind != URLKindHTML && s.agent.skipNonHTML {
+			s.agent.debugf("Skipping %s URL: %s", kind, url)
+			return "", false
+		}
+		stepCtx, cancel := s.stepContext(ctx)
+		stepCtx, end := s.agent.startSpan(stepCtx, spanFetch)
+		var err error
+		start := time.Now()
+		content, err = s.agent.fetcher.Fetch(stepCtx, url)
+		addRunTiming(ctx, timingFetch, time.Since(start))
+		end(withError(map[string]any{"laconic.url": url, "laconic.chars": len(content)}, err))
+		cancel()
+		if err != nil {
+			// Includes pages robots.txt disallows (fetch.ErrDisallowedByRobots).
+			s.agent.debugf("Skipping unfetchable URL %s: %v", url, err)
+			return "", false
+		}
+	}
+	// Skip trivially short pages (titles only, JS-rendered, error pages).
+	if n := len(strings.TrimSpace(content)); n < s.cfg.MinPageChars {
+		s.agent.debugf("Skipping too-short page content (%d chars): %s", n, url)
+		return "", true
+	}
+	return content, false
+}
+
+// snippetFacts returns the search snippet for url as a fact, used when the
+// page itself was too short to read.
+func snippetFacts(results []SearchResult, url string) []graph.AtomicFact {
+	for _, r := range results {
+		if r.URL == url && strings.TrimSpace(r.Snippet) != "" {
+			return []graph.AtomicFact{graph.NewAtomicFact(strings.TrimSpace(r.Snippet), url)}
+		}
+	}
+	return nil
+}
+
+// resultContent returns the full page text a search result carries for url,
+// or an empty string if the provider did not supply any.
+func resultContent(results []SearchResult, url string) string {
+	for _, r := range results {
+		if r.URL == url {
+			return strings.TrimSpace(r.Content)
+		}
+	}
+	return ""
+}
+
 func (s *graphReaderStrategy) isQueued(state *graph.AgentState, name string) bool {
 	for _, node := range state.Queue {
 		if node.Name == name {
@@ -0,0 +1,1133 @@
+package laconic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+// llmFunc adapts a function to LLMProvider.
+type llmFunc func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error)
+
+func (f llmFunc) Generate(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+	return f(ctx, systemPrompt, userPrompt)
+}
+
+// graphScript returns canned responses for each graph-reader stage.
+type graphScript struct {
+	plan      string
+	initial   string
+	extract   func(user string) string
+	neighbors string
+	canAnswer string
+	final     string
+}
+
+func (g graphScript) llm() llmFunc {
+	return func(_ context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch {
+		case systemPrompt == graphPlannerSystemPrompt && strings.Contains(userPrompt, "User Question:"):
+			return LLMResponse{Text: g.plan}, nil
+		case systemPrompt == graphPlannerSystemPrompt:
+			return LLMResponse{Text: g.initial}, nil
+		case systemPrompt == graphExtractorSystemPrompt:
+			return LLMResponse{Text: g.extract(userPrompt)}, nil
+		case systemPrompt == graphNeighborSystemPrompt:
+			return LLMResponse{Text: g.neighbors}, nil
+		case systemPrompt == graphAnswerCheckSystemPrompt:
+			return LLMResponse{Text: g.canAnswer}, nil
+		default:
+			return LLMResponse{Text: g.final}, nil
+		}
+	}
+}
+
+func defaultGraphScript() graphScript {
+	return graphScript{
+		plan:    `{"research_goal": "Find the capital of France", "strategy": ["search"], "key_elements": ["France"]}`,
+		initial: `["capital of France", "France government seat"]`,
+		extract: func(string) string {
+			return `{"new_facts": [{"content": "Paris is the capital of France", "source_url": "https://example.com"}], "read_more_urls": []}`
+		},
+		neighbors: `[]`,
+		canAnswer: `{"can_answer": false}`,
+		final:     "Paris",
+	}
+}
+
+type searchFunc func(ctx context.Context, query string) ([]SearchResult, error)
+
+func (f searchFunc) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	return f(ctx, query)
+}
+
+func TestGraphReaderStepTimeoutSkipsHungSearch(t *testing.T) {
+	llm := defaultGraphScript().llm()
+	searcher := searchFunc(func(ctx context.Context, query string) ([]SearchResult, error) {
+		if query == "capital of France" {
+			<-ctx.Done() // hangs until the step timeout fires
+			return nil, ctx.Err()
+		}
+		return []SearchResult{{Title: "Paris", URL: "https://example.com", Snippet: "Paris is the capital"}}, nil
+	})
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{StepTimeout: 20 * time.Millisecond}),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Paris" {
+		t.Fatalf("unexpected answer: %q", res.Answer)
+	}
+	if !strings.Contains(res.Knowledge, "Paris is the capital of France") {
+		t.Fatalf("expected facts from the second node, got %q", res.Knowledge)
+	}
+}
+
+func TestSearchErrorHandler(t *testing.T) {
+	llm := defaultGraphScript().llm()
+	searcher := searchFunc(func(ctx context.Context, query string) ([]SearchResult, error) {
+		if query == "capital of France" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []SearchResult{{Title: "Paris", URL: "https://example.com", Snippet: "Paris is the capital"}}, nil
+	})
+	var failed []string
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{StepTimeout: 20 * time.Millisecond}),
+		WithSearchErrorHandler(func(query string, err error) {
+			failed = append(failed, fmt.Sprintf("%s: %v", query, err))
+		}),
+	)
+	if _, err := agent.Answer(context.Background(), "What is the capital of France?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failed) != 1 || failed[0] != "capital of France: context deadline exceeded" {
+		t.Fatalf("handler saw %q", failed)
+	}
+}
+
+func TestGraphReaderInsufficientAnswerText(t *testing.T) {
+	script := defaultGraphScript()
+	script.extract = func(string) string { return `{"new_facts": [], "read_more_urls": []}` }
+	script.final = "should not be asked"
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(script.llm()),
+		WithSynthesizerModel(script.llm()),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithInsufficientAnswerText("Nothing found."),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Nothing found." {
+		t.Fatalf("answer = %q, want the configured fallback", res.Answer)
+	}
+}
+
+func TestGraphReaderCostBreakdown(t *testing.T) {
+	llm := defaultGraphScript().llm()
+	costly := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		resp, err := llm(ctx, systemPrompt, userPrompt)
+		resp.Cost = 0.01
+		return resp, err
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(costly),
+		WithSynthesizerModel(costly),
+		WithSearchProvider(searcher),
+		WithSearchCost(0.002),
+		WithStrategyName("graph-reader"),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sum float64
+	for _, phase := range []string{"planner", "extractor", "neighbor", "finalizer", "search"} {
+		if res.CostBreakdown[phase] == 0 {
+			t.Errorf("no %s cost in %v", phase, res.CostBreakdown)
+		}
+	}
+	for _, cost := range res.CostBreakdown {
+		sum += cost
+	}
+	if sum < res.Cost-1e-9 || sum > res.Cost+1e-9 {
+		t.Fatalf("breakdown %v sums to %v, want Cost %v", res.CostBreakdown, sum, res.Cost)
+	}
+}
+
+func TestGraphReaderMinFactConfidence(t *testing.T) {
+	script := defaultGraphScript()
+	script.extract = func(string) string {
+		return `{"new_facts": [
+			{"content": "Paris is the capital of France", "confidence": 0.9},
+			{"content": "Lyon might be the capital", "confidence": 0.2},
+			{"content": "France uses the euro"}
+		], "read_more_urls": []}`
+	}
+	llm := script.llm()
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{MinFactConfidence: 0.5}),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(res.Knowledge, "Lyon") {
+		t.Fatalf("low-confidence fact should be dropped: %s", res.Knowledge)
+	}
+	// A fact without a confidence defaults to 1.0 and is kept.
+	if !strings.Contains(res.Knowledge, "Paris") || !strings.Contains(res.Knowledge, "euro") {
+		t.Fatalf("expected confident facts to be kept: %s", res.Knowledge)
+	}
+}
+
+func TestGraphReaderMaxLLMCalls(t *testing.T) {
+	var calls int
+	script := defaultGraphScript()
+	inner := script.llm()
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		calls++
+		return inner(ctx, systemPrompt, userPrompt)
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithMaxLLMCalls(3),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if !errors.Is(err, ErrCallBudgetExceeded) {
+		t.Fatalf("expected ErrCallBudgetExceeded, got %v", err)
+	}
+	if res.Answer != "Paris" {
+		t.Fatalf("unexpected answer: %q", res.Answer)
+	}
+	// plan, initial nodes, one extraction, then the finalizer.
+	if calls != 4 {
+		t.Fatalf("expected 4 LLM calls, got %d", calls)
+	}
+}
+
+func TestGraphReaderMaxNeighborsPerStep(t *testing.T) {
+	script := defaultGraphScript()
+	script.initial = `["capital of France"]`
+	inner := script.llm()
+	neighborCalls := 0
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == graphNeighborSystemPrompt {
+			// Each call suggests eight fresh queries: "<call>-1" ... "<call>-8".
+			neighborCalls++
+			var names []string
+			for i := 1; i <= 8; i++ {
+				names = append(names, fmt.Sprintf("%q", fmt.Sprintf("%d-%d", neighborCalls, i)))
+			}
+			return LLMResponse{Text: "[" + strings.Join(names, ",") + "]"}, nil
+		}
+		return inner(ctx, systemPrompt, userPrompt)
+	})
+	var queries []string
+	searcher := searchFunc(func(_ context.Context, query string) ([]SearchResult, error) {
+		queries = append(queries, query)
+		return []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}, nil
+	})
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{MaxSteps: 4, MaxNeighborsPerStep: 2}),
+	)
+
+	if _, err := agent.Answer(context.Background(), "What is the capital of France?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Only two suggestions per step are queued, so the fourth step already
+	// reaches a query suggested by the second step.
+	want := []string{"capital of France", "1-1", "1-2", "2-1"}
+	if strings.Join(queries, ",") != strings.Join(want, ",") {
+		t.Fatalf("queries = %v, want %v", queries, want)
+	}
+}
+
+func TestGraphReaderStopReason(t *testing.T) {
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+	run := func(script graphScript, cfg GraphReaderConfig) Result {
+		llm := script.llm()
+		agent := New(
+			WithPlannerModel(llm),
+			WithSynthesizerModel(llm),
+			WithSearchProvider(searcher),
+			WithStrategyName("graph-reader"),
+			WithGraphReaderConfig(cfg),
+		)
+		res, _ := agent.Answer(context.Background(), "What is the capital of France?")
+		return res
+	}
+
+	// Two initial queries and no neighbors: the queue empties first.
+	if res := run(defaultGraphScript(), GraphReaderConfig{}); res.StopReason != StopQueueExhausted {
+		t.Errorf("StopReason = %q, want %q", res.StopReason, StopQueueExhausted)
+	}
+	if res := run(defaultGraphScript(), GraphReaderConfig{MaxSteps: 1}); res.StopReason != StopMaxSteps {
+		t.Errorf("StopReason = %q, want %q", res.StopReason, StopMaxSteps)
+	}
+
+	script := defaultGraphScript()
+	n := 0
+	script.extract = func(string) string {
+		n++
+		return fmt.Sprintf(`{"new_facts": [{"content": "fact %d a"}, {"content": "fact %d b"}, {"content": "fact %d c"}]}`, n, n, n)
+	}
+	script.canAnswer = `{"can_answer": true}`
+	if res := run(script, GraphReaderConfig{}); res.StopReason != StopAnswerCheckPassed {
+		t.Errorf("StopReason = %q, want %q", res.StopReason, StopAnswerCheckPassed)
+	}
+
+	script = defaultGraphScript()
+	script.final = ""
+	if res := run(script, GraphReaderConfig{}); res.StopReason != StopSalvaged || !strings.Contains(res.Answer, "Paris") {
+		t.Errorf("StopReason = %q (answer %q), want %q", res.StopReason, res.Answer, StopSalvaged)
+	}
+}
+
+func TestGraphReaderQueries(t *testing.T) {
+	llm := defaultGraphScript().llm()
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+	)
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(res.Queries, "|"); got != "capital of France|France government seat" {
+		t.Fatalf("Queries = %q", got)
+	}
+	if got := strings.Join(res.Sources, "|"); got != "https://example.com" {
+		t.Fatalf("Sources = %q, want the facts' one source URL", got)
+	}
+}
+
+func TestFactDedupKeepsShortPrefixFacts(t *testing.T) {
+	clues := []graph.AtomicFact{{Content: "Apple"}}
+	if isDuplicateFact(clues, "Apple revenue $90B", defaultFactDedupThreshold) {
+		t.Fatal(`"Apple revenue $90B" was treated as a duplicate of "Apple"`)
+	}
+	if !isDuplicateFact([]graph.AtomicFact{{Content: "Apple revenue was $90B"}}, "apple revenue was $90B.", defaultFactDedupThreshold) {
+		t.Fatal("near-identical facts should still be duplicates")
+	}
+	got := deduplicateFactTexts(append(clues, graph.AtomicFact{Content: "Apple revenue $90B"}), defaultFactDedupThreshold)
+	if len(got) != 2 {
+		t.Fatalf("deduplicateFactTexts = %q, want both facts", got)
+	}
+}
+
+func TestNotebookIndexFollowsReplacedClues(t *testing.T) {
+	var nb graph.Notebook
+	nb.Clues = []graph.AtomicFact{{Content: "Paris is in France"}, {Content: "Rome is in Italy"}}
+	if !nb.HasContent("paris is in france") {
+		t.Fatal("missing clue before replacement")
+	}
+	nb.Clues = []graph.AtomicFact{{Content: "Berlin is in Germany"}, {Content: "Rome is in Italy"}}
+	if nb.HasContent("Paris is in France") || !nb.HasContent("Berlin is in Germany") {
+		t.Fatal("index kept the replaced clues")
+	}
+	nb.Clues = nb.Clues[:1]
+	if nb.HasContent("Rome is in Italy") {
+		t.Fatal("index kept a dropped clue")
+	}
+}
+
+func TestNotebookCachesClueTokens(t *testing.T) {
+	nb := graph.Notebook{Clues: []graph.AtomicFact{{Content: "Paris has 2.1 million residents."}}}
+	tokens := nb.ClueTokens(0)
+	if !tokens["2.1"] || !tokens["residents"] || len(tokens) != 5 {
+		t.Fatalf("ClueTokens = %v", tokens)
+	}
+	nb.Clues = append(nb.Clues, graph.AtomicFact{Content: "The Seine crosses Paris"})
+	if again := nb.ClueTokens(0); reflect.ValueOf(again).Pointer() != reflect.ValueOf(tokens).Pointer() {
+		t.Fatal("ClueTokens split the same clue again")
+	}
+	if !nb.ClueTokens(1)["seine"] {
+		t.Fatalf("ClueTokens(1) = %v", nb.ClueTokens(1))
+	}
+}
+
+func TestDedupScope(t *testing.T) {
+	add := func(scope DedupScope) []string {
+		strategy, err := newGraphReaderStrategy(New(WithPlannerModel(llmFunc(nil)), WithGraphReaderConfig(GraphReaderConfig{DedupScope: scope})))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		s := strategy.(*graphReaderStrategy)
+		state := graph.NewAgentState("Q")
+		s.addFacts(state, 0, []graph.AtomicFact{{Content: "Paris has 2.1 million residents"}})
+		s.addFacts(state, len(state.Notebook.Clues), []graph.AtomicFact{
+			{Content: "Paris has 2.1 million residents."},
+			{Content: "paris  has 2.1 million\tresidents"},
+			{Content: "The Seine crosses Paris"},
+			{Content: "THE SEINE CROSSES PARIS"},
+		})
+		var got []string
+		for _, c := range state.Notebook.Clues {
+			got = append(got, c.Content)
+		}
+		return got
+	}
+	// Global also drops the whitespace variant through the notebook index.
+	if got := add(""); len(got) != 2 || got[1] != "The Seine crosses Paris" {
+		t.Fatalf("global: %q", got)
+	}
+	// Per-node keeps a restatement of the first node's fact, but only one:
+	// the whitespace variant duplicates it within the second node.
+	if got := add(DedupPerNode); len(got) != 3 || got[1] != "Paris has 2.1 million residents." {
+		t.Fatalf("per-node: %q", got)
+	}
+	if got := add(DedupOff); len(got) != 5 {
+		t.Fatalf("off: %q", got)
+	}
+	if _, err := newGraphReaderStrategy(New(WithGraphReaderConfig(GraphReaderConfig{DedupScope: "local"}))); err == nil {
+		t.Fatal("expected an error for an unknown scope")
+	}
+}
+
+func TestGraphReaderFixedPlanSkipsPlanner(t *testing.T) {
+	script := defaultGraphScript()
+	base := script.llm()
+	plannerCalls := 0
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == graphPlannerSystemPrompt {
+			plannerCalls++
+		}
+		return base(ctx, systemPrompt, userPrompt)
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{
+			FixedPlan:   &graph.RationalPlan{ResearchGoal: "Find the capital"},
+			SeedQueries: []string{"France capital city", " "},
+		}),
+	)
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plannerCalls != 0 {
+		t.Fatalf("planner called %d times, want 0", plannerCalls)
+	}
+	if got := strings.Join(res.Queries, "|"); got != "France capital city" {
+		t.Fatalf("Queries = %q", got)
+	}
+	if _, err := agent.AnswerFromText(context.Background(), "What is the capital of France?", "Paris is the capital of France."); err != nil {
+		t.Fatalf("AnswerFromText: %v", err)
+	}
+	if plannerCalls != 0 {
+		t.Fatalf("AnswerFromText called the planner %d times, want 0", plannerCalls)
+	}
+
+	agent = New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{FixedPlan: &graph.RationalPlan{}}),
+	)
+	if _, err := agent.Answer(context.Background(), "Q"); err == nil || !strings.Contains(err.Error(), "research goal") {
+		t.Fatalf("expected research goal error, got %v", err)
+	}
+}
+
+func TestGraphReaderTokenizerChunksDocument(t *testing.T) {
+	script := defaultGraphScript()
+	chunks := 0
+	script.extract = func(string) string {
+		chunks++
+		return `{"new_facts": [{"content": "Revenue was $5B"}]}`
+	}
+	llm := script.llm()
+	words := func(s string) int { return len(strings.Fields(s)) }
+
+	// 5000 one-letter words is about 2500 tokens by the chars/4 estimate
+	// (two chunks) but 5000 tokens when counting words (three chunks).
+	doc := strings.Repeat("a ", 5000)
+	for _, c := range []struct {
+		opts []Option
+		want int
+	}{
+		{nil, 2},
+		{[]Option{WithTokenizer(words)}, 3},
+	} {
+		chunks = 0
+		opts := append([]Option{WithPlannerModel(llm), WithSynthesizerModel(llm), WithStrategyName("graph-reader")}, c.opts...)
+		if _, err := New(opts...).AnswerFromText(context.Background(), "What was revenue?", doc); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chunks != c.want {
+			t.Errorf("extractor calls = %d, want %d", chunks, c.want)
+		}
+	}
+}
+
+func TestSplitTokensScalesWithChunkSize(t *testing.T) {
+	seen := 0
+	words := func(s string) int {
+		seen += len(s)
+		return len(strings.Fields(s))
+	}
+	a := New(WithTokenizer(words))
+	doc := strings.Repeat("lorem ipsum ", 20000)
+	chunks := a.splitTokens(doc, 100)
+	if len(chunks) != 400 || strings.Join(chunks, "") != doc {
+		t.Fatalf("got %d chunks that rejoin to the document: %v", len(chunks), strings.Join(chunks, "") == doc)
+	}
+	for _, c := range chunks {
+		if n := words(c); n > 100 {
+			t.Fatalf("chunk has %d tokens, limit 100", n)
+		}
+	}
+	// Each chunk is found by tokenizing prefixes a few times its own size;
+	// rescanning the rest of the document would tokenize gigabytes.
+	if seen > 30*len(doc) {
+		t.Fatalf("tokenizer saw %d bytes for a %d-byte document", seen, len(doc))
+	}
+}
+
+func TestGraphReaderPreserveSourcesThroughCondensation(t *testing.T) {
+	script := defaultGraphScript()
+	n := 0
+	script.extract = func(string) string {
+		var facts []string
+		for i := 0; i < 25; i++ {
+			n++
+			facts = append(facts, fmt.Sprintf(`{"content": "Measurement %d recorded.", "source_url": "https://src.example/%d"}`, n, n%3))
+		}
+		return `{"new_facts": [` + strings.Join(facts, ",") + `]}`
+	}
+	base := script.llm()
+	var mu sync.Mutex
+	var condenseInput, finalInput string
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch systemPrompt {
+		case graphCondenserSourcesSystemPrompt:
+			// Batches are condensed concurrently, so collect all inputs.
+			mu.Lock()
+			condenseInput += userPrompt
+			mu.Unlock()
+			return LLMResponse{Text: "Condensed facts [1] [2]."}, nil
+		case graphFinalizerSystemPrompt:
+			finalInput = userPrompt
+		}
+		return base(ctx, systemPrompt, userPrompt)
+	})
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{PreserveSources: true}),
+	)
+	if _, err := agent.Answer(context.Background(), "Q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(condenseInput, "- Measurement 1 recorded. [1]\n") {
+		t.Fatalf("condenser input lacks source markers:\n%s", condenseInput)
+	}
+	if !strings.Contains(finalInput, "Sources:\n[1] https://src.example/1\n[2] https://src.example/2\n[3] https://src.example/0\n") {
+		t.Fatalf("finalizer input lacks source list:\n%s", finalInput)
+	}
+}
+
+func TestSourcedFactTextsMaxSources(t *testing.T) {
+	clues := []graph.AtomicFact{
+		{Content: "Fact alpha.", SourceURL: "https://a.example"},
+		{Content: "Fact beta.", SourceURL: "https://b.example"},
+		{Content: "Fact gamma.", SourceURL: "https://c.example"},
+		{Content: "Fact delta.", SourceURL: "https://c.example"},
+		{Content: "Fact alpha.", SourceURL: "https://b.example"},
+	}
+	facts, sources := sourcedFactTexts(clues, 0, defaultFactDedupThreshold)
+	if len(sources) != 3 || facts[0] != "Fact alpha. [1] [2]" {
+		t.Fatalf("uncapped: facts %q sources %q", facts, sources)
+	}
+
+	// b and c are each cited twice, a once: a is dropped and the rest
+	// renumbered in their original order.
+	facts, sources = sourcedFactTexts(clues, 2, defaultFactDedupThreshold)
+	wantFacts := []string{"Fact alpha. [1]", "Fact beta. [1]", "Fact gamma. [2]", "Fact delta. [2]"}
+	wantSources := []string{"https://b.example", "https://c.example"}
+	if !reflect.DeepEqual(facts, wantFacts) || !reflect.DeepEqual(sources, wantSources) {
+		t.Fatalf("capped: facts %q sources %q", facts, sources)
+	}
+
+	// Ties go to the source seen first.
+	facts, sources = sourcedFactTexts(clues[:3], 1, defaultFactDedupThreshold)
+	if !reflect.DeepEqual(sources, []string{"https://a.example"}) || facts[1] != "Fact beta." {
+		t.Fatalf("tie: facts %q sources %q", facts, sources)
+	}
+}
+
+func TestPartialFactsFromTruncatedJSON(t *testing.T) {
+	raw := "```json\n{\"new_facts\": [\n" +
+		`{"content": "Paris is the capital of France", "source_url": "https://a.example"},` + "\n" +
+		`{"content": "Paris has {2.1M} residents", "source_url": "https://b.example"},` + "\n" +
+		`{"content": "The Seine flows thro`
+	facts, ok := partialFacts(raw)
+	if !ok || len(facts) != 2 {
+		t.Fatalf("expected 2 recovered facts, got %v (ok=%v)", facts, ok)
+	}
+	if facts[1].Content != "Paris has {2.1M} residents" || facts[1].SourceURL != "https://b.example" {
+		t.Fatalf("unexpected fact: %+v", facts[1])
+	}
+	if _, ok := partialFacts(`{"new_facts": [{"content": "cut`); ok {
+		t.Fatal("no complete entry should report false")
+	}
+
+	// End to end: the node keeps the facts instead of failing.
+	script := defaultGraphScript()
+	script.extract = func(string) string { return raw }
+	var finalInput string
+	base := script.llm()
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == graphFinalizerSystemPrompt {
+			finalInput = userPrompt
+		}
+		return base(ctx, systemPrompt, userPrompt)
+	})
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithStrategyName("graph-reader"),
+	)
+	if _, err := agent.Answer(context.Background(), "Q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(finalInput, "Paris has {2.1M} residents") {
+		t.Fatalf("recovered facts should reach the finalizer:\n%s", finalInput)
+	}
+}
+
+func TestGraphReaderCustomAnswerCheck(t *testing.T) {
+	script := defaultGraphScript()
+	n := 0
+	script.extract = func(string) string {
+		n++
+		return fmt.Sprintf(`{"new_facts": [{"content": "France fact %d"}, {"content": "Other fact %d"}, {"content": "Third fact %d"}]}`, n, n, n)
+	}
+	script.neighbors = `["next query"]`
+	base := script.llm()
+	llmChecks := 0
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		if systemPrompt == graphAnswerCheckSystemPrompt {
+			llmChecks++
+		}
+		return base(ctx, systemPrompt, userPrompt)
+	})
+	var checked []int
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{AnswerCheck: func(state *graph.AgentState) bool {
+			checked = append(checked, len(state.Notebook.Clues))
+			return len(state.Notebook.Clues) >= 9
+		}}),
+	)
+	res, err := agent.Answer(context.Background(), "Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if llmChecks != 0 {
+		t.Fatalf("answer-check LLM called %d times", llmChecks)
+	}
+	if res.StopReason != StopAnswerCheckPassed {
+		t.Fatalf("StopReason = %q", res.StopReason)
+	}
+	// The check only runs once 5 facts exist.
+	if len(checked) != 2 || checked[0] != 6 || checked[1] != 9 {
+		t.Fatalf("checks saw %v facts", checked)
+	}
+}
+
+func TestGraphReaderCondensesConcurrentlyInOrder(t *testing.T) {
+	script := defaultGraphScript()
+	n := 0
+	script.extract = func(string) string {
+		var facts []string
+		for i := 0; i < 25; i++ {
+			n++
+			facts = append(facts, fmt.Sprintf(`{"content": "Measurement %d recorded."}`, n))
+		}
+		return `{"new_facts": [` + strings.Join(facts, ",") + `]}`
+	}
+	base := script.llm()
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	var finalInput string
+	// The first batch finishes only after another batch has, which needs
+	// them to run concurrently and would put its paragraph last if
+	// paragraphs were appended on completion.
+	otherDone := make(chan struct{})
+	var once sync.Once
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch systemPrompt {
+		case graphCondenserSystemPrompt:
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			var first int
+			fmt.Sscanf(userPrompt, "- Measurement %d", &first)
+			if first == 1 {
+				select {
+				case <-otherDone:
+				case <-time.After(10 * time.Second):
+					t.Error("the first batch was condensed alone")
+				}
+			} else {
+				once.Do(func() { close(otherDone) })
+			}
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return LLMResponse{Text: fmt.Sprintf("Paragraph from %d.", first), Cost: 0.5}, nil
+		case graphFinalizerSystemPrompt:
+			finalInput = userPrompt
+		}
+		return base(ctx, systemPrompt, userPrompt)
+	})
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{CondenseConcurrency: 3}),
+	)
+	if _, err := agent.Answer(context.Background(), "Q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxInFlight > 3 {
+		t.Fatalf("max concurrent condense calls = %d, want at most 3", maxInFlight)
+	}
+	last := -1
+	for i := 1; i < n; i += 25 {
+		p := strings.Index(finalInput, fmt.Sprintf("Paragraph from %d.", i))
+		if p < 0 || p < last {
+			t.Fatalf("paragraph %d missing or out of order in:\n%s", i, finalInput)
+		}
+		last = p
+	}
+}
+
+func TestDeepReadFetchesTopResult(t *testing.T) {
+	script := defaultGraphScript()
+	script.neighbors = `["should not be searched"]`
+	var extracted []string
+	script.extract = func(user string) string {
+		extracted = append(extracted, user)
+		return `{"new_facts": [{"content": "Paris is the capital of France"}]}`
+	}
+	llm := script.llm()
+	searcher := fakeSearch{results: []SearchResult{
+		{Title: "Brochure", URL: "https://example.com/guide.pdf", Snippet: "s"},
+		{Title: "Paris", URL: "https://example.com/paris", Snippet: "s"},
+		{Title: "Other", URL: "https://example.com/other", Snippet: "s"},
+	}}
+	var fetched []string
+	fetcher := fetchFunc(func(_ context.Context, url string) (string, error) {
+		fetched = append(fetched, url)
+		return "Paris is the capital and largest city of France. " + strings.Repeat("It sits on the Seine. ", 20), nil
+	})
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithFetchProvider(fetcher),
+		WithSkipNonHTML(true),
+		WithStrategyName("deep-read"),
+	)
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(res.Queries, "|"); got != "capital of France|France government seat" {
+		t.Fatalf("Queries = %q; neighbors should not be followed", got)
+	}
+	if got := strings.Join(fetched, " "); got != "https://example.com/paris https://example.com/paris" {
+		t.Fatalf("fetched %q, want the top HTML result once per step", got)
+	}
+	if len(extracted) != 2 || !strings.Contains(extracted[0], "largest city") {
+		t.Fatalf("extractor should read the fetched page each step, got %d calls", len(extracted))
+	}
+
+	agent = New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher), WithStrategyName("deep-read"))
+	if _, err := agent.Answer(context.Background(), "Q"); err == nil {
+		t.Fatal("expected an error without a fetch provider")
+	}
+}
+
+func TestGraphReaderMinPageCharsAndSnippetFallback(t *testing.T) {
+	page := strings.Repeat("Short page text. ", 8) // about 136 characters
+	run := func(cfg GraphReaderConfig) (Result, int) {
+		script := defaultGraphScript()
+		pageReads := 0
+		script.extract = func(user string) string {
+			if strings.Contains(user, "Short page text.") {
+				pageReads++
+				return `{"new_facts": [{"content": "The page was read"}]}`
+			}
+			return `{"new_facts": [], "read_more_urls": ["https://example.com/page"]}`
+		}
+		llm := script.llm()
+		agent := New(
+			WithPlannerModel(llm),
+			WithSynthesizerModel(llm),
+			WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com/page", Snippet: "Snippet fact about the page"}}}),
+			WithFetchProvider(fetchFunc(func(context.Context, string) (string, error) { return page, nil })),
+			WithStrategyName("graph-reader"),
+			WithGraphReaderConfig(cfg),
+		)
+		res, _ := agent.Answer(context.Background(), "Q")
+		return res, pageReads
+	}
+
+	if res, reads := run(GraphReaderConfig{}); reads != 0 || strings.Contains(res.Knowledge, "Snippet fact") {
+		t.Fatalf("default: reads = %d, knowledge = %s", reads, res.Knowledge)
+	}
+	if _, reads := run(GraphReaderConfig{MinPageChars: 100}); reads == 0 {
+		t.Fatal("MinPageChars 100 should read the page")
+	}
+	if res, _ := run(GraphReaderConfig{SnippetFallback: true}); !strings.Contains(res.Knowledge, "Snippet fact about the page") {
+		t.Fatalf("SnippetFallback should keep the snippet, knowledge = %s", res.Knowledge)
+	}
+}
+
+func TestGraphReaderUsesResultContent(t *testing.T) {
+	page := strings.Repeat("Full text supplied by the provider. ", 10)
+	script := defaultGraphScript()
+	pageReads := 0
+	script.extract = func(user string) string {
+		if strings.Contains(user, "Full text supplied by the provider.") {
+			pageReads++
+			return `{"new_facts": [{"content": "The page was read"}]}`
+		}
+		return `{"new_facts": [], "read_more_urls": ["https://example.com/page"]}`
+	}
+	llm := script.llm()
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com/page", Snippet: "s", Content: page}}}),
+		WithFetchProvider(fetchFunc(func(_ context.Context, url string) (string, error) {
+			t.Errorf("fetched %s although the result carried its content", url)
+			return "", errors.New("unexpected fetch")
+		})),
+		WithStrategyName("graph-reader"),
+	)
+	res, err := agent.Answer(context.Background(), "Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pageReads == 0 || !strings.Contains(res.Knowledge, "The page was read") {
+		t.Fatalf("content not read: reads = %d, knowledge = %s", pageReads, res.Knowledge)
+	}
+}
+
+func TestGraphReaderReadsPagesConcurrentlyInOrder(t *testing.T) {
+	urls := []string{"https://example.com/p1", "https://example.com/p2", "https://example.com/p3", "https://example.com/p4", "https://example.com/p5"}
+	script := defaultGraphScript()
+	script.extract = func(user string) string {
+		var page int
+		if i := strings.Index(user, "Page number "); i >= 0 {
+			fmt.Sscanf(user[i:], "Page number %d", &page)
+			return fmt.Sprintf(`{"new_facts": [{"content": "Fact from page %d"}]}`, page)
+		}
+		return `{"new_facts": [], "read_more_urls": ["` + strings.Join(urls, `", "`) + `"]}`
+	}
+	base := script.llm()
+	var mu sync.Mutex
+	calls := 0
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		resp, err := base(ctx, systemPrompt, userPrompt)
+		resp.Cost = 0.01
+		return resp, err
+	})
+	inFlight, maxInFlight := 0, 0
+	// Fetches wait until three run at once, and page 1 then finishes only
+	// after another page has, so adding facts on completion would put it
+	// out of order.
+	full, otherDone := make(chan struct{}), make(chan struct{})
+	var fullOnce, doneOnce sync.Once
+	wait := func(ch chan struct{}, what string) {
+		select {
+		case <-ch:
+		case <-time.After(10 * time.Second):
+			t.Error(what)
+		}
+	}
+	fetcher := fetchFunc(func(_ context.Context, url string) (string, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		if inFlight == 3 {
+			fullOnce.Do(func() { close(full) })
+		}
+		mu.Unlock()
+		var page int
+		fmt.Sscanf(url, "https://example.com/p%d", &page)
+		wait(full, "three pages were never fetched at once")
+		if page == 1 {
+			wait(otherDone, "page 1 was fetched alone")
+		} else {
+			doneOnce.Do(func() { close(otherDone) })
+		}
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return fmt.Sprintf("Page number %d. ", page) + strings.Repeat("Long enough page text. ", 20), nil
+	})
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com/p1", Snippet: "s"}}}),
+		WithFetchProvider(fetcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{MaxSteps: 1}),
+	)
+	res, err := agent.Answer(context.Background(), "Q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxInFlight != 3 {
+		t.Fatalf("max concurrent fetches = %d, want the default of 3", maxInFlight)
+	}
+	last := -1
+	for page := 1; page <= len(urls); page++ {
+		p := strings.Index(res.Knowledge, fmt.Sprintf("Fact from page %d", page))
+		if p < 0 || p < last {
+			t.Fatalf("page %d fact missing or out of order in %s", page, res.Knowledge)
+		}
+		last = p
+	}
+	if want := float64(calls) * 0.01; res.Cost < want-1e-9 || res.Cost > want+1e-9 {
+		t.Fatalf("cost = %v, want %v for %d calls", res.Cost, want, calls)
+	}
+}
+
+func TestGraphReaderCustomPrompts(t *testing.T) {
+	script := defaultGraphScript()
+	var extractUser, finalSystem string
+	script.extract = func(user string) string {
+		extractUser = user
+		return `{"new_facts": [{"content": "Paris est la capitale de la France", "source_url": "https://example.com"}], "read_more_urls": []}`
+	}
+	base := script.llm()
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		switch systemPrompt {
+		case "Extrais les faits.":
+			systemPrompt = graphExtractorSystemPrompt
+		case "Réponds en français.":
+			finalSystem = systemPrompt
+		}
+		return base(ctx, systemPrompt, userPrompt)
+	})
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "Paris", URL: "https://example.com", Snippet: "Paris"}}}),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{
+			MaxSteps: 1,
+			Prompts: GraphPrompts{
+				ExtractorSystem: "Extrais les faits.",
+				FinalizerSystem: "Réponds en français.",
+				Extract:         template.Must(template.New("extract").Parse("Objectif : {{.Plan.ResearchGoal}}\n{{range .Snippets}}- {{.Content}}\n{{end}}")),
+			},
+		}),
+	)
+
+	if _, err := agent.Answer(context.Background(), "What is the capital of France?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "Objectif : Find the capital of France\n- Paris\n"; extractUser != want {
+		t.Fatalf("extract prompt = %q, want %q", extractUser, want)
+	}
+	if finalSystem == "" {
+		t.Fatal("custom finalizer system prompt was not used")
+	}
+}
+
+func TestGraphReaderPriorKnowledgeContentIDs(t *testing.T) {
+	agent := New(
+		WithPlannerModel(defaultGraphScript().llm()),
+		WithSynthesizerModel(defaultGraphScript().llm()),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "Paris", URL: "https://example.com", Snippet: "Paris"}}}),
+		WithStrategyName("graph-reader"),
+	)
+	// Two earlier sessions both numbered their facts from fact-1.
+	prior := `[{"id": "fact-1", "content": "Paris is the capital of France"}, {"id": "fact-1", "content": "France uses the euro"}, {"id": "fact-2", "content": "paris is the capital of france"}]`
+	res, err := agent.Answer(context.Background(), "What is the capital of France?", WithKnowledge(prior))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	facts, err := ParseKnowledge(res.Knowledge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(facts) != 2 {
+		t.Fatalf("expected the repeated fact to be merged, got %+v", facts)
+	}
+	for _, f := range facts {
+		if f.ID != graph.FactID(f.Content) {
+			t.Fatalf("fact %q has ID %q, want its content ID", f.Content, f.ID)
+		}
+	}
+}
+
+// graphStreamLLM streams each finalizer attempt from attempts in turn.
+type graphStreamLLM struct {
+	llmFunc
+	attempts [][]string
+	calls    *int
+}
+
+func (g graphStreamLLM) GenerateStream(context.Context, string, string) (<-chan string, <-chan LLMResponse, error) {
+	words := g.attempts[*g.calls]
+	*g.calls++
+	chunks := make(chan string, len(words))
+	final := make(chan LLMResponse, 1)
+	for _, w := range words {
+		chunks <- w
+	}
+	close(chunks)
+	final <- LLMResponse{}
+	close(final)
+	return chunks, final, nil
+}
+
+func TestGraphReaderStreamsOnlyKeptFinalizerAttempt(t *testing.T) {
+	script := defaultGraphScript()
+	calls := 0
+	llm := graphStreamLLM{
+		llmFunc:  script.llm(),
+		attempts: [][]string{{"<think>", "too much to weigh", "</think>"}, {"Paris"}},
+		calls:    &calls,
+	}
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com", Snippet: "s"}}}
+	var chunks []string
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithStreamHandler(func(chunk string) { chunks = append(chunks, chunk) }),
+	)
+
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Answer != "Paris" || calls != 2 {
+		t.Fatalf("answer = %q after %d attempts, want Paris after 2", res.Answer, calls)
+	}
+	if !reflect.DeepEqual(chunks, []string{"Paris"}) {
+		t.Fatalf("handler saw %q, want only the kept attempt", chunks)
+	}
+}
+
+func TestGraphReaderQuestionFactsKeepTheirIDs(t *testing.T) {
+	script := defaultGraphScript()
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(script.llm()),
+		WithSynthesizerModel(script.llm()),
+		WithSearchProvider(searcher),
+		WithStrategyName("graph-reader"),
+		WithQuestionContext(true),
+	)
+
+	res, err := agent.Answer(context.Background(), "Given that France is in Europe, what is its capital?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	facts, err := ParseKnowledge(res.Knowledge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]string{}
+	for _, f := range facts {
+		ids[f.Content] = f.ID
+	}
+	if id := ids["Paris is the capital of France"]; id != graph.FactID("Paris is the capital of France") {
+		t.Errorf("researched fact ID = %q, want its content ID", id)
+	}
+	var premise string
+	for content, id := range ids {
+		if strings.Contains(content, "France is in Europe") {
+			premise = id
+		}
+	}
+	if premise != "question-1" {
+		t.Errorf("premise ID = %q, want question-1; knowledge: %s", premise, res.Knowledge)
+	}
+}
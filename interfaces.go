@@ -1,12 +1,24 @@
 package laconic
 
-import "context"
+import (
+	"context"
+	"strings"
+	"time"
+)
 
 // SearchResult is a single item returned by a SearchProvider.
 type SearchResult struct {
 	Title   string
 	URL     string
-	Snippet string
+	Snippet string  // short preview text
+	Content string  // optional: full or long page text, when the provider returns it
+	Score   float64 // optional: provider relevance score (higher is better), 0 when unknown
+	Kind    URLKind // document type inferred from the URL; filled in by the agent
+	Domain  string  // lowercase host without "www."; filled in by the agent
+
+	// PublishedAt is when the page was published, if the provider reports
+	// it (for example Brave news results). Zero when unknown.
+	PublishedAt time.Time
 }
 
 // SearchProvider executes a query and returns results.
@@ -14,6 +26,13 @@ type SearchProvider interface {
 	Search(ctx context.Context, query string) ([]SearchResult, error)
 }
 
+// SearchValidator is optionally implemented by SearchProviders that need
+// credentials. Validate issues a minimal request and reports authentication
+// problems, letting callers fail fast at startup instead of mid-run.
+type SearchValidator interface {
+	Validate(ctx context.Context) error
+}
+
 // FetchProvider retrieves raw content for a URL.
 // Graph-based strategies can use it to read full pages when snippets are insufficient.
 type FetchProvider interface {
@@ -33,19 +52,137 @@ type LLMProvider interface {
 	Generate(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error)
 }
 
+// GenParams carries optional sampling parameters for a single model call.
+type GenParams struct {
+	// Seed, when non-nil, asks the backend for deterministic sampling.
+	Seed *int64
+}
+
+// ParamGenerator is optionally implemented by LLM providers that accept
+// sampling parameters. When the agent has parameters to pass (see WithSeed)
+// it calls GenerateWithParams instead of Generate; providers that do not
+// implement it are called as usual and the parameters are ignored.
+type ParamGenerator interface {
+	GenerateWithParams(ctx context.Context, systemPrompt, userPrompt string, params GenParams) (LLMResponse, error)
+}
+
+// Logger receives the agent's debug traces: every prompt and response plus
+// notes on skipped pages, facts and retries. See WithLogger.
+type Logger interface {
+	Debugf(format string, args ...any)
+}
+
+// LLMStreamProvider is optionally implemented by LLM providers that can
+// stream their output. When a stream handler is set (see WithStreamHandler)
+// the finalizer is called through GenerateStream: the provider sends text
+// chunks on the first channel and closes it, then sends the complete
+// response (with its Cost) on the second. If that response has no Text,
+// the chunks are joined instead. A provider that fails mid-stream should
+// close both channels; the call then fails.
+type LLMStreamProvider interface {
+	GenerateStream(ctx context.Context, systemPrompt, userPrompt string) (<-chan string, <-chan LLMResponse, error)
+}
+
+// ParamStreamProvider is optionally implemented by streaming providers that
+// accept sampling parameters. When the agent has parameters to pass (see
+// WithSeed) the streamed finalizer is called through
+// GenerateStreamWithParams instead of GenerateStream.
+type ParamStreamProvider interface {
+	GenerateStreamWithParams(ctx context.Context, systemPrompt, userPrompt string, params GenParams) (<-chan string, <-chan LLMResponse, error)
+}
+
 // Result is returned by Agent.Answer and carries the final answer text
 // together with the total cost accumulated during the research loop.
 type Result struct {
 	Answer    string
 	Cost      float64
 	Knowledge string // collected knowledge from the research session
+	// Unsupported lists answer claims the knowledge does not back up. It is
+	// only populated when WithAnswerValidation is enabled.
+	Unsupported []string
+	// StopReason records why the run ended.
+	StopReason StopReason
+	// Reasoning holds the finalizer's reasoning (its Reasoning field or
+	// <think> blocks). It is only populated when WithIncludeReasoning is set.
+	Reasoning string
+	// Queries lists the search queries the run issued, in order.
+	Queries []string
+	// Summary is a machine-readable digest of Answer. It is only populated
+	// when WithStructuredSummary is enabled and the summary call succeeds.
+	Summary *Summary
+	// RawResponses holds the unparsed text of every successful model call,
+	// keyed by stage ("planner", "synthesizer", "extractor", ...) in call
+	// order. It is only populated when WithCaptureRawResponses is enabled.
+	RawResponses map[string][]string
+	// Cached is set when the result was reused from an earlier, similar
+	// question (see WithAnswerCacheSimilarity). Cost is then zero.
+	Cached bool
+	// Sources lists the distinct URLs the answer was researched from, in the
+	// order they were first seen: the search results the scratchpad
+	// synthesized, or the graph-reader facts' source URLs.
+	Sources []string
+	// CostBreakdown splits Cost by phase: one entry per model stage that
+	// reported a cost ("planner", "synthesizer", "finalizer", "extractor",
+	// "neighbor", "condense", ...) plus "search" for WithSearchCost. For a
+	// completed run its values add up to Cost.
+	CostBreakdown map[string]float64
+	// Timings reports the run's wall time and the time spent searching,
+	// fetching and in each model stage.
+	Timings Timings
+}
+
+// Summary is a compact, structured digest of an answer, suitable for
+// indexing or preview cards.
+type Summary struct {
+	TLDR     string   `json:"tldr"`      // one-line summary
+	KeyFacts []string `json:"key_facts"` // the answer's main facts
+	Entities []string `json:"entities"`  // people, places, organizations, products
+}
+
+// StopReason explains why a run stopped.
+type StopReason string
+
+const (
+	// StopAnswered means the planner decided it could answer.
+	StopAnswered StopReason = "answered"
+	// StopAnswerCheckPassed means the graph-reader's answer check passed,
+	// or the scratchpad finished early under WithEarlyExit.
+	StopAnswerCheckPassed StopReason = "answer_check_passed"
+	// StopMaxIterations means the scratchpad loop hit WithMaxIterations.
+	StopMaxIterations StopReason = "max_iterations"
+	// StopMaxSteps means the graph-reader hit GraphReaderConfig.MaxSteps.
+	StopMaxSteps StopReason = "max_steps"
+	// StopQueueExhausted means the graph-reader ran out of queries to explore.
+	StopQueueExhausted StopReason = "queue_exhausted"
+	// StopBudgetExceeded means a call or cost budget ran out.
+	StopBudgetExceeded StopReason = "budget_exceeded"
+	// StopTimeout means the context deadline expired.
+	StopTimeout StopReason = "timeout"
+	// StopSalvaged means the finalizer produced nothing and the collected
+	// knowledge was returned as the answer instead.
+	StopSalvaged StopReason = "salvaged"
+	// StopError means the run failed with an error.
+	StopError StopReason = "error"
+)
+
+// ProvisionalAnswer is an intermediate answer emitted while a run is still
+// researching (see WithProgressiveAnswers). The final Result supersedes it.
+type ProvisionalAnswer struct {
+	Iteration int    // scratchpad iteration or graph-reader step (1-based)
+	Answer    string // answer written from the knowledge gathered so far
+	Cost      float64
 }
 
 // AnswerOption configures a single call to Agent.Answer.
 type AnswerOption func(*answerConfig)
 
 type answerConfig struct {
-	priorKnowledge string
+	priorKnowledge     string
+	initialSearchQuery string
+	searcher           SearchProvider
+	outputSchema       string
+	maxIterations      int
+	timeout            time.Duration
 }
 
 // WithKnowledge supplies prior knowledge collected from a previous research
@@ -55,3 +192,45 @@ type answerConfig struct {
 func WithKnowledge(knowledge string) AnswerOption {
 	return func(c *answerConfig) { c.priorKnowledge = knowledge }
 }
+
+// WithInitialSearchQuery sets the query the scratchpad strategy searches when
+// the planner tries to answer before anything has been searched. By default
+// that forced search uses the question itself, which is often a poor query
+// for long or conversational questions.
+func WithInitialSearchQuery(query string) AnswerOption {
+	return func(c *answerConfig) { c.initialSearchQuery = query }
+}
+
+// WithSearchProviderFor searches with p instead of the Agent's search
+// provider for this call only, for example to send academic questions to a
+// scholarly index. Both built-in strategies honor it.
+func WithSearchProviderFor(p SearchProvider) AnswerOption {
+	return func(c *answerConfig) { c.searcher = p }
+}
+
+// WithMaxIterationsForCall overrides WithMaxIterations for this call, or
+// GraphReaderConfig.MaxSteps for the graph-reader and deep-read strategies.
+// Values below 1 keep the agent's setting.
+func WithMaxIterationsForCall(n int) AnswerOption {
+	return func(c *answerConfig) { c.maxIterations = n }
+}
+
+// WithTimeout limits this call's research to d. When the time is up, the
+// run stops searching and the finalizer writes a best-effort answer from
+// what was gathered, which is returned with ErrTimeout. That last call is
+// bounded only by the caller's context. If the caller's context has an
+// earlier deadline, that deadline applies instead and the run fails with
+// the context's error when it expires, as without WithTimeout.
+func WithTimeout(d time.Duration) AnswerOption {
+	return func(c *answerConfig) { c.timeout = d }
+}
+
+// WithOutputSchema asks for the answer as JSON conforming to schema, a JSON
+// Schema document. Only the finalizer is given the schema; planning and
+// research notes stay plain text. If the answer does not parse as JSON, one
+// more call asks the finalizer model to repair it, and a run whose answer
+// still does not parse fails with ErrInvalidJSONAnswer. Result.Answer holds
+// the bare JSON, without code fences. WithMaxAnswerWords is not applied.
+func WithOutputSchema(schema string) AnswerOption {
+	return func(c *answerConfig) { c.outputSchema = strings.TrimSpace(schema) }
+}
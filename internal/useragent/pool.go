@@ -0,0 +1,49 @@
+// Package useragent picks User-Agent strings for scraping requests.
+package useragent
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// Default is a small pool of realistic desktop browser User-Agents.
+var Default = []string{ //nolint:gochecknoglobals
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+}
+
+// Pool hands out User-Agents from a fixed list. It is safe for concurrent use.
+type Pool struct {
+	agents []string
+	// Deterministic makes Pick cycle through the list in order instead of
+	// choosing at random, so tests see a predictable sequence.
+	Deterministic bool
+	next          atomic.Uint32
+}
+
+// New returns a pool over agents, or over Default when agents is empty.
+func New(agents []string) *Pool {
+	var list []string
+	for _, a := range agents {
+		if a != "" {
+			list = append(list, a)
+		}
+	}
+	if len(list) == 0 {
+		list = Default
+	}
+	return &Pool{agents: list}
+}
+
+// Pick returns the User-Agent to use for the next request.
+func (p *Pool) Pick() string {
+	if p.Deterministic {
+		i := p.next.Add(1) - 1
+		return p.agents[int(i)%len(p.agents)]
+	}
+	return p.agents[rand.Intn(len(p.agents))]
+}
@@ -0,0 +1,31 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeterministicPickRotates(t *testing.T) {
+	p := New([]string{"a", "", "b", "c"})
+	p.Deterministic = true
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, p.Pick())
+	}
+	if want := "a,b,c,a"; strings.Join(got, ",") != want {
+		t.Fatalf("picks = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestRandomPickStaysInPool(t *testing.T) {
+	p := New(nil)
+	valid := make(map[string]bool)
+	for _, a := range Default {
+		valid[a] = true
+	}
+	for i := 0; i < 20; i++ {
+		if ua := p.Pick(); !valid[ua] {
+			t.Fatalf("unexpected user agent %q", ua)
+		}
+	}
+}
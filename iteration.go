@@ -0,0 +1,23 @@
+package laconic
+
+// IterationEvent describes a finished scratchpad iteration or graph-reader
+// step. See WithIterationHook.
+type IterationEvent struct {
+	Iteration int // scratchpad iteration or graph-reader step (1-based)
+	// Decision is what the planner asked for. Graph-reader steps always
+	// search the next queued node, so their decision is a search for it.
+	Decision PlannerDecision
+	// Query is the query actually searched, after any reformulation; it is
+	// empty when the iteration did not search. Several queries searched in
+	// one iteration are joined by newlines.
+	Query   string
+	Results int     // number of distinct search results for Query
+	Cost    float64 // cost of the run so far
+}
+
+// emitIteration passes ev to the iteration hook, if one is set.
+func (a *Agent) emitIteration(ev IterationEvent) {
+	if a.iterationHook != nil {
+		a.iterationHook(ev)
+	}
+}
@@ -0,0 +1,62 @@
+package laconic
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIterationHookScratchpad(t *testing.T) {
+	llm := &scriptedLLM{
+		planner:     []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+		synth:       []string{"The sky is blue."},
+		final:       []string{"Blue."},
+		costPerCall: 0.01,
+	}
+	var events []IterationEvent
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithIterationHook(func(ev IterationEvent) { events = append(events, ev) }),
+	)
+	res, err := agent.Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+	if ev := events[0]; ev.Iteration != 1 || ev.Decision.Action != PlannerActionSearch || ev.Query != "sky color" || ev.Results != 1 {
+		t.Fatalf("first event = %+v", ev)
+	}
+	if ev := events[1]; ev.Iteration != 2 || ev.Decision.Action != PlannerActionAnswer || ev.Query != "" || ev.Cost != res.Cost {
+		t.Fatalf("last event = %+v, want an answer at the run's cost %v", ev, res.Cost)
+	}
+	if events[0].Cost >= events[1].Cost {
+		t.Fatalf("cost should grow between events: %v then %v", events[0].Cost, events[1].Cost)
+	}
+}
+
+func TestIterationHookGraphReader(t *testing.T) {
+	llm := defaultGraphScript().llm()
+	var queries []string
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithStrategyName("graph-reader"),
+		WithIterationHook(func(ev IterationEvent) {
+			if ev.Decision.Action != PlannerActionSearch || ev.Results != 1 || ev.Iteration != len(queries)+1 {
+				t.Errorf("unexpected event %+v", ev)
+			}
+			queries = append(queries, ev.Query)
+		}),
+	)
+	if _, err := agent.Answer(context.Background(), "What is the capital of France?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queries) != 2 || queries[0] != "capital of France" || queries[1] != "France government seat" {
+		t.Fatalf("queries = %q", queries)
+	}
+}
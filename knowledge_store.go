@@ -0,0 +1,111 @@
+package laconic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+// KnowledgeStore accumulates the knowledge of several Answer calls into one
+// deduplicated set of facts. Pass AsKnowledge to WithKnowledge so each new
+// question starts from everything learned so far:
+//
+//	var store laconic.KnowledgeStore
+//	res, _ := agent.Answer(ctx, q1)
+//	store.Merge(res)
+//	res, _ = agent.Answer(ctx, q2, laconic.WithKnowledge(store.AsKnowledge()))
+//	store.Merge(res)
+//
+// The zero value is ready to use and safe for concurrent use.
+type KnowledgeStore struct {
+	mu sync.Mutex
+	// nb holds the stored facts in Clues; its cached word sets keep each
+	// Merge from splitting every stored fact again.
+	nb graph.Notebook
+}
+
+// Merge adds the knowledge from res to the store. Graph-reader knowledge (a
+// JSON array of facts) is merged fact by fact; scratchpad knowledge is kept
+// as a single fact. Facts that duplicate stored ones are skipped.
+func (k *KnowledgeStore) Merge(res Result) {
+	knowledge := strings.TrimSpace(res.Knowledge)
+	if knowledge == "" {
+		return
+	}
+	facts, err := ParseKnowledge(knowledge)
+	if err != nil {
+		facts = []graph.AtomicFact{plainKnowledgeFact(knowledge)}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, fact := range facts {
+		content := strings.TrimSpace(fact.Content)
+		if content == "" || isDuplicateClue(&k.nb, 0, content, defaultFactDedupThreshold) {
+			continue
+		}
+		if fact.Timestamp == 0 {
+			fact.Timestamp = time.Now().Unix()
+		}
+		// Content IDs match across sessions; older knowledge may carry
+		// run-local numbers, so every fact is given its content ID.
+		fact.ID = graph.FactID(content)
+		fact.Content = content
+		k.nb.Clues = append(k.nb.Clues, fact)
+	}
+}
+
+// ParseKnowledge converts a Result.Knowledge value back into facts. The
+// graph-reader's JSON fact array is decoded as is; any other text (such as
+// the scratchpad's prose summary, even one starting with "[1]") is returned
+// as a single fact with its content ID (see graph.FactID), the same way
+// WithKnowledge treats it. An empty string yields no facts. An error is
+// returned only for valid JSON that is not a fact array.
+func ParseKnowledge(s string) ([]graph.AtomicFact, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "{") {
+		return []graph.AtomicFact{plainKnowledgeFact(s)}, nil
+	}
+	var facts []graph.AtomicFact
+	if err := json.Unmarshal([]byte(s), &facts); err != nil {
+		if !json.Valid([]byte(s)) {
+			return []graph.AtomicFact{plainKnowledgeFact(s)}, nil
+		}
+		return nil, fmt.Errorf("knowledge JSON parse: %w", err)
+	}
+	return facts, nil
+}
+
+// plainKnowledgeFact wraps free-text knowledge as a single fact.
+func plainKnowledgeFact(text string) graph.AtomicFact {
+	return graph.AtomicFact{ID: graph.FactID(text), Content: text, Confidence: 1}
+}
+
+// AsKnowledge returns the stored facts as a JSON array suitable for
+// WithKnowledge, or an empty string when the store is empty.
+func (k *KnowledgeStore) AsKnowledge() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.nb.Clues) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(k.nb.Clues)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Len returns the number of facts in the store.
+func (k *KnowledgeStore) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.nb.Clues)
+}
@@ -0,0 +1,137 @@
+package laconic
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+func TestKnowledgeStoreMergeDedupes(t *testing.T) {
+	var store KnowledgeStore
+	store.Merge(Result{Knowledge: `[{"id": "fact-1", "content": "Paris is the capital of France", "source_url": "https://a.example"}]`})
+	store.Merge(Result{Knowledge: `[
+		{"id": "fact-1", "content": "paris is the capital of france"},
+		{"id": "fact-2", "content": "France uses the euro"}
+	]`})
+	store.Merge(Result{Knowledge: "Lyon is the third-largest city in France."})
+	store.Merge(Result{})
+
+	if store.Len() != 3 {
+		t.Fatalf("expected 3 facts, got %d", store.Len())
+	}
+
+	var facts []graph.AtomicFact
+	if err := json.Unmarshal([]byte(store.AsKnowledge()), &facts); err != nil {
+		t.Fatalf("AsKnowledge is not a JSON fact list: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, f := range facts {
+		if ids[f.ID] {
+			t.Fatalf("duplicate fact ID %q", f.ID)
+		}
+		ids[f.ID] = true
+	}
+	if facts[0].SourceURL != "https://a.example" {
+		t.Fatalf("expected the first fact to keep its source, got %+v", facts[0])
+	}
+}
+
+func TestKnowledgeStoreContentIDs(t *testing.T) {
+	first := `[{"id": "fact-1", "content": "Paris is the capital of France"}]`
+	second := `[{"id": "fact-1", "content": "France uses the euro"}, {"id": "fact-2", "content": "Paris  is the capital of FRANCE"}]`
+	merge := func(knowledge ...string) []graph.AtomicFact {
+		var store KnowledgeStore
+		for _, k := range knowledge {
+			store.Merge(Result{Knowledge: k})
+		}
+		facts, err := ParseKnowledge(store.AsKnowledge())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return facts
+	}
+	norm := func(s string) string { return strings.Join(strings.Fields(strings.ToLower(s)), " ") }
+	a, b := merge(first, second), merge(second, first)
+	if len(a) != 2 || len(b) != 2 {
+		t.Fatalf("expected 2 facts each, got %+v and %+v", a, b)
+	}
+	ids := map[string]string{}
+	for _, f := range a {
+		ids[norm(f.Content)] = f.ID
+	}
+	for _, f := range b {
+		if ids[norm(f.Content)] != f.ID {
+			t.Fatalf("fact %q has ID %q in one order and %q in the other", f.Content, f.ID, ids[norm(f.Content)])
+		}
+	}
+	if id := graph.FactID("Paris is the capital of France"); ids["paris is the capital of france"] != id || len(id) != len("fact-")+8 {
+		t.Fatalf("unexpected content ID %q", id)
+	}
+}
+
+func TestKnowledgeStoreKeepsFactWords(t *testing.T) {
+	var store KnowledgeStore
+	store.Merge(Result{Knowledge: `[{"content": "Paris is the capital of France"}]`})
+	tokens := store.nb.ClueTokens(0)
+	store.Merge(Result{Knowledge: `[{"content": "Berlin is the capital of Germany"}]`})
+	if store.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", store.Len())
+	}
+	if again := store.nb.ClueTokens(0); reflect.ValueOf(again).Pointer() != reflect.ValueOf(tokens).Pointer() {
+		t.Fatal("Merge split a stored fact into words again")
+	}
+}
+
+func TestKnowledgeStoreEmpty(t *testing.T) {
+	var store KnowledgeStore
+	if store.AsKnowledge() != "" {
+		t.Fatalf("expected empty knowledge from an empty store")
+	}
+}
+
+func TestParseKnowledgeJSON(t *testing.T) {
+	facts, err := ParseKnowledge(`[{"id": "fact-1", "content": "Paris is the capital of France", "source_url": "https://a.example", "confidence": 0.8}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(facts) != 1 {
+		t.Fatalf("expected 1 fact, got %d", len(facts))
+	}
+	f := facts[0]
+	if f.ID != "fact-1" || f.Content != "Paris is the capital of France" || f.SourceURL != "https://a.example" || f.Confidence != 0.8 {
+		t.Fatalf("unexpected fact: %+v", f)
+	}
+}
+
+func TestParseKnowledgePlainText(t *testing.T) {
+	facts, err := ParseKnowledge("  The sky is blue due to Rayleigh scattering.  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sky := "The sky is blue due to Rayleigh scattering."
+	if len(facts) != 1 || facts[0].ID != graph.FactID(sky) || facts[0].Content != sky || facts[0].Confidence != 1 {
+		t.Fatalf("unexpected facts: %+v", facts)
+	}
+}
+
+func TestParseKnowledgeEmptyAndMalformed(t *testing.T) {
+	facts, err := ParseKnowledge("")
+	if err != nil || facts != nil {
+		t.Fatalf("expected no facts and no error, got %v, %v", facts, err)
+	}
+	if _, err := ParseKnowledge(`{"content": "not an array"}`); err == nil {
+		t.Fatal("expected an error for JSON that is not a fact array")
+	}
+}
+
+func TestParseKnowledgeBracketedProse(t *testing.T) {
+	for _, s := range []string{"[1] Paris is the capital of France.", `[{"content":`} {
+		facts, err := ParseKnowledge(s)
+		if err != nil || len(facts) != 1 || facts[0].ID != graph.FactID(s) || facts[0].Content != s {
+			t.Fatalf("ParseKnowledge(%q) = %+v, %v; want one plain fact", s, facts, err)
+		}
+	}
+}
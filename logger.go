@@ -0,0 +1,17 @@
+package laconic
+
+import "fmt"
+
+// stdoutLogger is the Logger used by WithDebug when none is configured.
+type stdoutLogger struct{}
+
+func (stdoutLogger) Debugf(format string, args ...any) {
+	fmt.Printf("[LACONIC DEBUG] "+format+"\n", args...)
+}
+
+// debugf writes a debug trace when debugging is enabled.
+func (a *Agent) debugf(format string, args ...any) {
+	if a.debug {
+		a.logger.Debugf(format, args...)
+	}
+}
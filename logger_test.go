@@ -0,0 +1,59 @@
+package laconic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingLogger keeps every debug trace.
+type recordingLogger struct{ lines []string }
+
+func (r *recordingLogger) Debugf(format string, args ...any) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithLoggerReceivesTraces(t *testing.T) {
+	llm := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+		synth:   []string{"The sky is blue."},
+		final:   []string{"Blue."},
+	}
+	logger := &recordingLogger{}
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{}),
+		WithDebug(true),
+		WithLogger(logger),
+	)
+	if _, err := agent.Answer(context.Background(), "Why is the sky blue?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var planner, finalizer bool
+	for _, line := range logger.lines {
+		planner = planner || strings.HasPrefix(line, "Planner User Prompt:")
+		finalizer = finalizer || line == "Finalizer Response:\nBlue."
+	}
+	if !planner || !finalizer {
+		t.Fatalf("missing planner or finalizer traces in %q", logger.lines)
+	}
+}
+
+func TestNoLoggerWithoutDebug(t *testing.T) {
+	agent := New()
+	if agent.debug || agent.logger != nil {
+		t.Fatal("debug output should be off by default")
+	}
+	logger := &recordingLogger{}
+	agent = New(WithLogger(logger), WithDebug(false))
+	agent.debugf("trace")
+	if len(logger.lines) != 0 {
+		t.Fatalf("logger received %q without WithDebug(true)", logger.lines)
+	}
+	if _, ok := New(WithDebug(true)).logger.(stdoutLogger); !ok {
+		t.Fatal("WithDebug without a logger should log to stdout")
+	}
+}
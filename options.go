@@ -1,7 +1,20 @@
 package laconic
 
+import (
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/smhanov/laconic/graph"
+)
+
 const defaultMaxIterations = 5
 const defaultGraphReaderSteps = 8
+const defaultMaxNeighborsPerStep = 4
+const defaultMinPageChars = 200
+const defaultCondenseConcurrency = 2
+const defaultReadConcurrency = 3
+const defaultFactDedupThreshold = 0.8
 
 // Option configures an Agent.
 type Option func(*Agent)
@@ -40,11 +53,18 @@ func WithMaxIterations(n int) Option {
 	}
 }
 
-// WithDebug enables debug logging of all LLM prompts and responses.
+// WithDebug enables debug logging of all LLM prompts and responses. Without
+// WithLogger the traces go to stdout.
 func WithDebug(enabled bool) Option {
 	return func(a *Agent) { a.debug = enabled }
 }
 
+// WithLogger sends the debug traces to l instead of stdout. It does not turn
+// them on; l receives nothing unless WithDebug(true) is also set.
+func WithLogger(l Logger) Option {
+	return func(a *Agent) { a.logger = l }
+}
+
 // WithStrategy sets a custom strategy instance.
 func WithStrategy(strategy Strategy) Option {
 	return func(a *Agent) { a.strategy = strategy }
@@ -72,6 +92,297 @@ func WithSearchCost(costPerSearch float64) Option {
 	return func(a *Agent) { a.searchCost = costPerSearch }
 }
 
+// WithGroundingMode controls whether answers may draw on the model's internal
+// knowledge. GroundingStrict (the default) requires every fact to come from
+// search results; GroundingAugment allows internal knowledge labeled as such;
+// GroundingOff removes the restriction entirely. In the non-strict modes the
+// scratchpad planner may also answer without searching first.
+func WithGroundingMode(mode GroundingMode) Option {
+	return func(a *Agent) { a.groundingMode = mode }
+}
+
+// WithReturnPartialOnError makes strategies return the partially populated
+// Result (accumulated cost and knowledge) together with the error when a run
+// fails midway. By default a failed run returns an empty Result.
+func WithReturnPartialOnError(enabled bool) Option {
+	return func(a *Agent) { a.returnPartialOnError = enabled }
+}
+
+// WithReformulateOnEmpty makes the scratchpad strategy recover from searches
+// that return no results: the planner model rewrites the query into a
+// broader one and the search is retried once. The extra LLM call and search
+// are included in the cost.
+func WithReformulateOnEmpty(enabled bool) Option {
+	return func(a *Agent) { a.reformulateOnEmpty = enabled }
+}
+
+// WithMaxLLMCalls caps the number of model calls a single run may make
+// across every stage (planning, synthesis, extraction, neighbor discovery,
+// answer checks). Once the cap is reached the run stops researching and
+// finalizes with what it has; the finalizer (and graph-reader's fact
+// condensation) may still run. The answer is returned together with
+// ErrCallBudgetExceeded. This suits self-hosted models where the budget is
+// latency rather than dollars. The default is 0 (unlimited).
+func WithMaxLLMCalls(n int) Option {
+	return func(a *Agent) {
+		if n > 0 {
+			a.maxLLMCalls = n
+		}
+	}
+}
+
+// WithMaxCost caps what a single run may spend, in the same units as
+// LLMResponse.Cost and WithSearchCost. Before each research call the run's
+// cost so far is compared with the budget; once it is reached the run stops
+// searching and finalizes with the knowledge it has, with StopReason
+// StopBudgetExceeded and ErrCostBudgetExceeded. The finalizer and later
+// post-processing still run, so the total can exceed the budget by their
+// cost and that of the last research call. The default is 0 (unlimited).
+func WithMaxCost(dollars float64) Option {
+	return func(a *Agent) {
+		if dollars > 0 {
+			a.maxCost = dollars
+		}
+	}
+}
+
+// WithAnswerValidation runs one extra finalizer call after the answer is
+// produced, checking each claim against Result.Knowledge. Claims that are not
+// grounded in the collected facts are listed in Result.Unsupported. The
+// answer itself is never withheld, and the call's cost is included.
+func WithAnswerValidation(enabled bool) Option {
+	return func(a *Agent) { a.answerValidation = enabled }
+}
+
+// WithSkipNonHTML stops the graph-reader from fetching links whose URL
+// points to a PDF or office document (see InferURLKind). Snippets from such
+// results are still used; only the binary download is skipped.
+func WithSkipNonHTML(enabled bool) Option {
+	return func(a *Agent) { a.skipNonHTML = enabled }
+}
+
+// WithProgressiveAnswers writes a provisional answer every `every`
+// iterations (scratchpad) or steps (graph-reader) once some knowledge has
+// been gathered, and passes it to handler. Each provisional answer is one
+// extra finalizer call whose cost is added to the run; it counts against
+// WithMaxLLMCalls. The Result returned by Answer remains authoritative.
+func WithProgressiveAnswers(every int, handler func(ProvisionalAnswer)) Option {
+	return func(a *Agent) {
+		if every > 0 && handler != nil {
+			a.progressEvery = every
+			a.progressHandler = handler
+		}
+	}
+}
+
+// WithIterationHook calls hook at the end of every scratchpad iteration and
+// graph-reader step with what the planner decided, what was searched and
+// the cost so far. It runs on the goroutine calling Answer and should
+// return quickly.
+func WithIterationHook(hook func(IterationEvent)) Option {
+	return func(a *Agent) { a.iterationHook = hook }
+}
+
+// WithMaxAnswerWords asks the finalizer to keep answers under n words and,
+// as a backstop, truncates Result.Answer at the last sentence boundary within
+// the limit when the model overshoots. Zero (the default) means no limit.
+func WithMaxAnswerWords(n int) Option {
+	return func(a *Agent) {
+		if n > 0 {
+			a.maxAnswerWords = n
+		}
+	}
+}
+
+// WithIncludeReasoning copies the reasoning produced by the finalizer call
+// that wrote the answer into Result.Reasoning. The answer text itself is
+// unchanged.
+func WithIncludeReasoning(enabled bool) Option {
+	return func(a *Agent) { a.includeReasoning = enabled }
+}
+
+// WithSynthesizerResultFields chooses which search result fields the
+// scratchpad synthesizer sees, in order. Valid names are "title", "url",
+// "snippet", and "content" (the provider's full page text, truncated);
+// unknown names are ignored. The default is title, url, and snippet.
+// Dropping "url" saves tokens on small-context models.
+func WithSynthesizerResultFields(fields []string) Option {
+	return func(a *Agent) {
+		var valid []string
+		for _, f := range fields {
+			switch f = strings.ToLower(strings.TrimSpace(f)); f {
+			case "title", "url", "snippet", "content":
+				valid = append(valid, f)
+			}
+		}
+		a.synthesizerFields = valid
+	}
+}
+
+// WithTitleDedup drops search results whose title is at least threshold
+// similar to an earlier result's (see TitleSimilarity), keeping the
+// highest-scored instance. This catches syndicated stories that appear under
+// many URLs. Both strategies apply it to every search; 0.8 is a reasonable
+// starting point. Zero (the default) disables it.
+func WithTitleDedup(threshold float64) Option {
+	return func(a *Agent) {
+		if threshold > 0 && threshold <= 1 {
+			a.titleDedup = threshold
+		}
+	}
+}
+
+// WithTokenizer sets the function used to count tokens when the graph-reader
+// truncates page content and finalizer retry knowledge, and when it splits
+// documents passed to AnswerFromText into chunks. Supply the tokenizer for
+// your model so these budgets land near its real context limit. By default
+// tokens are estimated as characters / 4.
+func WithTokenizer(count func(string) int) Option {
+	return func(a *Agent) { a.tokenizer = count }
+}
+
+// WithSeed passes a sampling seed to every model call, in every stage, for
+// providers that implement ParamGenerator (or ParamStreamProvider for a
+// streamed finalizer). Backends that support seeding
+// (OpenAI, Ollama, vLLM) then sample deterministically, which makes runs
+// repeatable for evals; other providers ignore it.
+func WithSeed(seed int64) Option {
+	return func(a *Agent) { a.seed = &seed }
+}
+
+// WithSearchResultLimit makes the agent use only the top n results of each
+// search. Providers can then be configured to return a larger set (their
+// MaxResults field) for wrappers such as search.Instrumented to see in full,
+// while the models still read a short list. Zero (the default) uses every
+// result the provider returns.
+func WithSearchResultLimit(n int) Option {
+	return func(a *Agent) {
+		if n > 0 {
+			a.searchResultLimit = n
+		}
+	}
+}
+
+// WithQuestionContext seeds the knowledge with premises stated in the
+// question itself, such as "Given that Acme acquired Beta last year, ..." or
+// a declarative sentence before the actual question. The premises are found
+// with a simple heuristic (no extra model call) and let the agent reason over
+// what the caller already said instead of searching for it.
+func WithQuestionContext(enabled bool) Option {
+	return func(a *Agent) { a.questionContext = enabled }
+}
+
+// WithSynthesizerHistory shows the scratchpad's search history to the
+// synthesizer, so it knows what has already been covered and is less likely
+// to re-introduce facts it pruned earlier. It costs extra prompt tokens on
+// every iteration, so it is off by default.
+func WithSynthesizerHistory(enabled bool) Option {
+	return func(a *Agent) { a.synthesizerHistory = enabled }
+}
+
+// WithStructuredSummary runs one extra finalizer call after the answer is
+// produced to fill Result.Summary with a one-line TL;DR, the key facts and
+// the entities the answer mentions. The prose answer is unchanged, and the
+// call's cost is included.
+func WithStructuredSummary(enabled bool) Option {
+	return func(a *Agent) { a.structuredSummary = enabled }
+}
+
+// WithSearchErrorHandler registers fn to be called with the query and error
+// of every failed search, whether the run recovers (for example when the
+// graph-reader skips a node whose search timed out) or stops with the error.
+// Use it to keep a record of failed queries across long batch runs. fn may be
+// called from several goroutines when runs share the Agent.
+func WithSearchErrorHandler(fn func(query string, err error)) Option {
+	return func(a *Agent) { a.searchErrorHandler = fn }
+}
+
+// WithCaptureRawResponses records the raw text of every model call, before
+// any parsing, in Result.RawResponses keyed by stage, so callers can archive
+// exactly what the models produced. Unlike WithDebug nothing is printed.
+func WithCaptureRawResponses(enabled bool) Option {
+	return func(a *Agent) { a.captureRawResponses = enabled }
+}
+
+// WithTracer starts a span with t around every run, search, fetch and model
+// call (see Tracer). Without it tracing is off and costs nothing.
+func WithTracer(t Tracer) Option {
+	return func(a *Agent) { a.tracer = t }
+}
+
+// WithAnswerCacheSimilarity makes the Agent remember its recent successful
+// answers and reuse one, without researching, when a new question is at
+// least threshold similar to the question it answered (see
+// QuestionSimilarity; 0.8 is a reasonable start). The cache belongs to the
+// Agent, so use one Agent per conversation to keep users apart. A threshold
+// outside (0, 1] leaves caching off.
+func WithAnswerCacheSimilarity(threshold float64) Option {
+	return func(a *Agent) {
+		if threshold > 0 && threshold <= 1 {
+			a.cacheSimilarity = threshold
+		}
+	}
+}
+
+// WithAnswerCacheSimilarityFunc replaces QuestionSimilarity as the measure
+// WithAnswerCacheSimilarity compares questions with, for example with an
+// embedding-based score. fn must return a value from 0 to 1.
+func WithAnswerCacheSimilarityFunc(fn func(a, b string) float64) Option {
+	return func(a *Agent) { a.cacheSimilarityFunc = fn }
+}
+
+// WithInsufficientAnswerText sets the answer given when research found
+// nothing to answer from. Under GroundingStrict, when the knowledge is empty
+// the text is returned as-is, skipping the finalizer call; otherwise the
+// scratchpad finalizer is told to reply with it if the knowledge falls
+// short. Use it to localize or brand the fallback.
+func WithInsufficientAnswerText(text string) Option {
+	return func(a *Agent) { a.insufficientText = strings.TrimSpace(text) }
+}
+
+// WithEarlyExit makes the scratchpad strategy check the knowledge after its
+// first synthesis and, when one line of it mentions every significant word
+// of the question, finalize at once instead of asking the planner again.
+// This saves a planner call on simple factual questions.
+func WithEarlyExit(enabled bool) Option {
+	return func(a *Agent) { a.earlyExit = enabled }
+}
+
+// WithStreamHandler passes the final answer to fn chunk by chunk as the
+// finalizer writes it, for finalizer models that implement
+// LLMStreamProvider; other models are called as usual and fn is not
+// called. <think> blocks are left out and nothing is sent until answer text
+// appears, so graph-reader finalizer attempts that only reason, and are
+// retried, stream nothing. WithMaxAnswerWords truncation is applied only to
+// Result.Answer.
+func WithStreamHandler(fn func(chunk string)) Option {
+	return func(a *Agent) { a.streamHandler = fn }
+}
+
+// WithPlannerPrompt replaces the scratchpad planner's system prompt, which
+// otherwise depends on the grounding mode. The user prompt, which asks for
+// "Action: Answer", or "Action: Search" followed by "Query:" lines, is
+// unchanged, and a custom prompt must keep that reply format. Do not ask
+// for JSON: a JSON object reply is taken as a decision to answer without
+// searching.
+func WithPlannerPrompt(prompt string) Option {
+	return func(a *Agent) { a.plannerPrompt = strings.TrimSpace(prompt) }
+}
+
+// WithSynthesizerPrompt replaces the scratchpad synthesizer's system
+// prompt, which otherwise depends on the grounding mode.
+func WithSynthesizerPrompt(prompt string) Option {
+	return func(a *Agent) { a.synthesizerPrompt = strings.TrimSpace(prompt) }
+}
+
+// WithFinalizerPrompt replaces the scratchpad finalizer's system prompt,
+// which otherwise depends on the grounding mode. Use it to set the tone,
+// language or citation style of answers. It also applies to provisional
+// answers from WithProgressiveAnswers.
+func WithFinalizerPrompt(prompt string) Option {
+	return func(a *Agent) { a.finalizerPrompt = strings.TrimSpace(prompt) }
+}
+
 // GraphReaderConfig configures the GraphReader strategy.
 type GraphReaderConfig struct {
 	Planner   LLMProvider
@@ -79,8 +390,131 @@ type GraphReaderConfig struct {
 	Neighbor  LLMProvider
 	Finalizer LLMProvider
 	MaxSteps  int
+
+	// StepTimeout bounds each search, fetch, and LLM call made while
+	// traversing the graph. An operation that times out is skipped and the
+	// traversal continues. Zero means no per-step limit.
+	StepTimeout time.Duration
+
+	// MinFactConfidence drops extracted facts whose confidence is below this
+	// threshold before they enter the notebook. Zero keeps every fact.
+	MinFactConfidence float64
+
+	// MaxNeighborsPerStep caps how many new neighbor queries one step may
+	// add to the queue; the model's first suggestions are kept. The default
+	// is 4.
+	MaxNeighborsPerStep int
+
+	// FixedPlan, when set, is used as the research plan instead of asking
+	// the planner model for one. Its ResearchGoal must not be empty;
+	// OriginalQuestion is filled in from each question.
+	FixedPlan *graph.RationalPlan
+
+	// SeedQueries, when set, become the initial search queue instead of
+	// the queries the planner would suggest. Together with FixedPlan this
+	// skips every planning call.
+	SeedQueries []string
+
+	// PreserveSources keeps fact provenance in the knowledge handed to the
+	// finalizer: facts are tagged with [n] markers, the condenser is asked
+	// to keep them, and the numbered source URLs are listed at the end. By
+	// default URLs are stripped to save tokens.
+	PreserveSources bool
+
+	// MaxCitations caps the numbered source list PreserveSources appends.
+	// Only the n sources cited by the most facts are kept and renumbered;
+	// facts from dropped sources stay but lose their marker. Zero (the
+	// default) keeps every source.
+	MaxCitations int
+
+	// MinPageChars is the shortest page text, in characters after
+	// extraction, that is worth reading; shorter pages are skipped. The
+	// default is 200.
+	MinPageChars int
+
+	// SnippetFallback adds a page's search snippet as a fact when the page
+	// is skipped for being shorter than MinPageChars.
+	SnippetFallback bool
+
+	// CondenseConcurrency caps how many fact batches are condensed in
+	// parallel when knowledge is too large to hand over as-is (default 2).
+	CondenseConcurrency int
+
+	// ReadConcurrency caps how many of the pages the extractor asks to read
+	// in full are fetched and extracted in parallel (default 3).
+	ReadConcurrency int
+
+	// AnswerCheck, when set, replaces the LLM call that decides after each
+	// step (once at least 5 facts exist) whether the notebook can answer the
+	// question. KeywordCoverage is a built-in heuristic.
+	AnswerCheck func(state *graph.AgentState) bool
+
+	// DedupScope selects which facts a new fact is checked against before it
+	// joins the notebook (default DedupGlobal).
+	DedupScope DedupScope
+
+	// DedupThreshold is the FactSimilarity at or above which two facts are
+	// duplicates, both when facts join the notebook and when knowledge is
+	// condensed (default 0.8). Raise it toward 1 to keep more rewordings.
+	DedupThreshold float64
+
+	// DiverseExtraction caps the snippets from any one domain that are
+	// sent to the extractor at 2 per step, and lists each domain's best
+	// result before any second one, so a single prolific site cannot
+	// crowd out the others. Pages can still be read in full.
+	DiverseExtraction bool
+
+	// Prompts replaces the built-in system prompts and prompt templates,
+	// for example to research in another language or a specialized field.
+	// Unset fields keep the defaults.
+	Prompts GraphPrompts
 }
 
+// GraphPrompts overrides the prompts of the GraphReader strategy. Each
+// template is executed with the same data as the built-in one it replaces
+// (graph.TmplPlan, graph.TmplExtract, ...) and must ask for the same JSON
+// reply, since responses are parsed as before.
+type GraphPrompts struct {
+	// System prompts by stage. FinalizerSystem and CondenserSystem, when
+	// set, are used for every grounding mode and with PreserveSources.
+	PlannerSystem     string
+	ExtractorSystem   string
+	NeighborSystem    string
+	AnswerCheckSystem string
+	FinalizerSystem   string
+	CondenserSystem   string
+
+	// Plan is executed with .Question.
+	Plan *template.Template
+	// Init is executed with the graph.RationalPlan.
+	Init *template.Template
+	// Extract is executed with .Plan, .CurrentNode and .Snippets, each
+	// with .URL and .Content.
+	Extract *template.Template
+	// ExtractText is executed with .Plan, .SourceURL and .Content.
+	ExtractText *template.Template
+	// Neighbors is executed with .Plan, .Notebook and .CurrentNode.
+	Neighbors *template.Template
+	// AnswerCheck is executed with .Plan and .Notebook.
+	AnswerCheck *template.Template
+}
+
+// DedupScope controls how the graph-reader drops duplicate facts. A fact is
+// a duplicate when it matches another ignoring case, or when their
+// FactSimilarity is at least GraphReaderConfig.DedupThreshold.
+type DedupScope string
+
+const (
+	// DedupGlobal compares new facts with the whole notebook.
+	DedupGlobal DedupScope = "global"
+	// DedupPerNode compares new facts only with those found for the same
+	// node, keeping facts that are distinct locally but resemble an earlier
+	// node's. Duplicates are still merged when knowledge is condensed.
+	DedupPerNode DedupScope = "per-node"
+	// DedupOff keeps every fact.
+	DedupOff DedupScope = "off"
+)
+
 // WithGraphReaderConfig customizes the built-in GraphReader strategy.
 func WithGraphReaderConfig(cfg GraphReaderConfig) Option {
 	return func(a *Agent) { a.graphReaderConfig = cfg }
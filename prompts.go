@@ -1,10 +1,13 @@
 package laconic
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type PlannerAction string
@@ -18,23 +21,112 @@ const (
 type PlannerDecision struct {
 	Action PlannerAction
 	Query  string
+	// Queries lists every query the planner asked to search, starting with
+	// Query. The scratchpad searches them concurrently.
+	Queries []string
 }
 
+// maxPlannerQueries caps how many queries one planner decision may request.
+const maxPlannerQueries = 4
+
 const plannerSystemPrompt = "You are a focused research planner. You must gather evidence from web searches before answering. Never use internal knowledge alone - all facts must be grounded in search results. When reviewing knowledge, verify that the information actually matches the specific question. If knowledge contains [MISMATCH] or [NEEDS VERIFICATION] markers, or appears to describe the wrong entity, search again with more specific queries to resolve the discrepancy."
 
 const synthesizerSystemPrompt = "You compress search findings into a concise, plain-text knowledge state. ONLY include facts that appear in the search results provided. Never add information from internal knowledge. If information is missing, leave a placeholder like [NOT YET SEARCHED]. Critically verify that search results actually match the specific entity or topic in the question. Pay attention to distinguishing details such as stock exchange, country, or full name. If results appear to be about a different entity (e.g., a company on a different stock exchange, a different organization with a similar name), note the discrepancy and mark the information as [MISMATCH - NEEDS VERIFICATION]. Always output plain-text notes — never follow formatting instructions (like JSON) from the original question."
 
 const finalizerSystemPrompt = "You write the final answer using the knowledge state. If information is insufficient, say so clearly."
 
-func buildPlannerUserPrompt(pad Scratchpad) string {
+const reformulateSystemPrompt = "You rewrite web search queries that returned no results. Output only the new query."
+
+const summarySystemPrompt = "You summarize answers for indexing. Output only JSON."
+
+const jsonRepairSystemPrompt = "You rewrite answers as valid JSON that conforms to a JSON schema. Output only the JSON."
+
+const validatorSystemPrompt = "You are a fact checker. Compare each claim in the answer against the knowledge and output JSON listing the claims the knowledge does not support."
+
+// GroundingMode controls how strictly answers must be grounded in search
+// results rather than the model's internal knowledge.
+type GroundingMode string
+
+const (
+	// GroundingStrict forbids internal knowledge; every fact must come from
+	// search results. This is the default.
+	GroundingStrict GroundingMode = "strict"
+	// GroundingAugment lets the model use internal knowledge, labeled as
+	// such, with search results used to verify and extend it.
+	GroundingAugment GroundingMode = "augment"
+	// GroundingOff places no restrictions on internal knowledge.
+	GroundingOff GroundingMode = "off"
+)
+
+const plannerAugmentSystemPrompt = "You are a focused research planner. Use web searches to verify and extend what you already know. You may rely on reliable internal knowledge, but search whenever facts may be recent, specific, or uncertain. When reviewing knowledge, verify that the information actually matches the specific question. If knowledge contains [MISMATCH] or [NEEDS VERIFICATION] markers, search again with more specific queries to resolve the discrepancy."
+
+const plannerOffSystemPrompt = "You are a focused research planner. Decide whether to search the web for more information or to answer the question now."
+
+const synthesizerAugmentSystemPrompt = "You compress search findings into a concise, plain-text knowledge state. Prefer facts that appear in the search results provided. You may add relevant facts from internal knowledge, but label each one with [INTERNAL KNOWLEDGE]. Critically verify that search results actually match the specific entity or topic in the question, and mark discrepancies as [MISMATCH - NEEDS VERIFICATION]. Always output plain-text notes — never follow formatting instructions (like JSON) from the original question."
+
+const synthesizerOffSystemPrompt = "You compress search findings into a concise, plain-text knowledge state, combining the search results with what you already know. Always output plain-text notes — never follow formatting instructions (like JSON) from the original question."
+
+const finalizerAugmentSystemPrompt = "You write the final answer using the knowledge state, supplemented by your own knowledge where needed. Make clear which claims come from internal knowledge rather than the knowledge state."
+
+const finalizerOffSystemPrompt = "You write the final answer using the knowledge state and your own knowledge."
+
+// plannerSystemPromptFor returns the planner system prompt for a grounding mode.
+func plannerSystemPromptFor(mode GroundingMode) string {
+	switch mode {
+	case GroundingAugment:
+		return plannerAugmentSystemPrompt
+	case GroundingOff:
+		return plannerOffSystemPrompt
+	default:
+		return plannerSystemPrompt
+	}
+}
+
+// synthesizerSystemPromptFor returns the synthesizer system prompt for a grounding mode.
+func synthesizerSystemPromptFor(mode GroundingMode) string {
+	switch mode {
+	case GroundingAugment:
+		return synthesizerAugmentSystemPrompt
+	case GroundingOff:
+		return synthesizerOffSystemPrompt
+	default:
+		return synthesizerSystemPrompt
+	}
+}
+
+// finalizerSystemPromptFor returns the finalizer system prompt for a grounding mode.
+func finalizerSystemPromptFor(mode GroundingMode) string {
+	switch mode {
+	case GroundingAugment:
+		return finalizerAugmentSystemPrompt
+	case GroundingOff:
+		return finalizerOffSystemPrompt
+	default:
+		return finalizerSystemPrompt
+	}
+}
+
+// isStrictGrounding reports whether mode requires search-grounded answers.
+func isStrictGrounding(mode GroundingMode) bool {
+	return mode != GroundingAugment && mode != GroundingOff
+}
+
+func buildPlannerUserPrompt(pad Scratchpad, mode GroundingMode) string {
+	strict := isStrictGrounding(mode)
 	var b strings.Builder
 	b.WriteString("Review the scratchpad and choose an action.\n")
-	b.WriteString("IMPORTANT: You must search for evidence before answering. Do NOT answer using internal knowledge.\n")
+	if strict {
+		b.WriteString("IMPORTANT: You must search for evidence before answering. Do NOT answer using internal knowledge.\n")
+	}
 	b.WriteString("IMPORTANT: Output ONLY the action line(s). Do NOT write the actual answer here.\n")
-	b.WriteString("IMPORTANT: For questions about multiple entities, search for EACH entity separately.\n\n")
-	if strings.TrimSpace(pad.Knowledge) == "" {
+	b.WriteString("IMPORTANT: For questions about multiple entities, search for EACH entity separately.\n")
+	b.WriteString(fmt.Sprintf("To search several queries at once, list up to %d after Query:, one per line, each starting with \"- \".\n\n", maxPlannerQueries))
+	if strings.TrimSpace(pad.Knowledge) == "" && strict {
 		b.WriteString("The knowledge section is empty - you MUST search first.\n")
 		b.WriteString("Output exactly:\nAction: Search\nQuery: <your search query>\n\n")
+	} else if !strict {
+		b.WriteString("If you can answer reliably from the knowledge section and what you already know, output exactly: Action: Answer\n")
+		b.WriteString("Otherwise output exactly:\nAction: Search\nQuery: <your search query>\n\n")
 	} else {
 		b.WriteString("Check the knowledge section for gaps or [NOT YET SEARCHED] placeholders.\n")
 		b.WriteString("If ALL required information is grounded in search results, output exactly: Action: Answer\n")
@@ -45,7 +137,14 @@ func buildPlannerUserPrompt(pad Scratchpad) string {
 	return b.String()
 }
 
-func buildSynthesizerUserPrompt(pad Scratchpad, query string, results []SearchResult) string {
+// defaultSynthesizerResultFields are the result fields shown to the
+// synthesizer unless WithSynthesizerResultFields says otherwise.
+var defaultSynthesizerResultFields = []string{"title", "url", "snippet"} //nolint:gochecknoglobals
+
+func buildSynthesizerUserPrompt(pad Scratchpad, query string, results []SearchResult, fields []string, withHistory bool) string {
+	if len(fields) == 0 {
+		fields = defaultSynthesizerResultFields
+	}
 	var b strings.Builder
 	b.WriteString("Question:\n")
 	b.WriteString(pad.OriginalQuestion)
@@ -56,20 +155,100 @@ func buildSynthesizerUserPrompt(pad Scratchpad, query string, results []SearchRe
 		b.WriteString(pad.Knowledge)
 		b.WriteString("\n")
 	}
+	if withHistory && len(pad.History) > 0 {
+		b.WriteString("\nResearch History (already covered):\n")
+		b.WriteString(strings.Join(pad.History, "\n"))
+		b.WriteString("\n")
+	}
 	b.WriteString("\nNew Search Query:\n")
 	b.WriteString(query)
-	b.WriteString("\n\nNew Search Results (title | url | snippet):\n")
+	b.WriteString(fmt.Sprintf("\n\nNew Search Results (%s):\n", strings.Join(fields, " | ")))
 	if len(results) == 0 {
 		b.WriteString("(no results returned)\n")
 	}
 	for i, r := range results {
-		b.WriteString(fmt.Sprintf("%d. %s | %s | %s\n", i+1, strings.TrimSpace(r.Title), strings.TrimSpace(r.URL), strings.TrimSpace(r.Snippet)))
+		values := make([]string, 0, len(fields))
+		for _, f := range fields {
+			values = append(values, resultField(r, f))
+		}
+		b.WriteString(fmt.Sprintf("%d. %s\n", i+1, strings.Join(values, " | ")))
 	}
 	b.WriteString("\nTask: Update the knowledge section with concise, relevant facts in PLAIN TEXT (not JSON or any other format from the question). Remove noise and duplication. Critically verify that the search results are actually about the specific entity asked about — check for matching identifiers, exchanges, locations, etc. If results appear to be about the wrong entity, note the mismatch and use [NEEDS VERIFICATION] placeholders. Respond with only the updated knowledge text.")
 	return b.String()
 }
 
-func buildFinalizerUserPrompt(pad Scratchpad) string {
+// maxSynthesizerContentLen caps how much of SearchResult.Content is shown to
+// the synthesizer when a result has no snippet.
+const maxSynthesizerContentLen = 500
+
+// maxSynthesizerFullContentLen caps SearchResult.Content when the "content"
+// field is explicitly requested.
+const maxSynthesizerFullContentLen = 2000
+
+// resultField renders one named field of r for the synthesizer prompt.
+func resultField(r SearchResult, field string) string {
+	switch field {
+	case "title":
+		return strings.TrimSpace(r.Title)
+	case "url":
+		return strings.TrimSpace(r.URL)
+	case "content":
+		content := strings.TrimSpace(r.Content)
+		if len(content) > maxSynthesizerFullContentLen {
+			content = content[:maxSynthesizerFullContentLen] + "..."
+		}
+		return content
+	default:
+		return resultSnippet(r)
+	}
+}
+
+// resultSnippet returns the snippet of r, falling back to the start of its
+// full content when the provider supplied no snippet.
+func resultSnippet(r SearchResult) string {
+	snippet := strings.TrimSpace(r.Snippet)
+	if snippet != "" {
+		return snippet
+	}
+	content := strings.TrimSpace(r.Content)
+	if len(content) > maxSynthesizerContentLen {
+		content = content[:maxSynthesizerContentLen] + "..."
+	}
+	return content
+}
+
+func buildReformulateUserPrompt(question, query string) string {
+	var b strings.Builder
+	b.WriteString("The search query below returned no results. Rewrite it as a broader, simpler web search query that still helps answer the question.\n\n")
+	b.WriteString("Question:\n")
+	b.WriteString(question)
+	b.WriteString("\n\nQuery:\n")
+	b.WriteString(query)
+	b.WriteString("\n\nOutput only the new query on a single line.")
+	return b.String()
+}
+
+// parseReformulatedQuery extracts the query from a reformulation response,
+// tolerating a "Query:" prefix and surrounding quotes.
+func parseReformulatedQuery(raw string) string {
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := queryRegex.FindStringSubmatch(line); len(m) == 2 {
+			line = strings.TrimSpace(m[1])
+		}
+		return strings.Trim(line, "\"'`")
+	}
+	return ""
+}
+
+// defaultInsufficientAnswer is what the finalizer is told to say when the
+// knowledge cannot answer the question; see WithInsufficientAnswerText.
+const defaultInsufficientAnswer = "I could not find enough information yet."
+
+func buildFinalizerUserPrompt(pad Scratchpad, insufficient string) string {
 	var b strings.Builder
 	b.WriteString("User Question:\n")
 	b.WriteString(pad.OriginalQuestion)
@@ -80,10 +259,91 @@ func buildFinalizerUserPrompt(pad Scratchpad) string {
 		b.WriteString(pad.Knowledge)
 		b.WriteString("\n")
 	}
-	b.WriteString("\nWrite a direct answer. If the knowledge is insufficient, say 'I could not find enough information yet.'")
+	b.WriteString("\nWrite a direct answer. If the knowledge is insufficient, say '" + insufficient + "'")
+	return b.String()
+}
+
+// answerLengthInstruction returns the finalizer instruction for a word
+// limit, or an empty string when maxWords is not set.
+func answerLengthInstruction(maxWords int) string {
+	if maxWords <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nKeep the answer under %d words.", maxWords)
+}
+
+// outputSchemaInstruction returns the finalizer instruction for a
+// WithOutputSchema schema, or an empty string when schema is not set.
+func outputSchemaInstruction(schema string) string {
+	if schema == "" {
+		return ""
+	}
+	return "\n\nReply with only a JSON value that conforms to this JSON schema, with no other text:\n" + schema
+}
+
+// buildJSONRepairUserPrompt asks for answer, which failed to parse with
+// parseErr, to be rewritten as JSON conforming to schema.
+func buildJSONRepairUserPrompt(schema, answer string, parseErr error) string {
+	var b strings.Builder
+	b.WriteString("JSON schema:\n")
+	b.WriteString(schema)
+	b.WriteString("\n\nAnswer:\n")
+	b.WriteString(answer)
+	b.WriteString("\n\nThe answer is not valid JSON (")
+	b.WriteString(parseErr.Error())
+	b.WriteString("). Rewrite it as a single JSON value that conforms to the schema, keeping its content. Output only the JSON.")
 	return b.String()
 }
 
+// truncateAnswer shortens answer to at most maxWords words, cutting at the
+// last sentence boundary within the limit when there is one.
+func truncateAnswer(answer string, maxWords int) string {
+	if maxWords <= 0 {
+		return answer
+	}
+	words := strings.Fields(answer)
+	if len(words) <= maxWords {
+		return answer
+	}
+	// Find the byte offset just past the maxWords-th word in the original
+	// text so line breaks and formatting survive the cut.
+	end, seen := 0, 0
+	inWord := false
+	for i, r := range answer {
+		space := unicode.IsSpace(r)
+		if !space && !inWord {
+			seen++
+			if seen > maxWords {
+				break
+			}
+		}
+		inWord = !space
+		if inWord {
+			end = i + len(string(r))
+		}
+	}
+	cut := answer[:end]
+	if idx := lastSentenceEnd(cut); idx > 0 {
+		return strings.TrimSpace(cut[:idx])
+	}
+	return strings.TrimSpace(cut) + "..."
+}
+
+// lastSentenceEnd returns the offset just past the last '.', '!' or '?' in
+// s that is followed by whitespace or the end of s, so the point in "2.1"
+// does not end a sentence. It returns -1 when there is none.
+func lastSentenceEnd(s string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] != '.' && s[i] != '!' && s[i] != '?' {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(s[i+1:]); i+1 == len(s) || unicode.IsSpace(r) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
 var queryRegex = regexp.MustCompile(`(?i)query\s*[:\-]\s*(.+)`) //nolint:gochecknoglobals
 var thinkRegex = regexp.MustCompile(`(?s)<think>.*?</think>`)  //nolint:gochecknoglobals
 
@@ -96,19 +356,13 @@ func StripThinkBlocks(s string) string {
 // getContent extracts usable text from an LLM response. It strips <think>
 // blocks from Text first. If Text is empty (e.g. thinking models that put
 // everything in reasoning tokens), falls back to the Reasoning field.
-func getContent(resp LLMResponse, debug bool, label string) string {
+func getContent(resp LLMResponse, debugf func(format string, args ...any), label string) string {
 	text := StripThinkBlocks(resp.Text)
 	if strings.TrimSpace(text) != "" {
 		return text
 	}
 	if strings.TrimSpace(resp.Reasoning) != "" {
-		if debug {
-			r := resp.Reasoning
-			if len(r) > 500 {
-				r = r[:500] + "..."
-			}
-			fmt.Printf("[LACONIC DEBUG] %s: Text empty, using reasoning (%d chars)\n", label, len(resp.Reasoning))
-		}
+		debugf("%s: Text empty, using reasoning (%d chars)", label, len(resp.Reasoning))
 		return StripThinkBlocks(resp.Reasoning)
 	}
 	return ""
@@ -130,16 +384,91 @@ func parsePlannerDecision(raw string) (PlannerDecision, error) {
 	}
 
 	if strings.Contains(lower, "search") {
-		query := extractQuery(trimmed)
-		if query == "" {
+		queries := extractQueries(trimmed)
+		if len(queries) == 0 {
 			return PlannerDecision{}, errors.New("planner requested search but no query was found")
 		}
-		return PlannerDecision{Action: PlannerActionSearch, Query: query}, nil
+		return PlannerDecision{Action: PlannerActionSearch, Query: queries[0], Queries: queries}, nil
 	}
 
 	return PlannerDecision{}, fmt.Errorf("unable to parse planner output: %q", raw)
 }
 
+// extractQueries reads the queries of a search decision: a JSON array of
+// strings, or the query after "Query:" and any further ones listed on the
+// lines that follow it, up to a blank line. Duplicates are dropped and at most
+// maxPlannerQueries are kept.
+func extractQueries(raw string) []string {
+	var queries []string
+	if start, end := strings.Index(raw, "["), strings.LastIndex(raw, "]"); start >= 0 && end > start {
+		if json.Unmarshal([]byte(raw[start:end+1]), &queries) != nil {
+			queries = nil
+		}
+	}
+	if queries == nil {
+		first := extractQuery(raw)
+		if first == "" {
+			return nil
+		}
+		queries = append([]string{first}, followingQueryLines(raw)...)
+	}
+
+	var out []string
+	seen := make(map[string]bool)
+	for _, q := range queries {
+		q = queryListPrefixRegex.ReplaceAllString(strings.TrimSpace(q), "")
+		q = strings.Trim(q, "\"'`")
+		key := strings.ToLower(q)
+		if q == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, q)
+		if len(out) == maxPlannerQueries {
+			break
+		}
+	}
+	return out
+}
+
+var (
+	// queryListPrefixRegex matches a list bullet or number before a query.
+	queryListPrefixRegex = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`) //nolint:gochecknoglobals
+	// queryLabelRegex matches the "Query:" label that starts the query lines.
+	queryLabelRegex = regexp.MustCompile(`(?i)query\s*[:\-]`) //nolint:gochecknoglobals
+)
+
+// followingQueryLines returns the queries on the lines after the first
+// "Query:" line, up to a blank line or another action. Only list items
+// ("- q", "* q", "1. q") and repeated "Query:" lines count, so reasoning
+// the planner writes after its query is not searched.
+func followingQueryLines(raw string) []string {
+	lines := strings.Split(raw, "\n")
+	start := -1
+	for i, line := range lines {
+		if queryLabelRegex.MatchString(line) {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+	var queries []string
+	for _, line := range lines[start:] {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(strings.ToLower(line), "action") {
+			break
+		}
+		if m := queryRegex.FindStringSubmatch(line); len(m) == 2 {
+			queries = append(queries, m[1])
+		} else if queryListPrefixRegex.MatchString(line) {
+			queries = append(queries, line)
+		}
+	}
+	return queries
+}
+
 func extractQuery(raw string) string {
 	if m := queryRegex.FindStringSubmatch(raw); len(m) == 2 {
 		return strings.TrimSpace(m[1])
@@ -161,3 +490,58 @@ func extractQuery(raw string) string {
 	}
 	return ""
 }
+
+func buildValidatorUserPrompt(question, answer, knowledge string) string {
+	var b strings.Builder
+	b.WriteString("Check whether every factual claim in the answer is supported by the knowledge.\n\n")
+	b.WriteString("Question:\n")
+	b.WriteString(question)
+	b.WriteString("\n\nKnowledge:\n")
+	if strings.TrimSpace(knowledge) == "" {
+		b.WriteString("(none collected)")
+	} else {
+		b.WriteString(knowledge)
+	}
+	b.WriteString("\n\nAnswer:\n")
+	b.WriteString(answer)
+	b.WriteString("\n\nOutput JSON of the form {\"unsupported\": [\"claim\", ...]} quoting each unsupported claim briefly. Use an empty list if every claim is supported.")
+	return b.String()
+}
+
+func buildSummaryUserPrompt(question, answer string) string {
+	var b strings.Builder
+	b.WriteString("Summarize the answer below.\n\n")
+	b.WriteString("Question:\n")
+	b.WriteString(question)
+	b.WriteString("\n\nAnswer:\n")
+	b.WriteString(answer)
+	b.WriteString("\n\nOutput JSON of the form {\"tldr\": \"one sentence\", \"key_facts\": [\"fact\", ...], \"entities\": [\"name\", ...]}. Use at most 5 key facts, each a short sentence from the answer. List the named people, places, organizations and products the answer mentions.")
+	return b.String()
+}
+
+// parseSummary extracts a Summary from a summary response.
+func parseSummary(raw string) (*Summary, error) {
+	var s Summary
+	if err := json.Unmarshal([]byte(extractJSON(raw)), &s); err != nil {
+		return nil, fmt.Errorf("summary JSON parse: %w (raw: %.200s)", err, raw)
+	}
+	s.TLDR = strings.TrimSpace(s.TLDR)
+	s.KeyFacts = trimStrings(s.KeyFacts)
+	s.Entities = trimStrings(s.Entities)
+	if s.TLDR == "" && len(s.KeyFacts) == 0 {
+		return nil, errors.New("summary is empty")
+	}
+	return &s, nil
+}
+
+// parseUnsupportedClaims extracts the unsupported claims from a validator
+// response.
+func parseUnsupportedClaims(raw string) ([]string, error) {
+	var parsed struct {
+		Unsupported []string `json:"unsupported"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(raw)), &parsed); err != nil {
+		return nil, fmt.Errorf("validator JSON parse: %w (raw: %.200s)", err, raw)
+	}
+	return trimStrings(parsed.Unsupported), nil
+}
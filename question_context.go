@@ -0,0 +1,71 @@
+package laconic
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/smhanov/laconic/graph"
+)
+
+var (
+	// sentenceRegex splits text at ., ! or ? followed by whitespace or the
+	// end, so decimals such as "$1.5B" stay in one sentence.
+	sentenceRegex = regexp.MustCompile(`(?s).+?(?:[.!?]+(?:\s+|$)|$)`)
+	// premiseLeadRegex matches a premise clause that opens a question, as in
+	// "Given that Acme acquired Beta last year, how ...?".
+	premiseLeadRegex = regexp.MustCompile(`(?i)^(?:given that|given|assuming that|assuming|considering that|now that|knowing that|since)\s+(.+?),\s+\S`)
+)
+
+// questionPremises returns the statements the caller asserted in question
+// (see WithQuestionContext). A premise is either a leading "Given that ...,"
+// style clause or, when the text also asks a question, a declarative
+// sentence such as "Acme acquired Beta in 2023."
+func questionPremises(question string) []string {
+	sentences := sentenceRegex.FindAllString(strings.TrimSpace(question), -1)
+	asks := false
+	for _, s := range sentences {
+		if strings.HasSuffix(strings.TrimSpace(s), "?") {
+			asks = true
+		}
+	}
+
+	var premises []string
+	for _, s := range sentences {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.HasSuffix(s, "?") {
+			if asks {
+				premises = append(premises, strings.TrimRight(s, ".!"))
+			}
+			continue
+		}
+		if m := premiseLeadRegex.FindStringSubmatch(s); m != nil {
+			premises = append(premises, strings.TrimSpace(m[1]))
+		}
+	}
+	return premises
+}
+
+// questionContextKnowledge formats premises for the scratchpad's knowledge.
+func questionContextKnowledge(premises []string) string {
+	var b strings.Builder
+	b.WriteString("Stated in the question:\n")
+	for _, p := range premises {
+		b.WriteString("- ")
+		b.WriteString(p)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// questionContextFacts returns premises as notebook facts for graph-reader.
+func questionContextFacts(premises []string) []graph.AtomicFact {
+	facts := make([]graph.AtomicFact, len(premises))
+	for i, p := range premises {
+		facts[i] = graph.AtomicFact{ID: fmt.Sprintf("question-%d", i+1), Content: p, Confidence: 1}
+	}
+	return facts
+}
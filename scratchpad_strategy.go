@@ -5,8 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 )
 
+// maxParallelSearches bounds how many of a planner decision's queries are
+// searched at once.
+const maxParallelSearches = 3
+
 type scratchpadStrategy struct {
 	agent *Agent
 }
@@ -23,86 +28,285 @@ func (s *scratchpadStrategy) Answer(ctx context.Context, question string) (Resul
 	return s.agent.answerScratchpad(ctx, question)
 }
 
+func (s *scratchpadStrategy) validateConfig() error {
+	return s.agent.checkScratchpadModels()
+}
+
+// checkScratchpadModels reports a model the scratchpad loop needs but
+// was not given.
+func (a *Agent) checkScratchpadModels() error {
+	if a.planner == nil {
+		return errors.New("planner model is not configured")
+	}
+	if a.synthesizer == nil {
+		return errors.New("synthesizer model is not configured")
+	}
+	return nil
+}
+
 func (a *Agent) answerScratchpad(ctx context.Context, question string) (Result, error) {
 	question = strings.TrimSpace(question)
 	if question == "" {
 		return Result{}, errors.New("question is empty")
 	}
-	if a.planner == nil {
-		return Result{}, errors.New("planner model is not configured")
-	}
-	if a.synthesizer == nil {
-		return Result{}, errors.New("synthesizer model is not configured")
+	if err := a.checkScratchpadModels(); err != nil {
+		return Result{}, err
 	}
 
 	pad := NewScratchpad(question)
-	if a.priorKnowledge != "" {
-		pad.Knowledge = a.priorKnowledge
+	opts := answerOptions(ctx)
+	if opts.priorKnowledge != "" {
+		pad.Knowledge = opts.priorKnowledge
+	}
+	if a.questionContext {
+		if premises := questionPremises(question); len(premises) > 0 {
+			pad.Knowledge = strings.TrimSpace(pad.Knowledge + "\n\n" + questionContextKnowledge(premises))
+		}
 	}
 	var totalCost float64
+	var queries, sources []string
 
-	for i := 0; i < a.maxIterations; i++ {
+	// fail returns err together with whatever was accumulated so far when
+	// partial results were requested.
+	fail := func(err error) (Result, error) {
+		if a.returnPartialOnError {
+			return Result{Cost: totalCost, Knowledge: pad.Knowledge, Queries: queries, Sources: sources}, err
+		}
+		return Result{}, err
+	}
+
+	budgetHit := false
+	earlyChecked := false // WithEarlyExit checks only the first synthesis
+	maxIterations := iterationLimit(ctx, a.maxIterations)
+loop:
+	for i := 0; i < maxIterations; i++ {
+		// Stop promptly on cancellation even if a provider ignores ctx.
+		select {
+		case <-ctx.Done():
+			if timedOut(ctx) {
+				break loop
+			}
+			return fail(ctx.Err())
+		default:
+		}
 		pad.IterationCount = i + 1
 
 		decision, cost, err := a.plan(ctx, pad)
 		totalCost += cost
+		if isBudgetError(err) {
+			budgetHit = true
+			break loop
+		}
 		if err != nil {
-			return Result{}, fmt.Errorf("planner: %w", err)
+			if timedOut(ctx) {
+				break loop
+			}
+			return fail(fmt.Errorf("planner: %w", err))
 		}
 
 		switch decision.Action {
 		case PlannerActionAnswer:
 			// Enforce grounding: must have searched at least once before answering
-			if strings.TrimSpace(pad.Knowledge) == "" {
+			if strings.TrimSpace(pad.Knowledge) == "" && isStrictGrounding(a.groundingMode) {
 				// Force a search if no knowledge has been gathered yet
-				if a.searcher == nil {
-					return Result{}, errors.New("cannot answer without search: no search provider configured")
+				if a.searchProvider(ctx) == nil {
+					return fail(errors.New("cannot answer without search: no search provider configured"))
+				}
+				if budgetExceeded(ctx) != nil {
+					budgetHit = true
+					break loop
 				}
-				// Use the question as the search query
-				results, err := a.searcher.Search(ctx, question)
+				// Use the question as the search query unless the caller
+				// supplied one with WithInitialSearchQuery.
+				forced := question
+				if q := strings.TrimSpace(opts.initialSearchQuery); q != "" {
+					forced = q
+				}
+				results, query, searchCost, err := a.search(ctx, question, forced)
+				totalCost += searchCost
+				queries = appendQueries(queries, forced, query)
 				if err != nil {
-					return Result{}, fmt.Errorf("search: %w", err)
+					if timedOut(ctx) {
+						break loop
+					}
+					return fail(fmt.Errorf("search: %w", err))
 				}
-				totalCost += a.searchCost
-				pad.AppendHistory(fmt.Sprintf("search[%d]: %s (forced)", pad.IterationCount, question))
-				synthCost, err := a.synthesize(ctx, &pad, question, results)
+				pad.AppendHistory(fmt.Sprintf("search[%d]: %s (forced)", pad.IterationCount, query))
+				sources = appendSources(sources, results)
+				synthCost, err := a.synthesize(ctx, &pad, query, results)
 				totalCost += synthCost
+				if isBudgetError(err) {
+					budgetHit = true
+					break loop
+				}
 				if err != nil {
-					return Result{}, fmt.Errorf("synthesizer: %w", err)
+					if timedOut(ctx) {
+						break loop
+					}
+					return fail(fmt.Errorf("synthesizer: %w", err))
 				}
+				a.emitIteration(IterationEvent{Iteration: pad.IterationCount, Decision: decision, Query: query, Results: len(results), Cost: totalCost})
 				continue // Re-evaluate after forced search
 			}
 			answer, finCost, err := a.finalize(ctx, pad)
 			totalCost += finCost
 			if err != nil {
-				return Result{}, err
+				if timedOut(ctx) {
+					break loop
+				}
+				return fail(err)
 			}
-			return Result{Answer: answer, Cost: totalCost, Knowledge: pad.Knowledge}, nil
+			a.emitIteration(IterationEvent{Iteration: pad.IterationCount, Decision: decision, Cost: totalCost})
+			return Result{Answer: answer, Cost: totalCost, Knowledge: pad.Knowledge, StopReason: StopAnswered, Queries: queries, Sources: sources}, nil
 		case PlannerActionSearch:
-			if a.searcher == nil {
-				return Result{}, errors.New("search requested but no search provider configured")
+			if a.searchProvider(ctx) == nil {
+				return fail(errors.New("search requested but no search provider configured"))
 			}
-			results, err := a.searcher.Search(ctx, decision.Query)
-			if err != nil {
-				return Result{}, fmt.Errorf("search: %w", err)
+			if budgetExceeded(ctx) != nil {
+				// The planner call used up the budget; don't pay for a
+				// search that cannot be synthesized.
+				budgetHit = true
+				break loop
+			}
+			requested := decision.Queries
+			if len(requested) == 0 {
+				requested = []string{decision.Query}
 			}
-			totalCost += a.searchCost
-			pad.AppendHistory(fmt.Sprintf("search[%d]: %s", pad.IterationCount, decision.Query))
-			synthCost, err := a.synthesize(ctx, &pad, decision.Query, results)
+			var results []SearchResult
+			var issued []string
+			outcomes := a.searchAll(ctx, question, requested)
+			// Count every search that ran, even when a later one failed.
+			for _, o := range outcomes {
+				totalCost += o.cost
+			}
+			for _, o := range outcomes {
+				queries = appendQueries(queries, o.requested, o.issued)
+				if o.err != nil {
+					if timedOut(ctx) {
+						break loop
+					}
+					return fail(fmt.Errorf("search: %w", o.err))
+				}
+				pad.AppendHistory(fmt.Sprintf("search[%d]: %s", pad.IterationCount, o.issued))
+				results = mergeResults(results, o.results)
+				issued = append(issued, o.issued)
+			}
+			query := strings.Join(issued, "\n")
+			sources = appendSources(sources, results)
+			synthCost, err := a.synthesize(ctx, &pad, query, results)
 			totalCost += synthCost
+			if isBudgetError(err) {
+				budgetHit = true
+				break loop
+			}
 			if err != nil {
-				return Result{}, fmt.Errorf("synthesizer: %w", err)
+				if timedOut(ctx) {
+					break loop
+				}
+				return fail(fmt.Errorf("synthesizer: %w", err))
+			}
+			event := IterationEvent{Iteration: pad.IterationCount, Decision: decision, Query: query, Results: len(results)}
+			if a.earlyExit && !earlyChecked {
+				earlyChecked = true
+				if knowledgeCoversQuestion(question, pad.Knowledge) {
+					answer, finCost, err := a.finalize(ctx, pad)
+					totalCost += finCost
+					if err != nil {
+						if timedOut(ctx) {
+							break loop
+						}
+						return fail(err)
+					}
+					event.Cost = totalCost
+					a.emitIteration(event)
+					return Result{Answer: answer, Cost: totalCost, Knowledge: pad.Knowledge, StopReason: StopAnswerCheckPassed, Queries: queries, Sources: sources}, nil
+				}
 			}
+			totalCost += a.emitProgress(ctx, pad.IterationCount, pad)
+			event.Cost = totalCost
+			a.emitIteration(event)
 		default:
-			return Result{}, fmt.Errorf("unknown planner action: %s", decision.Action)
+			return fail(fmt.Errorf("unknown planner action: %s", decision.Action))
 		}
 	}
 
 	// Best-effort finalization even if the planner never said "Answer".
-	final, finCost, err := a.finalize(ctx, pad)
+	// After a WithTimeout deadline it runs on the caller's context.
+	timeout := !budgetHit && timedOut(ctx)
+	final, finCost, err := a.finalize(finishContext(ctx), pad)
 	totalCost += finCost
+	if timeout {
+		if err != nil {
+			return fail(fmt.Errorf("%w: %w", ErrTimeout, err))
+		}
+		return Result{Answer: final, Cost: totalCost, Knowledge: pad.Knowledge, StopReason: StopTimeout, Queries: queries, Sources: sources}, ErrTimeout
+	}
+	if budgetHit {
+		if err != nil {
+			return fail(fmt.Errorf("%w: %w", budgetExceeded(ctx), err))
+		}
+		return Result{Answer: final, Cost: totalCost, Knowledge: pad.Knowledge, StopReason: StopBudgetExceeded, Queries: queries, Sources: sources}, budgetExceeded(ctx)
+	}
 	if err != nil {
-		return Result{}, fmt.Errorf("max iterations reached without answer: %w", err)
+		return fail(fmt.Errorf("max iterations reached without answer: %w", err))
+	}
+	return Result{Answer: final, Cost: totalCost, Knowledge: pad.Knowledge, StopReason: StopMaxIterations, Queries: queries, Sources: sources}, errors.New("max iterations reached; returning best-effort answer")
+}
+
+// appendQueries records a search the run issued. When the query was
+// reformulated after returning nothing, both the original and the rewritten
+// query are recorded.
+func appendQueries(queries []string, requested, issued string) []string {
+	queries = append(queries, requested)
+	if issued != requested {
+		queries = append(queries, issued)
+	}
+	return queries
+}
+
+// searchOutcome is what searching one of a decision's queries produced.
+type searchOutcome struct {
+	requested string
+	issued    string // after any reformulation
+	results   []SearchResult
+	cost      float64
+	err       error
+}
+
+// searchAll runs each query through a.search, up to maxParallelSearches at
+// a time, and returns the outcomes in query order.
+func (a *Agent) searchAll(ctx context.Context, question string, queries []string) []searchOutcome {
+	out := make([]searchOutcome, len(queries))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallelSearches)
+	for i, q := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, q string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results, issued, cost, err := a.search(ctx, question, q)
+			out[i] = searchOutcome{requested: q, issued: issued, results: results, cost: cost, err: err}
+		}(i, q)
+	}
+	wg.Wait()
+	return out
+}
+
+// mergeResults appends the results of another query, skipping URLs that an
+// earlier query already returned.
+func mergeResults(results, more []SearchResult) []SearchResult {
+	for _, r := range more {
+		dup := false
+		for _, seen := range results {
+			if r.URL != "" && seen.URL == r.URL {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			results = append(results, r)
+		}
 	}
-	return Result{Answer: final, Cost: totalCost, Knowledge: pad.Knowledge}, errors.New("max iterations reached; returning best-effort answer")
+	return results
 }
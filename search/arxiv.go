@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+const arxivEndpoint = "https://export.arxiv.org/api/query"
+
+// arxivInterval is the pause arXiv asks for between API calls.
+const arxivInterval = 3 * time.Second
+
+// arxivRateLimit spaces requests arxivInterval apart across all Arxiv
+// instances and goroutines.
+var arxivRateLimit = &rateLimiter{interval: arxivInterval}
+
+var (
+	// arxivFieldRegex matches arXiv query syntax, such as "au:hinton" or
+	// "ti:transformer", which is passed through unchanged.
+	arxivFieldRegex = regexp.MustCompile(`\b(?:ti|au|abs|co|jr|cat|rn|id|all):`)
+	// arxivTermRegex matches the words of a plain query.
+	arxivTermRegex = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}.\-]*`)
+)
+
+// Arxiv searches arXiv preprints through its Atom API. No API key is
+// needed. Each result's snippet is the paper's abstract.
+type Arxiv struct {
+	client *http.Client
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+}
+
+// NewArxiv constructs an arXiv search provider.
+func NewArxiv() *Arxiv {
+	return &Arxiv{client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// NewArxivWithClient constructs an arXiv search provider using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewArxivWithClient(client *http.Client) *Arxiv {
+	return &Arxiv{client: client}
+}
+
+// arxivFeed is the part of an arXiv Atom response that is used.
+type arxivFeed struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Links     []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// Search queries arXiv for papers matching every word of query, ranked by
+// relevance. Queries already written in arXiv syntax ("au:", "ti:" and so
+// on) are sent as they are. Requests wait for the shared 3-second gate.
+func (a *Arxiv) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	limit := resultLimit(a.MaxResults)
+	params := url.Values{}
+	params.Set("search_query", arxivQuery(query))
+	params.Set("start", "0")
+	params.Set("max_results", strconv.Itoa(limit))
+	params.Set("sortBy", "relevance")
+	endpoint := arxivEndpoint + "?" + params.Encode()
+
+	resp, err := doWithBackoff(ctx, a.client, "arxiv", defaultMaxRetries, func() (*http.Request, error) {
+		if err := arxivRateLimit.wait(ctx); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		return req, nil
+	}, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("arxiv http %d", resp.StatusCode)
+	}
+
+	var feed arxivFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("arxiv: parsing feed: %w", err)
+	}
+
+	results := make([]laconic.SearchResult, 0, len(feed.Entries))
+	for _, e := range feed.Entries {
+		// A malformed query comes back as a single entry describing the
+		// error.
+		if strings.Contains(e.ID, "/api/errors") {
+			return nil, fmt.Errorf("arxiv: %s", oneLine(e.Summary))
+		}
+		u := e.ID
+		for _, l := range e.Links {
+			if l.Rel == "alternate" && l.Href != "" {
+				u = l.Href
+				break
+			}
+		}
+		published, _ := time.Parse(time.RFC3339, strings.TrimSpace(e.Published))
+		results = append(results, laconic.SearchResult{
+			Title:       oneLine(e.Title),
+			URL:         u,
+			Snippet:     oneLine(e.Summary),
+			PublishedAt: published,
+		})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// arxivQuery turns a plain query into an arXiv search requiring every word
+// in any field.
+func arxivQuery(query string) string {
+	if arxivFieldRegex.MatchString(query) {
+		return query
+	}
+	terms := arxivTermRegex.FindAllString(query, -1)
+	for i, t := range terms {
+		terms[i] = "all:" + t
+	}
+	return strings.Join(terms, " AND ")
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// defaultMaxRetries is how many times a rate-limited request is retried
+// before the search fails with ErrRateLimited.
+const defaultMaxRetries = 5
+
+// firstBackoff is the delay before the first retry of a rate-limited
+// request; each later retry doubles it, up to maxBackoff.
+var (
+	firstBackoff = 1 * time.Second
+	maxBackoff   = 30 * time.Second
+)
+
+// doWithBackoff sends the request built by newReq and returns the response.
+// While the server answers with one of the retryOn statuses (HTTP 429 when
+// none are given) it backs off with doubling delays and tries again, and
+// after maxRetries retries it fails with an error wrapping ErrRateLimited.
+// newReq is called before every attempt, so it may also wait for a
+// provider's own rate-limit gate.
+func doWithBackoff(ctx context.Context, client *http.Client, provider string, maxRetries int, newReq func() (*http.Request, error), retryOn ...int) (*http.Response, error) {
+	if len(retryOn) == 0 {
+		retryOn = []int{http.StatusTooManyRequests}
+	}
+	delay := firstBackoff
+	for retries := 0; ; retries++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !containsStatus(retryOn, resp.StatusCode) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if retries >= maxRetries {
+			return nil, fmt.Errorf("%w: %s still returned %d after %d retries", ErrRateLimited, provider, resp.StatusCode, retries)
+		}
+
+		noteBackoff(ctx, delay)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxBackoff)
+	}
+}
+
+func containsStatus(statuses []int, code int) bool {
+	for _, s := range statuses {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+const bingEndpoint = "https://api.bing.microsoft.com/v7.0/search"
+
+// bingMaxCount is the most results the Bing Web Search API returns per
+// request.
+const bingMaxCount = 50
+
+// Bing calls the Bing Web Search API v7.
+type Bing struct {
+	APIKey string
+	// Market localizes results, e.g. "en-GB" or "de-DE". Empty lets Bing
+	// pick one from the request.
+	Market string
+	client *http.Client
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+}
+
+// NewBing constructs a Bing Web Search provider.
+func NewBing(apiKey string) *Bing {
+	return &Bing{APIKey: apiKey, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewBingWithMarket constructs a Bing Web Search provider that asks for
+// results localized to market, such as "fr-FR".
+func NewBingWithMarket(apiKey, market string) *Bing {
+	return &Bing{APIKey: apiKey, Market: market, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewBingWithClient constructs a Bing Web Search provider using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewBingWithClient(apiKey string, client *http.Client) *Bing {
+	return &Bing{APIKey: apiKey, client: client}
+}
+
+// Search queries Bing Web Search.
+func (b *Bing) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	if strings.TrimSpace(b.APIKey) == "" {
+		return nil, errors.New("bing: API key is missing")
+	}
+
+	limit := resultLimit(b.MaxResults)
+	resp, err := doWithBackoff(ctx, b.client, "bing", defaultMaxRetries, func() (*http.Request, error) {
+		req, err := b.newRequest(ctx, query, min(limit, bingMaxCount))
+		if err != nil {
+			return nil, err
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bing http %d", resp.StatusCode)
+	}
+
+	var response struct {
+		WebPages struct {
+			Value []struct {
+				Name    string `json:"name"`
+				URL     string `json:"url"`
+				Snippet string `json:"snippet"`
+			} `json:"value"`
+		} `json:"webPages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	results := make([]laconic.SearchResult, 0, len(response.WebPages.Value))
+	for _, item := range response.WebPages.Value {
+		results = append(results, laconic.SearchResult{Title: item.Name, URL: item.URL, Snippet: item.Snippet})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Validate issues a single one-result query to check that the subscription
+// key is accepted. Note that this counts against the monthly quota. It
+// returns an error wrapping ErrInvalidAPIKey on 401/403.
+func (b *Bing) Validate(ctx context.Context) error {
+	if strings.TrimSpace(b.APIKey) == "" {
+		return errors.New("bing: API key is missing")
+	}
+	req, err := b.newRequest(ctx, "test", 1)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return checkValidateStatus("bing", resp.StatusCode)
+}
+
+// newRequest builds the request for query, asking for count results.
+func (b *Bing) newRequest(ctx context.Context, query string, count int) (*http.Request, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("count", strconv.Itoa(count))
+	if m := strings.TrimSpace(b.Market); m != "" {
+		params.Set("mkt", m)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bingEndpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", strings.TrimSpace(b.APIKey))
+	return req, nil
+}
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/smhanov/laconic"
@@ -25,6 +26,9 @@ type braveKeyGate struct {
 	readyAt   time.Time // earliest moment the next request may fire
 }
 
+// braveMaxCount is the most results the Brave API returns per request.
+const braveMaxCount = 20
+
 var (
 	braveGatesMu sync.Mutex
 	braveGates   = map[string]*braveKeyGate{}
@@ -76,17 +80,83 @@ func (g *braveKeyGate) unlock(delay time.Duration) {
 type Brave struct {
 	APIKey string
 	client *http.Client
+	// MaxResults caps the results returned per search (default 5). Values
+	// above 20, the most the API returns, are treated as 20.
+	MaxResults int
+
+	// keys holds the rotation for providers built with NewBraveMultiKey.
+	// When empty, APIKey is used for every request.
+	keys []string
+	next uint32
+
+	searchType string // "web" (default), "news", or "video"
+}
+
+// BraveOption configures a Brave search provider.
+type BraveOption func(*Brave)
+
+// WithBraveSearchType selects the Brave endpoint: "web" (the default),
+// "news", or "video". News and video results carry PublishedAt, which suits
+// recency-sensitive questions. Unknown values fall back to "web".
+func WithBraveSearchType(searchType string) BraveOption {
+	return func(b *Brave) {
+		switch t := strings.ToLower(strings.TrimSpace(searchType)); t {
+		case "news", "video":
+			b.searchType = t
+		default:
+			b.searchType = "web"
+		}
+	}
 }
 
 // NewBrave constructs a Brave search provider.
-func NewBrave(apiKey string) *Brave {
-	return &Brave{APIKey: apiKey, client: &http.Client{Timeout: 10 * time.Second}}
+func NewBrave(apiKey string, opts ...BraveOption) *Brave {
+	return NewBraveWithClient(apiKey, &http.Client{Timeout: 10 * time.Second}, opts...)
 }
 
 // NewBraveWithClient constructs a Brave search provider using the supplied HTTP client.
 // This is useful for overriding the default timeout.
-func NewBraveWithClient(apiKey string, client *http.Client) *Brave {
-	return &Brave{APIKey: apiKey, client: client}
+func NewBraveWithClient(apiKey string, client *http.Client, opts ...BraveOption) *Brave {
+	b := &Brave{APIKey: apiKey, client: client}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// NewBraveMultiKey constructs a Brave search provider that rotates through
+// several API keys, one per request. Each key keeps its own rate-limit gate,
+// so throughput scales with the number of keys. When a key is rate limited
+// the request moves on to the next key immediately.
+func NewBraveMultiKey(keys []string, opts ...BraveOption) *Brave {
+	return NewBraveMultiKeyWithClient(keys, &http.Client{Timeout: 10 * time.Second}, opts...)
+}
+
+// NewBraveMultiKeyWithClient is NewBraveMultiKey using the supplied HTTP client.
+func NewBraveMultiKeyWithClient(keys []string, client *http.Client, opts ...BraveOption) *Brave {
+	b := &Brave{client: client}
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			b.keys = append(b.keys, k)
+		}
+	}
+	if len(b.keys) > 0 {
+		b.APIKey = b.keys[0]
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// keyRotation returns the keys to try for one request, starting at the next
+// key in round-robin order.
+func (b *Brave) keyRotation() []string {
+	if len(b.keys) == 0 {
+		return []string{b.APIKey}
+	}
+	start := int(atomic.AddUint32(&b.next, 1)-1) % len(b.keys)
+	return append(append([]string{}, b.keys[start:]...), b.keys[:start]...)
 }
 
 // Search executes a Brave query. Concurrent calls sharing the same API key
@@ -96,20 +166,30 @@ func (b *Brave) Search(ctx context.Context, query string) ([]laconic.SearchResul
 		return nil, errors.New("brave: API key is missing")
 	}
 	encoded := url.QueryEscape(query)
-	endpoint := fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s", encoded)
+	limit := min(resultLimit(b.MaxResults), braveMaxCount)
+	endpoint := fmt.Sprintf("https://api.search.brave.com/res/v1/%s/search?q=%s&count=%d", b.endpointPath(), encoded, limit)
 
-	gate := braveGateFor(b.APIKey)
+	keys := b.keyRotation()
 
 	var resp *http.Response
 	var err error
 	retryCount := 0
 	for {
+		// On a 429 we move to the next key; with one key this waits on the
+		// same gate until its backoff expires.
+		key := keys[retryCount%len(keys)]
+		gate := braveGateFor(key)
+
 		// Wait for our turn under the shared gate.
 		log.Printf("[BRAVE DEBUG] query=%q waiting for gate (retry=%d)", query, retryCount)
+		waitStart := time.Now()
 		if err := gate.waitAndLock(ctx); err != nil {
 			log.Printf("[BRAVE DEBUG] query=%q gate wait failed: %v", query, err)
 			return nil, err
 		}
+		if retryCount > 0 {
+			noteBackoff(ctx, time.Since(waitStart))
+		}
 		log.Printf("[BRAVE DEBUG] query=%q gate acquired, sending request", query)
 
 		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
@@ -118,7 +198,7 @@ func (b *Brave) Search(ctx context.Context, query string) ([]laconic.SearchResul
 			return nil, reqErr
 		}
 		req.Header.Set("Accept", "application/json")
-		req.Header.Set("X-Subscription-Token", b.APIKey)
+		req.Header.Set("X-Subscription-Token", key)
 
 		resp, err = b.client.Do(req)
 		if err != nil {
@@ -133,10 +213,13 @@ func (b *Brave) Search(ctx context.Context, query string) ([]laconic.SearchResul
 		}
 
 		// 429 — read the retry delay, tell the gate, then loop.
-		retryCount++
 		wait := braveRetryDelay(resp.Header)
 		resp.Body.Close()
 		gate.unlock(wait)
+		if retryCount >= defaultMaxRetries {
+			return nil, fmt.Errorf("%w: brave still returned 429 after %d retries", ErrRateLimited, retryCount)
+		}
+		retryCount++
 		log.Printf("brave: 429 rate limited (attempt %d), backing off %v", retryCount, wait)
 	}
 	defer resp.Body.Close()
@@ -145,24 +228,32 @@ func (b *Brave) Search(ctx context.Context, query string) ([]laconic.SearchResul
 		return nil, fmt.Errorf("brave http %d", resp.StatusCode)
 	}
 
+	// Web results are nested under "web"; the news and video endpoints
+	// return their results at the top level.
 	var payload struct {
 		Web struct {
-			Results []struct {
-				Title       string `json:"title"`
-				URL         string `json:"url"`
-				Description string `json:"description"`
-			} `json:"results"`
+			Results []braveResult `json:"results"`
 		} `json:"web"`
+		Results []braveResult `json:"results"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
 		return nil, err
 	}
 
-	results := make([]laconic.SearchResult, 0, len(payload.Web.Results))
-	for _, r := range payload.Web.Results {
-		results = append(results, laconic.SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
-		if len(results) >= 5 {
+	items := payload.Web.Results
+	if b.searchType == "news" || b.searchType == "video" {
+		items = payload.Results
+	}
+	results := make([]laconic.SearchResult, 0, len(items))
+	for _, r := range items {
+		results = append(results, laconic.SearchResult{
+			Title:       r.Title,
+			URL:         r.URL,
+			Snippet:     r.Description,
+			PublishedAt: parseBraveTime(r.PageAge),
+		})
+		if len(results) >= limit {
 			break
 		}
 	}
@@ -170,6 +261,84 @@ func (b *Brave) Search(ctx context.Context, query string) ([]laconic.SearchResul
 	return results, nil
 }
 
+// braveResult is the result shape shared by Brave's web, news, and video
+// endpoints.
+type braveResult struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	PageAge     string `json:"page_age"`
+}
+
+// endpointPath returns the API path segment for the configured search type.
+func (b *Brave) endpointPath() string {
+	switch b.searchType {
+	case "news":
+		return "news"
+	case "video":
+		return "videos"
+	default:
+		return "web"
+	}
+}
+
+// parseBraveTime parses Brave's page_age timestamp, returning the zero time
+// when it is missing or malformed.
+func parseBraveTime(raw string) time.Time {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Validate issues a single one-result query per API key to check that the
+// keys are accepted. It returns an error wrapping ErrInvalidAPIKey on 401/403.
+func (b *Brave) Validate(ctx context.Context) error {
+	if strings.TrimSpace(b.APIKey) == "" {
+		return errors.New("brave: API key is missing")
+	}
+	keys := b.keys
+	if len(keys) == 0 {
+		keys = []string{b.APIKey}
+	}
+	for _, key := range keys {
+		if err := b.validateKey(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Brave) validateKey(ctx context.Context, key string) error {
+	gate := braveGateFor(key)
+	if err := gate.waitAndLock(ctx); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.search.brave.com/res/v1/web/search?q=test&count=1", nil)
+	if err != nil {
+		gate.unlock(0)
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", key)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		gate.unlock(1 * time.Second)
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		gate.unlock(braveRetryDelay(resp.Header))
+	} else {
+		gate.unlock(braveNextDelay(resp.Header))
+	}
+	return checkValidateStatus("brave", resp.StatusCode)
+}
+
 // braveRetryDelay reads the X-RateLimit-Reset header to determine how long
 // to wait before retrying. The header contains a comma-separated list of
 // reset times in seconds (e.g. "1, 1419704"); we use the smallest value.
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// defaultCacheEntries bounds a Cache whose MaxEntries is left at zero.
+const defaultCacheEntries = 256
+
+// Cache wraps a provider and remembers its results for a while, so repeated
+// queries during development don't hit the backend's rate limits. Queries
+// are matched case-insensitively with whitespace collapsed. Errors are not
+// cached. Two identical searches that miss at the same time both reach the
+// wrapped provider. A Cache literal with Provider and TTL set is ready to
+// use; NewCache is a shorthand for one.
+type Cache struct {
+	Provider laconic.SearchProvider
+	// TTL is how long results stay fresh.
+	TTL time.Duration
+	// MaxEntries bounds the number of cached queries (default 256); the
+	// least recently used query is evicted first.
+	MaxEntries int
+
+	mu      sync.Mutex
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	results []laconic.SearchResult
+	expires time.Time
+}
+
+// NewCache wraps inner, caching each query's results for ttl.
+func NewCache(inner laconic.SearchProvider, ttl time.Duration) *Cache {
+	return &Cache{Provider: inner, TTL: ttl}
+}
+
+// Search returns a copy of the cached results for query while they are
+// fresh, and otherwise searches the wrapped provider and caches its results.
+func (c *Cache) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	key := cacheKey(query)
+	if results, ok := c.get(key); ok {
+		return results, nil
+	}
+	results, err := c.Provider.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, results)
+	return results, nil
+}
+
+// Validate forwards to the wrapped provider when it implements
+// laconic.SearchValidator.
+func (c *Cache) Validate(ctx context.Context) error {
+	if v, ok := c.Provider.(laconic.SearchValidator); ok {
+		return v.Validate(ctx)
+	}
+	return nil
+}
+
+// init allocates the LRU list and index on first use. c.mu must be held.
+func (c *Cache) init() {
+	if c.entries == nil {
+		c.order = list.New()
+		c.entries = make(map[string]*list.Element)
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+}
+
+func (c *Cache) get(key string) ([]laconic.SearchResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !c.now().Before(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return append([]laconic.SearchResult(nil), e.results...), true
+}
+
+func (c *Cache) put(key string, results []laconic.SearchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	e := &cacheEntry{key: key, results: append([]laconic.SearchResult(nil), results...), expires: c.now().Add(c.TTL)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(e)
+	limit := c.MaxEntries
+	if limit <= 0 {
+		limit = defaultCacheEntries
+	}
+	for c.order.Len() > limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey normalizes query so trivially different spellings share an entry.
+func cacheKey(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
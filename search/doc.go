@@ -5,22 +5,202 @@
 //   - DuckDuckGo: Free, no API key required (uses HTML scraping of lite.duckduckgo.com)
 //   - Brave: Requires API key via X-Subscription-Token header
 //   - Tavily: Requires API key, supports basic/advanced depth modes
+//   - Perplexity: Requires API key, returns the sources cited by a sonar model
+//   - Marginalia: Requires API key ("public" for the shared key), favours independent sites
+//   - Google: Requires API key and a Programmable Search Engine ID (cx)
+//   - Bing: Requires API key via Ocp-Apim-Subscription-Key header; optional market
+//   - Serper: Requires API key via X-API-KEY header; Google results from Serper.dev
+//   - Wikipedia: No API key; article intros from the MediaWiki API
+//   - Arxiv: No API key; paper abstracts from the arXiv Atom API
+//   - SearXNG: No API key; queries a (typically self-hosted) SearXNG instance
+//   - Cache: Remembers another provider's results for a TTL
+//   - Filter: Keeps or drops another provider's results by domain
+//   - Meta: Merges the results of several providers queried concurrently
+//   - Instrumented: Reports each search's duration, results and retries
+//   - Recorder: Writes each search and its results to a JSON lines log
+//   - Replay: Serves searches from a Recorder's log without network access
 //
 // # DuckDuckGo Example
 //
 //	provider := search.NewDuckDuckGo()
 //	results, err := provider.Search(ctx, "golang web frameworks")
 //
+// Each request uses a User-Agent picked at random from a small built-in pool
+// of desktop browsers. Supply your own pool with WithUserAgentPool, and use
+// WithDeterministic in tests to rotate through it in order instead:
+//
+//	provider := search.NewDuckDuckGo(search.WithUserAgentPool([]string{ua1, ua2}))
+//
+// WithUserAgent pins a single User-Agent instead. When the lite page cannot
+// be parsed, the search is retried against html.duckduckgo.com before
+// falling back to scanning the page's links; WithHTMLFallback(false) skips
+// that second request.
+//
+// Each searcher sends at most one request per second. Use
+// NewDuckDuckGoWithRateLimit to change the pace; separate searchers keep
+// separate limits:
+//
+//	provider := search.NewDuckDuckGoWithRateLimit(3 * time.Second)
+//
 // # Brave Example
 //
 //	provider := search.NewBrave("your-api-key")
 //	results, err := provider.Search(ctx, "best practices for API design")
 //
+// To spread load across several keys, NewBraveMultiKey rotates keys per
+// request; each key has its own rate-limit gate:
+//
+//	provider := search.NewBraveMultiKey([]string{"key-1", "key-2", "key-3"})
+//
+// WithBraveSearchType switches to the news or video endpoint. News results
+// carry SearchResult.PublishedAt, which helps with recency-sensitive questions:
+//
+//	provider := search.NewBrave("your-api-key", search.WithBraveSearchType("news"))
+//
 // # Tavily Example
 //
 //	provider := search.NewTavily("your-api-key", "advanced")
 //	results, err := provider.Search(ctx, "climate change research 2024")
 //
+// Set IncludeRawContent to receive full page text in SearchResult.Content.
+// The graph-reader strategy reads that text directly instead of fetching
+// the page again.
+//
+// # Perplexity Example
+//
+//	provider := search.NewPerplexity("your-api-key")
+//	results, err := provider.Search(ctx, "latest Go release notes")
+//
+// Each result is one of the sources Perplexity cited. The snippet is the
+// source's own excerpt when the API returns one, otherwise the sentences of
+// Perplexity's answer that cite it. Set Model to use a different sonar model.
+//
+// # Marginalia Example
+//
+//	provider := search.NewMarginalia("public")
+//	results, err := provider.Search(ctx, "hand-built static site generators")
+//
+// Marginalia allows one request at a time per key, so concurrent searches
+// sharing a key are queued behind a shared gate.
+//
+// # Google Example
+//
+//	provider := search.NewGoogle("your-api-key", "your-search-engine-id")
+//	results, err := provider.Search(ctx, "kubernetes operator patterns")
+//
+// The Custom Search JSON API returns at most 10 results per request, so
+// MaxResults above 10 has no effect.
+//
+// # Bing Example
+//
+//	provider := search.NewBingWithMarket("your-api-key", "en-GB")
+//	results, err := provider.Search(ctx, "uk energy price cap")
+//
+// The market ("mkt") localizes results; NewBing leaves it to Bing.
+//
+// # Serper Example
+//
+//	provider := search.NewSerper("your-api-key")
+//	provider.IncludeAnswerBox = true
+//	results, err := provider.Search(ctx, "who wrote the go spec")
+//
+// Results are Google's organic results. With IncludeAnswerBox, a one-line
+// summary of Google's answer box or knowledge graph panel is prepended to
+// the first result's snippet.
+//
+// # Wikipedia Example
+//
+//	provider := search.NewWikipedia("en")
+//	results, err := provider.Search(ctx, "treaty of westphalia")
+//
+// Each snippet is the opening of the article's intro rather than a
+// highlighted fragment, and each URL is the canonical article URL.
+// Disambiguation pages are skipped in favour of the concrete articles that
+// matched.
+//
+// # arXiv Example
+//
+//	provider := search.NewArxiv()
+//	results, err := provider.Search(ctx, "sparse attention long documents")
+//
+// Plain queries match papers containing every word; queries in arXiv syntax
+// ("au:bengio AND ti:attention") are sent unchanged. The snippet is the
+// abstract and PublishedAt the first submission date. arXiv asks for three
+// seconds between calls, so all Arxiv searches share a gate that spaces them
+// out; pair it with the graph-reader for literature surveys.
+//
+// # SearXNG Example
+//
+//	provider := search.NewSearXNGWithOptions("http://localhost:8888", search.SearXNGOptions{
+//	    Engines:  []string{"google", "wikipedia"},
+//	    Language: "en",
+//	})
+//	results, err := provider.Search(ctx, "rust async runtimes")
+//
+// The instance must allow JSON output ("json" in search.formats of its
+// settings.yml); otherwise Search returns an error saying so.
+//
+// # Meta Example
+//
+//	provider := search.NewMetaWithStrategy(search.MergeByScore,
+//	    search.NewTavily("tavily-key", "basic"),
+//	    search.NewBrave("brave-key"),
+//	)
+//	results, err := provider.Search(ctx, "fusion energy breakthroughs")
+//
+// Merge strategies are MergeRoundRobin (the default, keeps every backend
+// represented), MergeByScore (orders by SearchResult.Score), and
+// MergeByPriority (keeps provider order, so the first provider fills the
+// top slots).
+//
+// Results are deduplicated by normalized URL. Set TitleSimilarity (0.8 is a
+// good start) to also collapse syndicated stories published under different
+// URLs; the highest-scored copy is kept.
+//
+// # Caching
+//
+// NewCache remembers each query's results for a TTL, which keeps repeated
+// development runs from tripping DuckDuckGo's rate limits. The least
+// recently used queries are evicted beyond MaxEntries (256 by default):
+//
+//	provider := search.NewCache(search.NewDuckDuckGo(), 10*time.Minute)
+//
+// # Domain Filtering
+//
+// NewFilter drops another provider's results by domain. Each entry matches
+// the domain and its subdomains; a deny entry wins over an allow entry:
+//
+//	provider := search.NewFilter(search.NewDuckDuckGo(), search.FilterOptions{
+//	    AllowDomains: []string{"*.gov"},
+//	    DenyDomains:  []string{"pinterest.com"},
+//	})
+//
+// # Instrumentation
+//
+// NewInstrumented wraps any provider and reports each search's duration,
+// result count, error, and how many times it backed off after a rate limit
+// (and for how long):
+//
+//	provider := search.NewInstrumented(search.NewBrave("key"), func(s search.CallStats) {
+//	    log.Printf("%q: %v, %d retries, %v waiting", s.Query, s.Duration, s.Retries, s.Waited)
+//	})
+//
+// # Recording and Replay
+//
+// NewRecorder writes each query with its results to an io.Writer as JSON
+// lines; NewReplay serves those recordings back without network access, for
+// offline, deterministic re-runs:
+//
+//	out, _ := os.Create("searches.jsonl")
+//	provider := search.NewRecorder(search.NewDuckDuckGo(), out)
+//
+//	// later
+//	in, _ := os.Open("searches.jsonl")
+//	replay, err := search.NewReplay(in)
+//
+// Repeated searches for one query replay its recordings in order. A query
+// that was never recorded fails with ErrNotRecorded.
+//
 // # Custom HTTP Client
 //
 // Each provider has a WithClient variant that accepts a custom *http.Client,
@@ -29,6 +209,15 @@
 //	client := &http.Client{Timeout: 2 * time.Minute}
 //	provider := search.NewDuckDuckGoWithClient(client)
 //
+// # Result Count
+//
+// Every provider returns at most five results by default. Set the MaxResults
+// field to change the cap; Brave and Tavily also request that many results
+// from their APIs:
+//
+//	provider := search.NewBrave("your-api-key")
+//	provider.MaxResults = 10
+//
 // # Custom Providers
 //
 // Implement the laconic.SearchProvider interface to add your own search backend:
@@ -9,108 +9,204 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/smhanov/laconic"
+	"github.com/smhanov/laconic/internal/useragent"
 )
 
-// ddgRateLimit enforces a global rate limit of 1 query per second across all
-// DuckDuckGo instances and goroutines.
-var ddgRateLimit struct {
-	mu   sync.Mutex
-	last time.Time
-}
+// defaultDDGInterval is the default pause between one DuckDuckGo instance's
+// requests: one query per second.
+const defaultDDGInterval = time.Second
 
 // DuckDuckGo implements a searcher using DuckDuckGo's HTML lite interface.
 type DuckDuckGo struct {
 	client *http.Client
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+	agents     *useragent.Pool
+	maxRetries int
+	// noHTMLFallback disables the html.duckduckgo.com retry; see
+	// WithHTMLFallback.
+	noHTMLFallback bool
+	limiter        *rateLimiter
+}
+
+// DuckDuckGoOption configures a DuckDuckGo searcher.
+type DuckDuckGoOption func(*DuckDuckGo)
+
+// WithUserAgentPool makes each request use a User-Agent picked at random
+// from agents, which makes the scraper harder to fingerprint. Without this
+// option a small built-in pool of desktop browser User-Agents is used.
+func WithUserAgentPool(agents []string) DuckDuckGoOption {
+	return func(d *DuckDuckGo) {
+		deterministic := d.agents.Deterministic
+		d.agents = useragent.New(agents)
+		d.agents.Deterministic = deterministic
+	}
+}
+
+// WithUserAgent sends every request with the User-Agent ua instead of one
+// picked from a pool. Use it when a particular browser string is known to
+// get through; WithUserAgentPool spreads requests over several.
+func WithUserAgent(ua string) DuckDuckGoOption {
+	return WithUserAgentPool([]string{ua})
+}
+
+// WithHTMLFallback controls whether a lite page the parser cannot read is
+// retried against html.duckduckgo.com (the default) before the last-resort
+// link scan. Disable it to make at most one request per search.
+func WithHTMLFallback(enabled bool) DuckDuckGoOption {
+	return func(d *DuckDuckGo) { d.noHTMLFallback = !enabled }
+}
+
+// WithDeterministic replaces random choices (such as the User-Agent) with a
+// fixed rotation so tests see the same requests on every run.
+func WithDeterministic(enabled bool) DuckDuckGoOption {
+	return func(d *DuckDuckGo) { d.agents.Deterministic = enabled }
+}
+
+// WithMaxRetries sets how many times a search answered with HTTP 429 is
+// retried, with doubling delays, before Search fails with ErrRateLimited
+// (default 5). Zero fails on the first 429.
+func WithMaxRetries(n int) DuckDuckGoOption {
+	return func(d *DuckDuckGo) {
+		if n >= 0 {
+			d.maxRetries = n
+		}
+	}
+}
+
+// WithRateLimit sets the pause between the searcher's requests (default one
+// second). Each searcher keeps its own pace, so lower the interval when a
+// single agent searches and raise it when many searchers share one IP
+// address. Zero or less disables the wait.
+func WithRateLimit(interval time.Duration) DuckDuckGoOption {
+	return func(d *DuckDuckGo) { d.limiter.interval = interval }
 }
 
 // NewDuckDuckGo creates a DuckDuckGo searcher with a modest timeout.
-func NewDuckDuckGo() *DuckDuckGo {
-	return &DuckDuckGo{client: &http.Client{Timeout: 15 * time.Second}}
+func NewDuckDuckGo(opts ...DuckDuckGoOption) *DuckDuckGo {
+	return NewDuckDuckGoWithClient(&http.Client{Timeout: 15 * time.Second}, opts...)
 }
 
 // NewDuckDuckGoWithClient creates a DuckDuckGo searcher using the supplied HTTP client.
 // This is useful for overriding the default timeout.
-func NewDuckDuckGoWithClient(client *http.Client) *DuckDuckGo {
-	return &DuckDuckGo{client: client}
+func NewDuckDuckGoWithClient(client *http.Client, opts ...DuckDuckGoOption) *DuckDuckGo {
+	d := &DuckDuckGo{
+		client:     client,
+		agents:     useragent.New(nil),
+		maxRetries: defaultMaxRetries,
+		limiter:    &rateLimiter{interval: defaultDDGInterval},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// NewDuckDuckGoWithRateLimit creates a DuckDuckGo searcher that waits
+// interval between its requests instead of the default second; it is
+// shorthand for NewDuckDuckGo(WithRateLimit(interval), opts...).
+func NewDuckDuckGoWithRateLimit(interval time.Duration, opts ...DuckDuckGoOption) *DuckDuckGo {
+	return NewDuckDuckGo(append([]DuckDuckGoOption{WithRateLimit(interval)}, opts...)...)
 }
 
-// Search scrapes the DuckDuckGo lite HTML page for results.
+const (
+	ddgLiteEndpoint = "https://lite.duckduckgo.com/lite/"
+	ddgHTMLEndpoint = "https://html.duckduckgo.com/html/"
+)
+
+// ddgNoResultsRegex matches the notice DuckDuckGo shows when a query has no
+// results, as opposed to a page whose layout the parser does not know.
+var ddgNoResultsRegex = regexp.MustCompile(`(?i)class=['"][^'"]*\bno-results\b|>\s*No\s+(?:more\s+)?results(?:\s+found)?\.?\s*<`)
+
+// Search scrapes the DuckDuckGo lite HTML page for results. When the page
+// yields no results but does not say the query has none, the parser has
+// likely missed a layout change, so the search is retried once against the
+// html.duckduckgo.com endpoint (see WithHTMLFallback). Only if that also
+// yields nothing are the lite page's external links scanned as a last
+// resort.
 func (d *DuckDuckGo) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, errors.New("query is empty")
 	}
 
-	// Enforce global 1 QPS rate limit.
-	ddgRateLimit.mu.Lock()
-	if wait := time.Until(ddgRateLimit.last.Add(time.Second)); wait > 0 {
-		ddgRateLimit.mu.Unlock()
-		select {
-		case <-time.After(wait):
-		case <-ctx.Done():
-			return nil, ctx.Err()
+	limit := resultLimit(d.MaxResults)
+	lite, err := d.fetch(ctx, ddgLiteEndpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	results := parseHTMLResults(lite, limit)
+	if len(results) > 0 || ddgNoResultsRegex.MatchString(lite) {
+		return results, nil
+	}
+
+	if d.noHTMLFallback {
+		return fallbackParse(lite, limit), nil
+	}
+	body, err := d.fetch(ctx, ddgHTMLEndpoint, query)
+	if err == nil {
+		if results = parseHTMLEndpointResults(body, limit); len(results) > 0 {
+			return results, nil
 		}
-		ddgRateLimit.mu.Lock()
 	}
-	ddgRateLimit.last = time.Now()
-	ddgRateLimit.mu.Unlock()
+	results = fallbackParse(lite, limit)
+	if len(results) == 0 && err != nil {
+		return nil, fmt.Errorf("duckduckgo html fallback: %w", err)
+	}
+	return results, nil
+}
+
+// fetch posts query to a DuckDuckGo endpoint and returns the page, waiting
+// for the instance's rate limit and retrying on 429.
+func (d *DuckDuckGo) fetch(ctx context.Context, endpoint, query string) (string, error) {
+	// Pace requests; see NewDuckDuckGoWithRateLimit.
+	if err := d.limiter.wait(ctx); err != nil {
+		return "", err
+	}
 
-	// Use the lite HTML version which is more stable for scraping
-	endpoint := "https://lite.duckduckgo.com/lite/"
-	
 	formData := url.Values{}
 	formData.Set("q", query)
 
-	var resp *http.Response
-	delay := 1 * time.Second
-	for {
+	resp, err := doWithBackoff(ctx, d.client, "duckduckgo", d.maxRetries, func() (*http.Request, error) {
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(formData.Encode()))
 		if err != nil {
 			return nil, err
 		}
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+		req.Header.Set("User-Agent", d.userAgent())
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-		resp, err = d.client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-
-		if resp.StatusCode != http.StatusTooManyRequests {
-			break
-		}
-		resp.Body.Close()
-
-		// Back off and retry on 429, doubling the delay each time up to 30 s.
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-time.After(delay):
-		}
-		if delay < 30*time.Second {
-			delay *= 2
-		}
+		return req, nil
+	})
+	if err != nil {
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("duckduckgo http %d", resp.StatusCode)
+		return "", fmt.Errorf("duckduckgo http %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	return parseHTMLResults(string(body)), nil
+	return string(body), nil
+}
+
+// userAgent returns the User-Agent for the next request. A DuckDuckGo built
+// as a struct literal falls back to the built-in pool.
+func (d *DuckDuckGo) userAgent() string {
+	if d.agents == nil {
+		return useragent.Default[0]
+	}
+	return d.agents.Pick()
 }
 
 // parseHTMLResults extracts search results from the DuckDuckGo lite HTML.
 // The lite page has a simple structure with result links and snippets.
-func parseHTMLResults(html string) []laconic.SearchResult {
+func parseHTMLResults(html string, limit int) []laconic.SearchResult {
 	var results []laconic.SearchResult
 
 	// Pattern to find result links: <a rel="nofollow" href="URL" class='result-link'>TITLE</a>
@@ -134,7 +230,7 @@ func parseHTMLResults(html string) []laconic.SearchResult {
 			continue
 		}
 		
-		urlStr := strings.TrimSpace(match[1])
+		urlStr := resolveDDGRedirect(strings.TrimSpace(match[1]))
 		title := strings.TrimSpace(match[2])
 		
 		// Clean up HTML entities
@@ -156,22 +252,16 @@ func parseHTMLResults(html string) []laconic.SearchResult {
 			Snippet: snippet,
 		})
 		
-		if len(results) >= 5 {
+		if len(results) >= limit {
 			break
 		}
 	}
 
-	// If the regex approach didn't work well, try a simpler fallback
-	// Look for any links that look like search results (external URLs)
-	if len(results) == 0 {
-		results = fallbackParse(html)
-	}
-
 	return results
 }
 
 // fallbackParse tries a simpler approach to extract links
-func fallbackParse(html string) []laconic.SearchResult {
+func fallbackParse(html string, limit int) []laconic.SearchResult {
 	var results []laconic.SearchResult
 	
 	// Look for links that appear to be search results
@@ -184,7 +274,7 @@ func fallbackParse(html string) []laconic.SearchResult {
 			continue
 		}
 		
-		urlStr := strings.TrimSpace(match[1])
+		urlStr := resolveDDGRedirect(strings.TrimSpace(match[1]))
 		title := cleanHTML(strings.TrimSpace(match[2]))
 		
 		// Skip DuckDuckGo internal links
@@ -212,7 +302,7 @@ func fallbackParse(html string) []laconic.SearchResult {
 			Snippet: "",
 		})
 		
-		if len(results) >= 5 {
+		if len(results) >= limit {
 			break
 		}
 	}
@@ -220,6 +310,68 @@ func fallbackParse(html string) []laconic.SearchResult {
 	return results
 }
 
+var (
+	// ddgHTMLLinkRegex and ddgHTMLSnippetRegex match a result's title link
+	// and snippet on the html.duckduckgo.com page, where both may contain
+	// <b> highlighting.
+	ddgHTMLLinkRegex    = regexp.MustCompile(`(?s)<a[^>]*class=['"][^'"]*\bresult__a\b[^'"]*['"][^>]*>(.*?)</a>`)
+	ddgHTMLSnippetRegex = regexp.MustCompile(`(?s)class=['"][^'"]*\bresult__snippet\b[^'"]*['"][^>]*>(.*?)</(?:a|div|td)>`)
+	ddgHrefRegex        = regexp.MustCompile(`href=['"]([^'"]+)['"]`)
+)
+
+// parseHTMLEndpointResults extracts search results from the
+// html.duckduckgo.com page, the fallback when the lite page cannot be
+// parsed.
+func parseHTMLEndpointResults(html string, limit int) []laconic.SearchResult {
+	links := ddgHTMLLinkRegex.FindAllStringSubmatchIndex(html, -1)
+	var results []laconic.SearchResult
+	for i, m := range links {
+		tag := html[m[0]:m[2]]
+		href := ddgHrefRegex.FindStringSubmatch(tag)
+		if href == nil {
+			continue
+		}
+		urlStr := resolveDDGRedirect(strings.TrimSpace(href[1]))
+		title := cleanHTML(html[m[2]:m[3]])
+		if urlStr == "" || title == "" {
+			continue
+		}
+		// The snippet belongs to this result if it appears before the next
+		// result's link.
+		rest := html[m[1]:]
+		if i+1 < len(links) {
+			rest = html[m[1]:links[i+1][0]]
+		}
+		snippet := ""
+		if s := ddgHTMLSnippetRegex.FindStringSubmatch(rest); s != nil {
+			snippet = cleanHTML(s[1])
+		}
+		results = append(results, laconic.SearchResult{Title: title, URL: urlStr, Snippet: snippet})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results
+}
+
+// resolveDDGRedirect returns the destination of a DuckDuckGo redirect link
+// such as //duckduckgo.com/l/?uddg=<encoded-url>&rut=..., or raw unchanged
+// when it is not one.
+func resolveDDGRedirect(raw string) string {
+	u, err := url.Parse(strings.ReplaceAll(raw, "&amp;", "&"))
+	if err != nil || !strings.HasPrefix(u.Path, "/l/") {
+		return raw
+	}
+	if host := u.Hostname(); host != "duckduckgo.com" && !strings.HasSuffix(host, ".duckduckgo.com") {
+		return raw
+	}
+	// Query().Get already percent-decodes the parameter.
+	if dest := strings.TrimSpace(u.Query().Get("uddg")); dest != "" {
+		return dest
+	}
+	return raw
+}
+
 // cleanHTML removes HTML entities and tags
 func cleanHTML(s string) string {
 	// Remove HTML tags
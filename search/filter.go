@@ -0,0 +1,99 @@
+package search
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/smhanov/laconic"
+)
+
+// FilterOptions selects the domains a Filter lets through. Entries match
+// the domain and all of its subdomains, so "example.com" also matches
+// "docs.example.com"; "*.gov" and "gov" both match every .gov host.
+type FilterOptions struct {
+	// AllowDomains, when non-empty, keeps only results on these domains.
+	AllowDomains []string
+	// DenyDomains drops results on these domains. It wins over AllowDomains.
+	DenyDomains []string
+}
+
+// Filter wraps a provider and drops results by domain. Filtering happens
+// after the search, so a query may return fewer results than the wrapped
+// provider's MaxResults, or none.
+type Filter struct {
+	Provider laconic.SearchProvider
+
+	allow []string
+	deny  []string
+}
+
+// NewFilter wraps inner, keeping only the results opts allows.
+func NewFilter(inner laconic.SearchProvider, opts FilterOptions) *Filter {
+	return &Filter{Provider: inner, allow: normalizeDomains(opts.AllowDomains), deny: normalizeDomains(opts.DenyDomains)}
+}
+
+// Search delegates to the wrapped provider and filters its results.
+func (f *Filter) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	results, err := f.Provider.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]laconic.SearchResult, 0, len(results))
+	for _, r := range results {
+		if f.allowed(r.URL) {
+			kept = append(kept, r)
+		}
+	}
+	return kept, nil
+}
+
+// Validate forwards to the wrapped provider when it implements
+// laconic.SearchValidator.
+func (f *Filter) Validate(ctx context.Context) error {
+	if v, ok := f.Provider.(laconic.SearchValidator); ok {
+		return v.Validate(ctx)
+	}
+	return nil
+}
+
+// allowed reports whether a result at rawURL passes the filter. A URL
+// without a host only passes when there is no allow list.
+func (f *Filter) allowed(rawURL string) bool {
+	host := ""
+	if u, err := url.Parse(strings.TrimSpace(rawURL)); err == nil {
+		host = strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	}
+	if host == "" {
+		return len(f.allow) == 0
+	}
+	if matchesDomain(host, f.deny) {
+		return false
+	}
+	return len(f.allow) == 0 || matchesDomain(host, f.allow)
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one.
+func matchesDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomains lowercases the entries and strips wildcards and dots
+// around them, dropping the empty ones.
+func normalizeDomains(domains []string) []string {
+	var out []string
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		d = strings.TrimPrefix(d, "*")
+		d = strings.Trim(d, ".")
+		if d != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
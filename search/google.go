@@ -0,0 +1,126 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+const googleEndpoint = "https://www.googleapis.com/customsearch/v1"
+
+// googleMaxNum is the most results the Custom Search API returns per request.
+const googleMaxNum = 10
+
+// Google calls the Google Custom Search JSON API. CX is the ID of the
+// Programmable Search Engine to query.
+type Google struct {
+	APIKey string
+	CX     string
+	client *http.Client
+	// MaxResults caps the results returned per search (default 5, at most
+	// 10 per request).
+	MaxResults int
+}
+
+// NewGoogle constructs a Google Custom Search provider.
+func NewGoogle(apiKey, cx string) *Google {
+	return &Google{APIKey: apiKey, CX: cx, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewGoogleWithClient constructs a Google Custom Search provider using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewGoogleWithClient(apiKey, cx string, client *http.Client) *Google {
+	return &Google{APIKey: apiKey, CX: cx, client: client}
+}
+
+// Search queries Google Custom Search.
+func (g *Google) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	if err := g.checkConfig(); err != nil {
+		return nil, err
+	}
+
+	limit := resultLimit(g.MaxResults)
+	resp, err := doWithBackoff(ctx, g.client, "google", defaultMaxRetries, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.endpoint(query, min(limit, googleMaxNum)), nil)
+		if err != nil {
+			return nil, err
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google http %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Items []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	results := make([]laconic.SearchResult, 0, len(response.Items))
+	for _, item := range response.Items {
+		results = append(results, laconic.SearchResult{Title: item.Title, URL: item.Link, Snippet: item.Snippet})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Validate issues a single one-result query to check that the API key and
+// search engine ID are accepted. Note that this counts against the daily
+// query quota. It returns an error wrapping ErrInvalidAPIKey on 401/403.
+func (g *Google) Validate(ctx context.Context) error {
+	if err := g.checkConfig(); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.endpoint("test", 1), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return checkValidateStatus("google", resp.StatusCode)
+}
+
+// checkConfig reports a missing API key or search engine ID.
+func (g *Google) checkConfig() error {
+	if strings.TrimSpace(g.APIKey) == "" {
+		return errors.New("google: API key is missing")
+	}
+	if strings.TrimSpace(g.CX) == "" {
+		return errors.New("google: search engine ID (cx) is missing")
+	}
+	return nil
+}
+
+// endpoint builds the request URL for query, asking for num results.
+func (g *Google) endpoint(query string, num int) string {
+	params := url.Values{}
+	params.Set("key", strings.TrimSpace(g.APIKey))
+	params.Set("cx", strings.TrimSpace(g.CX))
+	params.Set("q", query)
+	params.Set("num", strconv.Itoa(num))
+	return googleEndpoint + "?" + params.Encode()
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// CallStats describes one search made through an Instrumented provider.
+type CallStats struct {
+	Query    string
+	Duration time.Duration // total time spent in Search, including backoff
+	Results  int
+	Err      error
+	// AllResults is the full result set the provider returned, before any
+	// agent-side limit (see laconic.WithSearchResultLimit). It is a copy,
+	// so callers may keep and reorder it for their own reranking.
+	AllResults []laconic.SearchResult
+	// Retries counts requests repeated after the backend rate-limited the
+	// call (HTTP 429, or 503 for Marginalia and arXiv).
+	Retries int
+	// Waited is the total backoff delay before those retries.
+	Waited time.Duration
+}
+
+// Instrumented wraps a provider and reports timing and rate-limit retries
+// for every search to OnSearch. The built-in providers record their
+// backoffs; custom providers report zero retries.
+type Instrumented struct {
+	Provider laconic.SearchProvider
+	OnSearch func(CallStats)
+}
+
+// NewInstrumented wraps provider, calling onSearch after every search.
+func NewInstrumented(provider laconic.SearchProvider, onSearch func(CallStats)) *Instrumented {
+	return &Instrumented{Provider: provider, OnSearch: onSearch}
+}
+
+// Search runs the wrapped provider and reports its stats.
+func (i *Instrumented) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	rec := &backoffRecorder{}
+	start := time.Now()
+	results, err := i.Provider.Search(context.WithValue(ctx, backoffKey{}, rec), query)
+	if i.OnSearch != nil {
+		rec.mu.Lock()
+		stats := CallStats{
+			Query:      query,
+			Duration:   time.Since(start),
+			Results:    len(results),
+			Err:        err,
+			AllResults: append([]laconic.SearchResult(nil), results...),
+			Retries:    rec.retries,
+			Waited:     rec.waited,
+		}
+		rec.mu.Unlock()
+		i.OnSearch(stats)
+	}
+	return results, err
+}
+
+// Validate forwards to the wrapped provider when it implements
+// laconic.SearchValidator.
+func (i *Instrumented) Validate(ctx context.Context) error {
+	if v, ok := i.Provider.(laconic.SearchValidator); ok {
+		return v.Validate(ctx)
+	}
+	return nil
+}
+
+type backoffKey struct{}
+
+// backoffRecorder accumulates the retries made during one instrumented
+// search. Meta may report from several goroutines at once.
+type backoffRecorder struct {
+	mu      sync.Mutex
+	retries int
+	waited  time.Duration
+}
+
+// noteBackoff records that a provider is about to wait delay before
+// retrying a rate-limited request. It does nothing outside an Instrumented
+// search.
+func noteBackoff(ctx context.Context, delay time.Duration) {
+	rec, ok := ctx.Value(backoffKey{}).(*backoffRecorder)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	rec.retries++
+	rec.waited += delay
+	rec.mu.Unlock()
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a provider gives up on a query after the
+// backend kept rate-limiting its retries.
+var ErrRateLimited = errors.New("search: rate limited")
+
+// defaultMaxResults is the number of results a provider returns when its
+// MaxResults field is left at zero.
+const defaultMaxResults = 5
+
+// resultLimit returns n, or defaultMaxResults when n is not positive.
+func resultLimit(n int) int {
+	if n <= 0 {
+		return defaultMaxResults
+	}
+	return n
+}
+
+// rateLimiter spaces requests interval apart. Each caller reserves the next
+// free slot, so concurrent searches queue in order.
+type rateLimiter struct {
+	mu       sync.Mutex
+	next     time.Time
+	interval time.Duration
+}
+
+// wait blocks until the caller's slot comes up or ctx is done. A nil
+// limiter or one with no interval never waits.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil || l.interval <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := time.Until(at)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// marginaliaGates serialises requests per API key. Marginalia allows only one
+// request in flight at a time, so every Marginalia instance sharing a key
+// waits on the same single-slot semaphore.
+var (
+	marginaliaGatesMu sync.Mutex
+	marginaliaGates   = map[string]chan struct{}{}
+)
+
+// marginaliaGateFor returns (or creates) the shared gate for the given API key.
+func marginaliaGateFor(apiKey string) chan struct{} {
+	marginaliaGatesMu.Lock()
+	defer marginaliaGatesMu.Unlock()
+	g, ok := marginaliaGates[apiKey]
+	if !ok {
+		g = make(chan struct{}, 1)
+		marginaliaGates[apiKey] = g
+	}
+	return g
+}
+
+// Marginalia uses the Marginalia Search API, which favours independent,
+// non-commercial websites. The key "public" selects the shared public key.
+type Marginalia struct {
+	APIKey string
+	client *http.Client
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+}
+
+// NewMarginalia constructs a Marginalia search provider.
+func NewMarginalia(apiKey string) *Marginalia {
+	return &Marginalia{APIKey: apiKey, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// NewMarginaliaWithClient constructs a Marginalia search provider using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewMarginaliaWithClient(apiKey string, client *http.Client) *Marginalia {
+	return &Marginalia{APIKey: apiKey, client: client}
+}
+
+// Search executes a Marginalia query. Concurrent calls sharing an API key
+// run one at a time through a shared per-key gate.
+func (m *Marginalia) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	key := strings.TrimSpace(m.APIKey)
+	if key == "" {
+		return nil, errors.New("marginalia: API key is missing")
+	}
+	limit := resultLimit(m.MaxResults)
+	endpoint := fmt.Sprintf("https://api.marginalia.nu/%s/search/%s?count=%d",
+		url.PathEscape(key), url.PathEscape(query), limit)
+
+	gate := marginaliaGateFor(key)
+	select {
+	case gate <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-gate }()
+
+	resp, err := doWithBackoff(ctx, m.client, "marginalia", defaultMaxRetries, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	}, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("marginalia http %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	results := make([]laconic.SearchResult, 0, limit)
+	for _, r := range response.Results {
+		results = append(results, laconic.SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
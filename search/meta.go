@@ -0,0 +1,165 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/smhanov/laconic"
+)
+
+// MergeStrategy controls how Meta combines the results of its providers.
+type MergeStrategy int
+
+const (
+	// MergeRoundRobin takes the first result from each provider, then the
+	// second from each, and so on. This keeps every backend represented.
+	MergeRoundRobin MergeStrategy = iota
+	// MergeByScore orders all results by SearchResult.Score, highest first.
+	// Results without a score sort after scored ones, in provider order.
+	MergeByScore
+	// MergeByPriority keeps the provider order: all results from the first
+	// provider, then the second, and so on.
+	MergeByPriority
+)
+
+// Meta queries several search providers concurrently and merges their
+// results into one deduplicated list.
+type Meta struct {
+	Providers []laconic.SearchProvider
+	// Strategy selects the merge order. The default is MergeRoundRobin.
+	Strategy MergeStrategy
+	// MaxResults caps the merged results returned per search (default 5).
+	MaxResults int
+	// TitleSimilarity, when between 0 and 1, also drops results whose title
+	// is at least this similar to one already kept (see
+	// laconic.TitleSimilarity), keeping the highest-scored instance. This
+	// collapses syndicated stories that URL dedup misses. Zero disables it.
+	TitleSimilarity float64
+}
+
+// NewMeta constructs a Meta provider that merges results round-robin.
+func NewMeta(providers ...laconic.SearchProvider) *Meta {
+	return &Meta{Providers: providers}
+}
+
+// NewMetaWithStrategy constructs a Meta provider using the given merge strategy.
+func NewMetaWithStrategy(strategy MergeStrategy, providers ...laconic.SearchProvider) *Meta {
+	return &Meta{Providers: providers, Strategy: strategy}
+}
+
+// Search runs every provider concurrently and merges their results. A failing
+// provider is logged and skipped; an error is returned only if all fail.
+func (m *Meta) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	if len(m.Providers) == 0 {
+		return nil, errors.New("meta: no providers configured")
+	}
+
+	lists := make([][]laconic.SearchResult, len(m.Providers))
+	errs := make([]error, len(m.Providers))
+	var wg sync.WaitGroup
+	for i, p := range m.Providers {
+		wg.Add(1)
+		go func(i int, p laconic.SearchProvider) {
+			defer wg.Done()
+			lists[i], errs[i] = p.Search(ctx, query)
+		}(i, p)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			log.Printf("meta: provider %d failed for %q: %v", i, query, err)
+		}
+	}
+	if failed == len(m.Providers) {
+		return nil, fmt.Errorf("meta: all providers failed: %w", errors.Join(errs...))
+	}
+
+	return mergeResults(lists, m.Strategy, m.TitleSimilarity, resultLimit(m.MaxResults)), nil
+}
+
+// Validate validates every child provider that implements
+// laconic.SearchValidator and returns the first failure.
+func (m *Meta) Validate(ctx context.Context) error {
+	for _, p := range m.Providers {
+		if v, ok := p.(laconic.SearchValidator); ok {
+			if err := v.Validate(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeResults combines per-provider result lists according to strategy,
+// dropping duplicate URLs (and near-duplicate titles when titleSimilarity is
+// set) and stopping once limit results are collected.
+func mergeResults(lists [][]laconic.SearchResult, strategy MergeStrategy, titleSimilarity float64, limit int) []laconic.SearchResult {
+	var ordered []laconic.SearchResult
+	switch strategy {
+	case MergeByScore:
+		for _, l := range lists {
+			ordered = append(ordered, l...)
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].Score > ordered[j].Score
+		})
+	case MergeByPriority:
+		for _, l := range lists {
+			ordered = append(ordered, l...)
+		}
+	default:
+		for i := 0; ; i++ {
+			added := false
+			for _, l := range lists {
+				if i < len(l) {
+					ordered = append(ordered, l[i])
+					added = true
+				}
+			}
+			if !added {
+				break
+			}
+		}
+	}
+
+	unique := make([]laconic.SearchResult, 0, len(ordered))
+	seen := make(map[string]bool)
+	for _, r := range ordered {
+		key := normalizeURL(r.URL)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, r)
+	}
+	unique = laconic.DedupeByTitle(unique, titleSimilarity)
+	if len(unique) > limit {
+		unique = unique[:limit]
+	}
+	return unique
+}
+
+// normalizeURL reduces a URL to a comparison key that ignores the scheme,
+// a leading "www.", letter case in the host, and a trailing slash.
+func normalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(strings.ToLower(raw), "/")
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	key := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// Perplexity uses Perplexity's online "sonar" models as a search backend.
+// Instead of raw search hits it returns the sources Perplexity cited, with
+// snippets taken from the synthesized answer where each source was used.
+type Perplexity struct {
+	APIKey string
+	client *http.Client
+	// Model is the Perplexity model to call (default "sonar").
+	Model string
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+}
+
+// NewPerplexity constructs a Perplexity search provider.
+func NewPerplexity(apiKey string) *Perplexity {
+	return &Perplexity{APIKey: apiKey, Model: "sonar", client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+// NewPerplexityWithClient constructs a Perplexity search provider using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewPerplexityWithClient(apiKey string, client *http.Client) *Perplexity {
+	return &Perplexity{APIKey: apiKey, Model: "sonar", client: client}
+}
+
+// Search asks Perplexity the query and returns its cited sources.
+func (p *Perplexity) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	if strings.TrimSpace(p.APIKey) == "" {
+		return nil, errors.New("perplexity: API key is missing")
+	}
+	model := p.Model
+	if model == "" {
+		model = "sonar"
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "system", "content": "Answer concisely with facts, citing sources."},
+			{"role": "user", "content": query},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doWithBackoff(ctx, p.client, "perplexity", defaultMaxRetries, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.perplexity.ai/chat/completions", bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("perplexity http %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Citations     []string `json:"citations"`
+		SearchResults []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Snippet string `json:"snippet"`
+		} `json:"search_results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	answer := ""
+	if len(response.Choices) > 0 {
+		answer = response.Choices[0].Message.Content
+	}
+
+	limit := resultLimit(p.MaxResults)
+	results := make([]laconic.SearchResult, 0, limit)
+	if len(response.SearchResults) > 0 {
+		for i, r := range response.SearchResults {
+			snippet := strings.TrimSpace(r.Snippet)
+			if snippet == "" {
+				snippet = citedText(answer, i+1)
+			}
+			results = append(results, laconic.SearchResult{Title: r.Title, URL: r.URL, Snippet: snippet})
+			if len(results) >= limit {
+				break
+			}
+		}
+		return results, nil
+	}
+	for i, u := range response.Citations {
+		results = append(results, laconic.SearchResult{Title: u, URL: u, Snippet: citedText(answer, i+1)})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+var (
+	reSentence     = regexp.MustCompile(`[^.!?\n]+[.!?]*(?:\s*\[\d+\])*`)
+	reCitationMark = regexp.MustCompile(`\s*\[\d+\]`)
+)
+
+// citedText returns the sentences of answer that cite source n (as "[n]"),
+// with citation markers removed.
+func citedText(answer string, n int) string {
+	marker := fmt.Sprintf("[%d]", n)
+	var parts []string
+	for _, sentence := range reSentence.FindAllString(answer, -1) {
+		if strings.Contains(sentence, marker) {
+			parts = append(parts, strings.TrimSpace(reCitationMark.ReplaceAllString(sentence, "")))
+		}
+	}
+	return strings.Join(parts, " ")
+}
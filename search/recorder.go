@@ -0,0 +1,122 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// ErrNotRecorded is returned by Replay for a query with no recording.
+var ErrNotRecorded = errors.New("replay: query not recorded")
+
+// recording is one JSON line written by Recorder and read by Replay.
+type recording struct {
+	Query     string                 `json:"query"`
+	Results   []laconic.SearchResult `json:"results"`
+	Timestamp time.Time              `json:"timestamp"`
+	Err       string                 `json:"err,omitempty"`
+}
+
+// Recorder wraps a provider and writes every search, with its results or
+// error, to a writer as one JSON line. Use it to build eval datasets or to
+// capture a run for Replay.
+type Recorder struct {
+	Provider laconic.SearchProvider
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder wraps inner, recording each search to w.
+func NewRecorder(inner laconic.SearchProvider, w io.Writer) *Recorder {
+	return &Recorder{Provider: inner, w: w}
+}
+
+// Search delegates to the wrapped provider and records the call. A failed
+// write is logged and does not affect the search.
+func (r *Recorder) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	results, err := r.Provider.Search(ctx, query)
+	rec := recording{Query: query, Results: results, Timestamp: time.Now().UTC()}
+	if err != nil {
+		rec.Err = err.Error()
+	}
+	line, mErr := json.Marshal(rec)
+	if mErr == nil {
+		r.mu.Lock()
+		_, mErr = r.w.Write(append(line, '\n'))
+		r.mu.Unlock()
+	}
+	if mErr != nil {
+		log.Printf("recorder: writing %q: %v", query, mErr)
+	}
+	return results, err
+}
+
+// Validate forwards to the wrapped provider when it implements
+// laconic.SearchValidator.
+func (r *Recorder) Validate(ctx context.Context) error {
+	if v, ok := r.Provider.(laconic.SearchValidator); ok {
+		return v.Validate(ctx)
+	}
+	return nil
+}
+
+// Replay serves searches from a Recorder's output without touching the
+// network. Repeated searches for one query return its recordings in order,
+// then keep returning the last one. Unrecorded queries fail with
+// ErrNotRecorded.
+type Replay struct {
+	mu         sync.Mutex
+	recordings map[string][]recording
+	served     map[string]int
+}
+
+// NewReplay reads the JSON lines written by a Recorder.
+func NewReplay(r io.Reader) (*Replay, error) {
+	rp := &Replay{recordings: make(map[string][]recording), served: make(map[string]int)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec recording
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("replay: line %d: %w", line, err)
+		}
+		rp.recordings[rec.Query] = append(rp.recordings[rec.Query], rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	return rp, nil
+}
+
+// Search returns the next recording for query.
+func (rp *Replay) Search(_ context.Context, query string) ([]laconic.SearchResult, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	recs := rp.recordings[query]
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotRecorded, query)
+	}
+	i := rp.served[query]
+	if i >= len(recs) {
+		i = len(recs) - 1
+	}
+	rp.served[query] = i + 1
+	if recs[i].Err != "" {
+		return nil, errors.New(recs[i].Err)
+	}
+	return recs[i].Results, nil
+}
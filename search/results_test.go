@@ -0,0 +1,1040 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// roundTripFunc lets tests answer HTTP requests without a network.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// mockClient returns an HTTP client that answers every request with body.
+func mockClient(body string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"X-Ratelimit-Remaining": []string{"1, 1000"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}
+}
+
+func TestMaxResultsHonored(t *testing.T) {
+	var braveItems, tavilyItems, ddgRows, citations, marginaliaItems, googleItems, searxngItems []string
+	for i := 1; i <= 12; i++ {
+		braveItems = append(braveItems, fmt.Sprintf(`{"title": "t%d", "url": "https://example.com/%d", "description": "d%d"}`, i, i, i))
+		tavilyItems = append(tavilyItems, fmt.Sprintf(`{"title": "t%d", "url": "https://example.com/%d", "content": "c%d"}`, i, i, i))
+		marginaliaItems = append(marginaliaItems, fmt.Sprintf(`{"title": "t%d", "url": "https://example.com/%d", "description": "d%d"}`, i, i, i))
+		googleItems = append(googleItems, fmt.Sprintf(`{"title": "t%d", "link": "https://example.com/%d", "snippet": "s%d"}`, i, i, i))
+		searxngItems = append(searxngItems, fmt.Sprintf(`{"title": "t%d", "url": "https://example.com/%d", "content": "c%d"}`, i, i, i))
+		citations = append(citations, fmt.Sprintf(`"https://example.com/%d"`, i))
+		ddgRows = append(ddgRows, fmt.Sprintf(`<a rel="nofollow" href="https://example.com/%d" class='result-link'>Title %d</a><td class='result-snippet'>snippet %d</td>`, i, i, i))
+	}
+	braveBody := `{"web": {"results": [` + strings.Join(braveItems, ",") + `]}}`
+	tavilyBody := `{"results": [` + strings.Join(tavilyItems, ",") + `]}`
+	ddgBody := "<html>" + strings.Join(ddgRows, "\n") + "</html>"
+	marginaliaBody := `{"results": [` + strings.Join(marginaliaItems, ",") + `]}`
+	googleBody := `{"items": [` + strings.Join(googleItems, ",") + `]}`
+	searxngBody := `{"results": [` + strings.Join(searxngItems, ",") + `]}`
+	perplexityBody := `{"choices": [{"message": {"content": "answer [1]."}}], "citations": [` + strings.Join(citations, ",") + `]}`
+
+	brave := NewBraveWithClient("max-results-test-key", mockClient(braveBody))
+	brave.MaxResults = 10
+	tavily := NewTavilyWithClient("key", "basic", mockClient(tavilyBody))
+	tavily.MaxResults = 10
+	ddg := NewDuckDuckGoWithClient(mockClient(ddgBody))
+	ddg.MaxResults = 10
+	perplexity := NewPerplexityWithClient("key", mockClient(perplexityBody))
+	perplexity.MaxResults = 10
+	marginalia := NewMarginaliaWithClient("public", mockClient(marginaliaBody))
+	marginalia.MaxResults = 10
+	google := NewGoogleWithClient("key", "cx", mockClient(googleBody))
+	google.MaxResults = 10
+	searxng := NewSearXNGWithClient("http://localhost:8888", SearXNGOptions{}, mockClient(searxngBody))
+	searxng.MaxResults = 10
+
+	for name, p := range map[string]laconic.SearchProvider{"brave": brave, "tavily": tavily, "duckduckgo": ddg, "perplexity": perplexity, "marginalia": marginalia, "google": google, "searxng": searxng} {
+		results, err := p.Search(context.Background(), "q")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(results) != 10 {
+			t.Fatalf("%s: expected 10 results, got %d", name, len(results))
+		}
+	}
+}
+
+func TestPerplexityCitationSnippets(t *testing.T) {
+	body := `{
+		"choices": [{"message": {"content": "Paris is the capital of France [1]. It has about 2 million residents [2][1]. Lyon is the third-largest city [3]."}}],
+		"citations": ["https://a.example", "https://b.example", "https://c.example"]
+	}`
+	p := NewPerplexityWithClient("key", mockClient(body))
+	results, err := p.Search(context.Background(), "capital of France")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].URL != "https://a.example" {
+		t.Fatalf("unexpected URL: %q", results[0].URL)
+	}
+	if want := "Paris is the capital of France. It has about 2 million residents."; results[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", results[0].Snippet, want)
+	}
+	if want := "Lyon is the third-largest city."; results[2].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", results[2].Snippet, want)
+	}
+}
+
+func TestDuckDuckGoUserAgentPool(t *testing.T) {
+	var agents []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(ddgNoResultsPage)), Request: r}, nil
+	})}
+	ddg := NewDuckDuckGoWithClient(client, WithDeterministic(true), WithUserAgentPool([]string{"agent-a", "agent-b"}))
+
+	if _, err := ddg.Search(context.Background(), "q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(agents) != 1 || agents[0] != "agent-a" {
+		t.Fatalf("expected the first pooled agent, got %v", agents)
+	}
+}
+
+// ddgNoResultsPage is a lite page for a query with no results.
+const ddgNoResultsPage = `<html><body><table><tr><td class="no-results">No results.</td></tr></table></body></html>`
+
+func TestDuckDuckGoHTMLFallbackOnParseMiss(t *testing.T) {
+	pages := map[string]string{}
+	for host, file := range map[string]string{
+		"lite.duckduckgo.com": "testdata/ddg_lite_unparsed.html",
+		"html.duckduckgo.com": "testdata/ddg_html_results.html",
+	} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[host] = string(b)
+	}
+	var hosts []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		hosts = append(hosts, r.URL.Host)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(pages[r.URL.Host])), Request: r}, nil
+	})}
+	ddg := NewDuckDuckGoWithClient(client)
+
+	results, err := ddg.Search(context.Background(), "golang generics")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(hosts, ",") != "lite.duckduckgo.com,html.duckduckgo.com" {
+		t.Fatalf("requested %v, want lite then html", hosts)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	want := laconic.SearchResult{
+		Title:   "Tutorial: Getting started with generics",
+		URL:     "https://go.dev/doc/tutorial/generics",
+		Snippet: "This tutorial introduces the basics of generics in Go.",
+	}
+	if results[0] != want {
+		t.Errorf("first result = %+v, want %+v", results[0], want)
+	}
+	if results[1].URL != "https://go.dev/blog/intro-generics" {
+		t.Errorf("second URL = %q", results[1].URL)
+	}
+}
+
+func TestDuckDuckGoUserAgentAndFallbackOptions(t *testing.T) {
+	// A lite page in an unknown layout whose only usable link is external.
+	const lite = `<html><body><div><a href="https://go.dev/doc/">Go documentation</a></div></body></html>`
+	var hosts, agents []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		hosts = append(hosts, r.URL.Host)
+		agents = append(agents, r.Header.Get("User-Agent"))
+		body := lite
+		if r.URL.Host == "html.duckduckgo.com" {
+			body = "<html><body></body></html>"
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+
+	// The html endpoint is tried before the last-resort link scan.
+	results, err := NewDuckDuckGoWithClient(client, WithUserAgent("my-agent")).Search(context.Background(), "go docs")
+	if err != nil || len(results) != 1 || results[0].URL != "https://go.dev/doc/" {
+		t.Fatalf("got %+v, %v; want the scanned link", results, err)
+	}
+	if strings.Join(hosts, ",") != "lite.duckduckgo.com,html.duckduckgo.com" || strings.Join(agents, ",") != "my-agent,my-agent" {
+		t.Fatalf("requests went to %v with agents %v", hosts, agents)
+	}
+
+	hosts = nil
+	results, err = NewDuckDuckGoWithClient(client, WithHTMLFallback(false)).Search(context.Background(), "go docs")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("got %+v, %v; want the scanned link", results, err)
+	}
+	if len(hosts) != 1 {
+		t.Fatalf("requested %v, want only the lite page", hosts)
+	}
+}
+
+func TestDuckDuckGoNoResultsSkipsFallback(t *testing.T) {
+	requests := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(ddgNoResultsPage)), Request: r}, nil
+	})}
+	results, err := NewDuckDuckGoWithClient(client).Search(context.Background(), "xqzv nothing matches")
+	if err != nil || len(results) != 0 {
+		t.Fatalf("got %v, %v; want no results", results, err)
+	}
+	if requests != 1 {
+		t.Fatalf("made %d requests, want 1", requests)
+	}
+}
+
+func TestDuckDuckGoMaxRetries(t *testing.T) {
+	requests := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+	})}
+	ddg := NewDuckDuckGoWithClient(client, WithMaxRetries(0))
+	_, err := ddg.Search(context.Background(), "q")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestBackoffGivesUpAfterMaxRetries(t *testing.T) {
+	defer func(d time.Duration) { firstBackoff = d }(firstBackoff)
+	firstBackoff = time.Millisecond
+
+	requests := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+	})}
+	var retries int
+	p := NewInstrumented(NewTavilyWithClient("key", "", client), func(s CallStats) { retries = s.Retries })
+	_, err := p.Search(context.Background(), "q")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if requests != defaultMaxRetries+1 || retries != defaultMaxRetries {
+		t.Fatalf("made %d requests with %d retries, want %d and %d", requests, retries, defaultMaxRetries+1, defaultMaxRetries)
+	}
+}
+
+func TestBackoffRetriesListedStatuses(t *testing.T) {
+	defer func(d time.Duration) { firstBackoff = d }(firstBackoff)
+	firstBackoff = time.Millisecond
+
+	requests := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		if requests == 1 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Request: r}, nil
+	})}
+	newReq := func() (*http.Request, error) { return http.NewRequest(http.MethodGet, "https://example.com", nil) }
+
+	resp, err := doWithBackoff(context.Background(), client, "test", 1, newReq)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable || requests != 1 {
+		t.Fatalf("503 without retryOn: got %v, %v after %d requests", resp, err, requests)
+	}
+	resp.Body.Close()
+
+	requests = 0
+	resp, err = doWithBackoff(context.Background(), client, "test", 1, newReq, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	if err != nil || resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Fatalf("503 with retryOn: got %v, %v after %d requests", resp, err, requests)
+	}
+	resp.Body.Close()
+}
+
+func TestDuckDuckGoRateLimitSpacesRequests(t *testing.T) {
+	var times []time.Time
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		times = append(times, time.Now())
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(ddgNoResultsPage)), Request: r}, nil
+	})}
+	const interval = 200 * time.Millisecond
+	ddg := NewDuckDuckGoWithClient(client, WithRateLimit(interval), WithHTMLFallback(false))
+	for i := 0; i < 2; i++ {
+		if _, err := ddg.Search(context.Background(), "q"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(times) != 2 {
+		t.Fatalf("made %d requests, want 2", len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < interval-10*time.Millisecond || gap >= defaultDDGInterval {
+		t.Fatalf("requests %v apart, want about %v", gap, interval)
+	}
+
+	// Instances keep their own pace.
+	other := NewDuckDuckGoWithClient(client, WithRateLimit(interval), WithHTMLFallback(false))
+	start := time.Now()
+	if _, err := other.Search(context.Background(), "q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if waited := time.Since(start); waited >= interval/2 {
+		t.Fatalf("a fresh instance waited %v for another's limit", waited)
+	}
+}
+
+func TestGoogleRequest(t *testing.T) {
+	var got *http.Request
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		body := `{"items": [{"title": "Go", "link": "https://go.dev", "snippet": "The Go language"}]}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	results, err := NewGoogleWithClient("my-key", "my-cx", client).Search(context.Background(), "go lang")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := got.URL.Query()
+	if got.URL.Host != "www.googleapis.com" || q.Get("key") != "my-key" || q.Get("cx") != "my-cx" || q.Get("q") != "go lang" || q.Get("num") != "5" {
+		t.Fatalf("unexpected request %s", got.URL)
+	}
+	want := laconic.SearchResult{Title: "Go", URL: "https://go.dev", Snippet: "The Go language"}
+	if len(results) != 1 || results[0] != want {
+		t.Fatalf("results = %+v, want [%+v]", results, want)
+	}
+
+	if _, err := NewGoogleWithClient("my-key", " ", client).Search(context.Background(), "q"); err == nil || !strings.Contains(err.Error(), "cx") {
+		t.Fatalf("expected a missing cx error, got %v", err)
+	}
+}
+
+func TestBingRequest(t *testing.T) {
+	var got *http.Request
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		body := `{"webPages": {"value": [{"name": "Go", "url": "https://go.dev", "snippet": "The Go language"}]}}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	bing := NewBingWithMarket("my-key", "en-GB")
+	bing.client = client
+	results, err := bing.Search(context.Background(), "go lang")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := got.URL.Query()
+	if got.URL.Host != "api.bing.microsoft.com" || got.Header.Get("Ocp-Apim-Subscription-Key") != "my-key" ||
+		q.Get("q") != "go lang" || q.Get("count") != "5" || q.Get("mkt") != "en-GB" {
+		t.Fatalf("unexpected request %s", got.URL)
+	}
+	want := laconic.SearchResult{Title: "Go", URL: "https://go.dev", Snippet: "The Go language"}
+	if len(results) != 1 || results[0] != want {
+		t.Fatalf("results = %+v, want [%+v]", results, want)
+	}
+
+	if _, err := NewBingWithClient("my-key", client).Search(context.Background(), "q"); err != nil || got.URL.Query().Has("mkt") {
+		t.Fatalf("without a market: err = %v, request %s", err, got.URL)
+	}
+}
+
+func TestSerperRequest(t *testing.T) {
+	var got *http.Request
+	var sent map[string]any
+	body := `{
+		"answerBox": {"title": "Go", "answer": "Go 1.23\nreleased August 2024"},
+		"knowledgeGraph": {"title": "Go", "type": "Programming language", "description": "Go is a language."},
+		"organic": [
+			{"title": "Go", "link": "https://go.dev", "snippet": "The Go language"},
+			{"title": "Go blog", "link": "https://go.dev/blog", "snippet": "News"}
+		]}`
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		json.NewDecoder(r.Body).Decode(&sent) //nolint:errcheck
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	serper := NewSerperWithClient("my-key", client)
+	serper.MaxResults = 1
+	results, err := serper.Search(context.Background(), "go lang")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Method != http.MethodPost || got.URL.String() != "https://google.serper.dev/search" ||
+		got.Header.Get("X-API-KEY") != "my-key" || sent["q"] != "go lang" || sent["num"] != float64(1) {
+		t.Fatalf("unexpected request %s %s %v", got.Method, got.URL, sent)
+	}
+	want := laconic.SearchResult{Title: "Go", URL: "https://go.dev", Snippet: "The Go language"}
+	if len(results) != 1 || results[0] != want {
+		t.Fatalf("results = %+v, want [%+v]", results, want)
+	}
+
+	serper.IncludeAnswerBox = true
+	results, _ = serper.Search(context.Background(), "go lang")
+	if want := "Answer: Go 1.23 released August 2024 The Go language"; results[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", results[0].Snippet, want)
+	}
+
+	body = `{"knowledgeGraph": {"title": "Go", "type": "Programming language", "description": "Go is a language."},
+		"organic": [{"title": "Go", "link": "https://go.dev", "snippet": "The Go language"}]}`
+	results, _ = serper.Search(context.Background(), "go lang")
+	if want := "Go (Programming language): Go is a language. The Go language"; results[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", results[0].Snippet, want)
+	}
+}
+
+func TestWikipedia(t *testing.T) {
+	var got *http.Request
+	intro := "Paris is the capital and largest city of France. " + strings.Repeat("It is known for its museums and architecture. ", 20)
+	body := `{"batchcomplete": true, "query": {"pages": [
+		{"pageid": 3, "title": "Paris (disambiguation)", "index": 1, "extract": "Paris may refer to:", "pageprops": {"disambiguation": ""},
+		 "canonicalurl": "https://en.wikipedia.org/wiki/Paris_(disambiguation)"},
+		{"pageid": 2, "title": "Paris, Texas", "index": 3, "extract": "Paris is a city in Texas.",
+		 "canonicalurl": "https://en.wikipedia.org/wiki/Paris,_Texas"},
+		{"pageid": 1, "title": "Paris", "index": 2, "extract": "` + intro + `",
+		 "canonicalurl": "https://en.wikipedia.org/wiki/Paris", "fullurl": "https://en.wikipedia.org/wiki/Paris"}
+	]}}`
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	results, err := NewWikipediaWithClient("", client).Search(context.Background(), "paris")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := got.URL.Query()
+	if got.URL.Host != "en.wikipedia.org" || q.Get("gsrsearch") != "paris" || q.Get("gsrlimit") != "10" ||
+		q.Get("exintro") != "1" || got.Header.Get("User-Agent") == "" {
+		t.Fatalf("unexpected request %s", got.URL)
+	}
+	if len(results) != 2 || results[0].Title != "Paris" || results[1].Title != "Paris, Texas" {
+		t.Fatalf("results = %+v, want Paris then Paris, Texas without the disambiguation page", results)
+	}
+	if results[0].URL != "https://en.wikipedia.org/wiki/Paris" {
+		t.Fatalf("URL = %q, want the canonical article URL", results[0].URL)
+	}
+	if s := results[0].Snippet; !strings.HasPrefix(s, "Paris is the capital") || len(s) > maxWikipediaSnippet || !strings.HasSuffix(s, ".") {
+		t.Fatalf("snippet = %q, want the intro cut at a sentence", s)
+	}
+
+	if _, err := NewWikipediaWithClient("de", client).Search(context.Background(), "paris"); err != nil || got.URL.Host != "de.wikipedia.org" {
+		t.Fatalf("lang de: err = %v, host %s", err, got.URL.Host)
+	}
+}
+
+func TestArxiv(t *testing.T) {
+	feed, err := os.ReadFile("testdata/arxiv_feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *http.Request
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(feed))), Request: r}, nil
+	})}
+	results, err := NewArxivWithClient(client).Search(context.Background(), "transformer attention")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := got.URL.Query()
+	if got.URL.Host != "export.arxiv.org" || q.Get("search_query") != "all:transformer AND all:attention" || q.Get("max_results") != "5" {
+		t.Fatalf("unexpected request %s", got.URL)
+	}
+	want := laconic.SearchResult{
+		Title:       "Attention Is All You Need",
+		URL:         "http://arxiv.org/abs/1706.03762v7",
+		Snippet:     "The dominant sequence transduction models are based on complex recurrent or convolutional neural networks. We propose a new simple network architecture, the Transformer, based solely on attention mechanisms.",
+		PublishedAt: time.Date(2017, 6, 12, 17, 57, 34, 0, time.UTC),
+	}
+	if len(results) != 2 || results[0] != want || results[1].Title != "Efficient Transformers: A Survey" {
+		t.Fatalf("results = %+v, want [%+v, ...]", results, want)
+	}
+
+	if q := arxivQuery("au:vaswani AND ti:attention"); q != "au:vaswani AND ti:attention" {
+		t.Fatalf("arXiv syntax rewritten to %q", q)
+	}
+}
+
+func TestRateLimiterHonorsContext(t *testing.T) {
+	l := &rateLimiter{interval: time.Hour}
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("first slot: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if arxivRateLimit.interval != arxivInterval {
+		t.Fatalf("arXiv limiter spaces requests %v apart, want %v", arxivRateLimit.interval, arxivInterval)
+	}
+}
+
+func TestSearXNG(t *testing.T) {
+	var got *http.Request
+	status, body := http.StatusOK, `{"results": [{"title": "Go", "url": "https://go.dev", "content": "The Go language", "score": 2.5}]}`
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		got = r
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	sx := NewSearXNGWithClient("http://searx.local/", SearXNGOptions{Engines: []string{"google", "wikipedia"}, Language: "en"}, client)
+
+	results, err := sx.Search(context.Background(), "go lang")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := got.URL.Query()
+	if got.URL.Path != "/search" || q.Get("format") != "json" || q.Get("q") != "go lang" ||
+		q.Get("engines") != "google,wikipedia" || q.Get("language") != "en" || q.Has("categories") {
+		t.Fatalf("unexpected request %s", got.URL)
+	}
+	want := laconic.SearchResult{Title: "Go", URL: "https://go.dev", Snippet: "The Go language", Score: 2.5}
+	if len(results) != 1 || results[0] != want {
+		t.Fatalf("results = %+v, want [%+v]", results, want)
+	}
+
+	for _, tc := range []struct {
+		status int
+		body   string
+	}{
+		{http.StatusForbidden, "Forbidden"},
+		{http.StatusOK, "<!DOCTYPE html><html><body>results</body></html>"},
+	} {
+		status, body = tc.status, tc.body
+		if _, err := sx.Search(context.Background(), "q"); err == nil || !strings.Contains(err.Error(), "JSON output is disabled") {
+			t.Errorf("http %d %.20q: err = %v, want a JSON-disabled error", tc.status, tc.body, err)
+		}
+	}
+}
+
+func TestMarginaliaSerializesRequests(t *testing.T) {
+	var inFlight, maxInFlight int
+	var mu sync.Mutex
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		body := `{"results": [{"title": "t", "url": "https://example.com", "description": "d"}]}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	m := NewMarginaliaWithClient("serialize-test-key", client)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Search(context.Background(), "q"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxInFlight != 1 {
+		t.Fatalf("expected one request at a time, saw %d", maxInFlight)
+	}
+}
+
+func TestBraveSearchType(t *testing.T) {
+	var paths []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		body := `{"type": "news", "results": [{"title": "Launch", "url": "https://news.example.com/a", "description": "d", "page_age": "2024-03-05T08:30:00"}]}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+
+	news := NewBraveWithClient("brave-news-test-key", client, WithBraveSearchType("news"))
+	results, err := news.Search(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || results[0].URL != "https://news.example.com/a" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if want := time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC); !results[0].PublishedAt.Equal(want) {
+		t.Fatalf("PublishedAt = %v, want %v", results[0].PublishedAt, want)
+	}
+
+	video := NewBraveWithClient("brave-video-test-key", client, WithBraveSearchType("video"))
+	if _, err := video.Search(context.Background(), "q"); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	want := []string{"/res/v1/news/search", "/res/v1/videos/search"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestBraveClampsCount(t *testing.T) {
+	var count string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		count = r.URL.Query().Get("count")
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"web": {"results": []}}`)), Request: r}, nil
+	})}
+	brave := NewBraveWithClient("brave-count-test-key", client)
+	brave.MaxResults = 50
+	if _, err := brave.Search(context.Background(), "q"); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if count != "20" {
+		t.Fatalf("count = %s, want 20", count)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	statuses := map[string]int{}
+	var keys []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		key := r.Header.Get("X-Subscription-Token")
+		if key == "" {
+			var body struct {
+				APIKey string `json:"api_key"`
+			}
+			json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+			key = body.APIKey
+		}
+		keys = append(keys, key)
+		return &http.Response{StatusCode: statuses[key], Body: io.NopCloser(strings.NewReader("{}")), Request: r}, nil
+	})}
+	statuses["tavily-good"] = http.StatusOK
+	statuses["tavily-bad"] = http.StatusUnauthorized
+	statuses["tavily-quota"] = http.StatusTooManyRequests
+
+	if err := NewTavilyWithClient("tavily-good", "", client).Validate(context.Background()); err != nil {
+		t.Fatalf("accepted key: %v", err)
+	}
+	if err := NewTavilyWithClient("tavily-bad", "", client).Validate(context.Background()); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("rejected key: err = %v, want ErrInvalidAPIKey", err)
+	}
+	if err := NewTavilyWithClient("tavily-quota", "", client).Validate(context.Background()); err == nil || errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("rate-limited key: err = %v, want a non-key error", err)
+	}
+	if err := NewTavilyWithClient(" ", "", client).Validate(context.Background()); err == nil {
+		t.Fatal("missing key: expected an error")
+	}
+
+	// Every Brave key is checked, and the first rejection is reported.
+	statuses["brave-validate-good"] = http.StatusOK
+	statuses["brave-validate-bad"] = http.StatusForbidden
+	keys = nil
+	brave := NewBraveMultiKeyWithClient([]string{"brave-validate-good", "brave-validate-bad"}, client)
+	if err := brave.Validate(context.Background()); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("brave: err = %v, want ErrInvalidAPIKey", err)
+	}
+	if strings.Join(keys, ",") != "brave-validate-good,brave-validate-bad" {
+		t.Fatalf("brave validated keys %v", keys)
+	}
+
+	// Wrappers forward to the providers they hold.
+	meta := NewMeta(staticProvider{}, NewTavilyWithClient("tavily-good", "", client), NewInstrumented(NewTavilyWithClient("tavily-bad", "", client), nil))
+	if err := meta.Validate(context.Background()); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("meta: err = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestBraveMultiKeyRotation(t *testing.T) {
+	var used []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		key := r.Header.Get("X-Subscription-Token")
+		used = append(used, key)
+		if key == "rotation-key-2" {
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"web": {"results": []}}`)), Request: r}, nil
+	})}
+	// Blank keys are dropped.
+	brave := NewBraveMultiKeyWithClient([]string{"rotation-key-1", " ", "rotation-key-2", "rotation-key-3"}, client)
+	if brave.APIKey != "rotation-key-1" {
+		t.Fatalf("APIKey = %q, want the first key", brave.APIKey)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := brave.Search(context.Background(), "q"); err != nil {
+			t.Fatalf("search %d: %v", i+1, err)
+		}
+	}
+	// The second search starts at the next key and moves past the
+	// rate-limited one without waiting for it.
+	if want := "rotation-key-1,rotation-key-2,rotation-key-3"; strings.Join(used, ",") != want {
+		t.Fatalf("keys used = %v, want %s", used, want)
+	}
+}
+
+func TestTavilyRawContent(t *testing.T) {
+	var sent map[string]any
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		json.NewDecoder(r.Body).Decode(&sent) //nolint:errcheck
+		body := `{"results": [{"title": "Go", "url": "https://go.dev", "content": "snippet", "raw_content": "full page", "score": 0.9}]}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	tavily := NewTavilyWithClient("key", "", client)
+	results, err := tavily.Search(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if _, ok := sent["include_raw_content"]; ok {
+		t.Fatalf("raw content requested by default: %v", sent)
+	}
+	if len(results) != 1 || results[0].Snippet != "snippet" || results[0].Content != "full page" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	tavily.IncludeRawContent = true
+	if _, err := tavily.Search(context.Background(), "q"); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if sent["include_raw_content"] != true {
+		t.Fatalf("include_raw_content not sent: %v", sent)
+	}
+}
+
+// staticProvider returns a fixed result list.
+type staticProvider []laconic.SearchResult
+
+func (p staticProvider) Search(context.Context, string) ([]laconic.SearchResult, error) {
+	return p, nil
+}
+
+func TestMetaTitleSimilarity(t *testing.T) {
+	a := staticProvider{{Title: "Quake hits region, officials say", URL: "https://wire.example/quake", Score: 0.5}}
+	b := staticProvider{
+		{Title: "Quake hits region, officials say | Local News", URL: "https://local.example/story", Score: 0.8},
+		{Title: "Rebuilding begins", URL: "https://local.example/rebuild"},
+	}
+	meta := NewMetaWithStrategy(MergeByPriority, a, b)
+	meta.TitleSimilarity = 0.8
+	results, err := meta.Search(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 || results[0].URL != "https://local.example/story" || results[1].URL != "https://local.example/rebuild" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestInstrumentedReportsRetries(t *testing.T) {
+	calls := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+		}
+		body := `{"web": {"results": [{"title": "t", "url": "https://example.com", "description": "d"}]}}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+
+	// With two keys the retry moves straight to the second key's gate.
+	brave := NewBraveMultiKeyWithClient([]string{"instrumented-key-1", "instrumented-key-2"}, client)
+	var got []CallStats
+	p := NewInstrumented(brave, func(s CallStats) { got = append(got, s) })
+	if _, err := p.Search(context.Background(), "q"); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("OnSearch called %d times, want 1", len(got))
+	}
+	if s := got[0]; s.Query != "q" || s.Results != 1 || len(s.AllResults) != 1 || s.Retries != 1 || s.Err != nil || s.Waited > s.Duration {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestInstrumentedCopiesAllResults(t *testing.T) {
+	inner := searchFunc(func(context.Context, string) ([]laconic.SearchResult, error) {
+		return []laconic.SearchResult{{URL: "https://example.com/a"}}, nil
+	})
+	var got CallStats
+	results, err := NewInstrumented(inner, func(s CallStats) { got = s }).Search(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	// The agent tags results in place after the search returns.
+	results[0].Domain = "example.com"
+	if got.AllResults[0].Domain != "" {
+		t.Fatalf("AllResults shares the agent's slice: %+v", got.AllResults)
+	}
+}
+
+func TestDuckDuckGoDecodesRedirectLinks(t *testing.T) {
+	html := `<a rel="nofollow" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fwww.example.org%2Fnews%3Fid%3D7&amp;rut=abc123" class='result-link'>Example story</a>
+<td class='result-snippet'>snippet</td>
+<a rel="nofollow" href="https://direct.example.com/page" class='result-link'>Direct link</a>
+<td class='result-snippet'>snippet</td>`
+	results := parseHTMLResults(html, 5)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].URL != "https://www.example.org/news?id=7" {
+		t.Errorf("redirect URL = %q, want decoded destination", results[0].URL)
+	}
+	if results[1].URL != "https://direct.example.com/page" {
+		t.Errorf("direct URL = %q, want unchanged", results[1].URL)
+	}
+	if got := resolveDDGRedirect("https://html.duckduckgo.com/l/?uddg=https%3A%2F%2Fa.example"); got != "https://a.example" {
+		t.Errorf("subdomain redirect = %q, want decoded destination", got)
+	}
+	const lookalike = "https://evilduckduckgo.com/l/?uddg=https%3A%2F%2Fa.example"
+	if got := resolveDDGRedirect(lookalike); got != lookalike {
+		t.Errorf("lookalike host redirect = %q, want unchanged", got)
+	}
+}
+
+func TestRecorderReplay(t *testing.T) {
+	calls := 0
+	inner := searchFunc(func(_ context.Context, query string) ([]laconic.SearchResult, error) {
+		calls++
+		if query == "broken" {
+			return nil, fmt.Errorf("backend down")
+		}
+		return []laconic.SearchResult{{Title: query, URL: fmt.Sprintf("https://example.com/%d", calls), Snippet: "s"}}, nil
+	})
+
+	var buf strings.Builder
+	rec := NewRecorder(inner, &buf)
+	for _, q := range []string{"alpha", "alpha", "broken"} {
+		rec.Search(context.Background(), q)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("recorded %d lines, want 3:\n%s", lines, buf.String())
+	}
+
+	replay, err := NewReplay(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("NewReplay: %v", err)
+	}
+	var urls []string
+	for i := 0; i < 3; i++ {
+		results, err := replay.Search(context.Background(), "alpha")
+		if err != nil || len(results) != 1 {
+			t.Fatalf("replay alpha: %v %v", results, err)
+		}
+		urls = append(urls, results[0].URL)
+	}
+	if got := strings.Join(urls, " "); got != "https://example.com/1 https://example.com/2 https://example.com/2" {
+		t.Fatalf("replayed URLs = %s", got)
+	}
+	if _, err := replay.Search(context.Background(), "broken"); err == nil || err.Error() != "backend down" {
+		t.Fatalf("replayed error = %v", err)
+	}
+	if _, err := replay.Search(context.Background(), "unknown"); !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("unknown query error = %v", err)
+	}
+}
+
+// searchFunc adapts a function to laconic.SearchProvider.
+func TestFilterDomains(t *testing.T) {
+	inner := searchFunc(func(context.Context, string) ([]laconic.SearchResult, error) {
+		return []laconic.SearchResult{
+			{URL: "https://www.nasa.gov/missions"},
+			{URL: "https://data.census.gov/table"},
+			{URL: "https://pinterest.com/pin/1"},
+			{URL: "https://uk.pinterest.com/pin/2"},
+			{URL: "https://example.com/a"},
+			{URL: "https://notexample.com/b"},
+			{URL: "not a url"},
+		}, nil
+	})
+	urls := func(opts FilterOptions) string {
+		results, err := NewFilter(inner, opts).Search(context.Background(), "q")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.URL)
+		}
+		return strings.Join(got, " ")
+	}
+
+	if got := urls(FilterOptions{AllowDomains: []string{"*.gov"}}); got != "https://www.nasa.gov/missions https://data.census.gov/table" {
+		t.Errorf("allow *.gov: %s", got)
+	}
+	if got := urls(FilterOptions{DenyDomains: []string{"Pinterest.com"}}); got != "https://www.nasa.gov/missions https://data.census.gov/table https://example.com/a https://notexample.com/b not a url" {
+		t.Errorf("deny pinterest.com: %s", got)
+	}
+	if got := urls(FilterOptions{AllowDomains: []string{"example.com", "gov"}, DenyDomains: []string{"census.gov"}}); got != "https://www.nasa.gov/missions https://example.com/a" {
+		t.Errorf("allow and deny: %s", got)
+	}
+}
+
+func TestCacheLiteral(t *testing.T) {
+	calls := 0
+	inner := searchFunc(func(_ context.Context, query string) ([]laconic.SearchResult, error) {
+		calls++
+		return []laconic.SearchResult{{Title: query}}, nil
+	})
+	cache := &Cache{Provider: inner, TTL: time.Minute}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Search(context.Background(), "q"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("inner calls = %d, want 1", calls)
+	}
+}
+
+func TestCacheReusesResultsWithinTTL(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	inner := searchFunc(func(_ context.Context, query string) ([]laconic.SearchResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[query]++
+		return []laconic.SearchResult{{Title: query, URL: "https://example.com/" + query}}, nil
+	})
+	now := time.Unix(0, 0)
+	cache := NewCache(inner, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	first, err := cache.Search(context.Background(), "go lang")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first[0].Title = "mutated"
+	second, err := cache.Search(context.Background(), "  Go   LANG ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls["go lang"] != 1 || len(calls) != 1 {
+		t.Fatalf("inner calls = %v, want one for the first query", calls)
+	}
+	if second[0].Title != "go lang" {
+		t.Fatalf("cached result was mutated by a caller: %+v", second[0])
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := cache.Search(context.Background(), "go lang"); err != nil || calls["go lang"] != 2 {
+		t.Fatalf("expired entry should be searched again: err = %v, calls = %v", err, calls)
+	}
+
+	// With room for two queries, touching "go lang" makes "b" the least
+	// recently used one.
+	cache.MaxEntries = 2
+	var wg sync.WaitGroup
+	for _, q := range []string{"b", "b", "b"} {
+		wg.Add(1)
+		go func(q string) {
+			defer wg.Done()
+			cache.Search(context.Background(), q) //nolint:errcheck
+		}(q)
+	}
+	wg.Wait()
+	searchedB := calls["b"]
+	cache.Search(context.Background(), "go lang") //nolint:errcheck
+	cache.Search(context.Background(), "c")       //nolint:errcheck
+	cache.Search(context.Background(), "go lang") //nolint:errcheck
+	cache.Search(context.Background(), "b")       //nolint:errcheck
+	if calls["go lang"] != 2 || calls["c"] != 1 || calls["b"] != searchedB+1 {
+		t.Fatalf("inner calls = %v, want b evicted and go lang kept", calls)
+	}
+}
+
+func TestMetaRoundRobinDedupesOverlappingURLs(t *testing.T) {
+	a := staticProvider{
+		{Title: "A1", URL: "https://example.com/shared"},
+		{Title: "A2", URL: "https://a.example/2"},
+		{Title: "A3", URL: "https://a.example/3"},
+	}
+	b := staticProvider{
+		{Title: "B1", URL: "https://b.example/1"},
+		{Title: "B2", URL: "http://www.Example.com/shared/"},
+		{Title: "B3", URL: "https://b.example/3"},
+	}
+	meta := NewMeta(a, b)
+	meta.MaxResults = 10
+
+	results, err := meta.Search(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var titles []string
+	for _, r := range results {
+		titles = append(titles, r.Title)
+	}
+	if got := strings.Join(titles, ","); got != "A1,B1,A2,A3,B3" {
+		t.Fatalf("titles = %s, want round-robin order without B2's duplicate URL", got)
+	}
+
+	failing := searchFunc(func(context.Context, string) ([]laconic.SearchResult, error) {
+		return nil, errors.New("boom")
+	})
+	if results, err := NewMeta(failing, b).Search(context.Background(), "q"); err != nil || len(results) != 3 {
+		t.Fatalf("one failing provider: %d results, err %v; want b's results", len(results), err)
+	}
+	if _, err := NewMeta(failing, failing).Search(context.Background(), "q"); err == nil || !strings.Contains(err.Error(), "all providers failed") {
+		t.Fatalf("all failing: err = %v", err)
+	}
+}
+
+func TestMetaMergeStrategies(t *testing.T) {
+	a := staticProvider{
+		{Title: "A1", URL: "https://a.example/1", Score: 0.2},
+		{Title: "A2", URL: "https://a.example/2"},
+		{Title: "A3", URL: "https://shared.example/x", Score: 0.9},
+	}
+	b := staticProvider{
+		{Title: "B1", URL: "https://b.example/1", Score: 0.5},
+		{Title: "B2", URL: "https://shared.example/x/", Score: 0.7},
+	}
+	for _, tt := range []struct {
+		strategy MergeStrategy
+		limit    int
+		want     string
+	}{
+		// Unscored results sort last, keeping provider order; the shared
+		// URL keeps its highest-scored copy.
+		{MergeByScore, 10, "A3,B1,A1,A2"},
+		{MergeByScore, 2, "A3,B1"},
+		// Priority keeps all of a, then b without its duplicate.
+		{MergeByPriority, 10, "A1,A2,A3,B1"},
+		{MergeByPriority, 3, "A1,A2,A3"},
+		// Round-robin reaches B2 before A3, so B2 keeps the shared URL.
+		{MergeRoundRobin, 10, "A1,B1,A2,B2"},
+	} {
+		meta := NewMetaWithStrategy(tt.strategy, a, b)
+		meta.MaxResults = tt.limit
+		results, err := meta.Search(context.Background(), "q")
+		if err != nil {
+			t.Fatalf("strategy %d: %v", tt.strategy, err)
+		}
+		var titles []string
+		for _, r := range results {
+			titles = append(titles, r.Title)
+		}
+		if got := strings.Join(titles, ","); got != tt.want {
+			t.Errorf("strategy %d, limit %d: titles = %s, want %s", tt.strategy, tt.limit, got, tt.want)
+		}
+	}
+}
+
+type searchFunc func(ctx context.Context, query string) ([]laconic.SearchResult, error)
+
+func (f searchFunc) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	return f(ctx, query)
+}
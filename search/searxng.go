@@ -0,0 +1,132 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// errSearXNGNoJSON explains the most common SearXNG setup problem: the
+// instance only serves HTML.
+var errSearXNGNoJSON = errors.New(`searxng: JSON output is disabled on this instance; add "json" to search.formats in settings.yml`)
+
+// SearXNG queries a SearXNG metasearch instance, typically self-hosted, so
+// no API key is needed.
+type SearXNG struct {
+	// BaseURL is the instance root, such as "http://localhost:8888".
+	BaseURL string
+	client  *http.Client
+	opts    SearXNGOptions
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+}
+
+// SearXNGOptions narrows what a SearXNG instance searches. Empty fields use
+// the instance's defaults.
+type SearXNGOptions struct {
+	// Engines lists the engines to query, such as "google" or "wikipedia".
+	Engines []string
+	// Categories lists the categories to search, such as "general" or
+	// "science".
+	Categories []string
+	// Language is a language code such as "en" or "de-CH".
+	Language string
+}
+
+// NewSearXNG constructs a provider for the SearXNG instance at baseURL.
+func NewSearXNG(baseURL string) *SearXNG {
+	return NewSearXNGWithOptions(baseURL, SearXNGOptions{})
+}
+
+// NewSearXNGWithOptions constructs a SearXNG provider that restricts
+// searches to the given engines, categories, and language.
+func NewSearXNGWithOptions(baseURL string, opts SearXNGOptions) *SearXNG {
+	return NewSearXNGWithClient(baseURL, opts, &http.Client{Timeout: 15 * time.Second})
+}
+
+// NewSearXNGWithClient constructs a SearXNG provider with the given options
+// using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewSearXNGWithClient(baseURL string, opts SearXNGOptions, client *http.Client) *SearXNG {
+	return &SearXNG{BaseURL: baseURL, opts: opts, client: client}
+}
+
+// Search queries the instance's JSON API.
+func (s *SearXNG) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	base := strings.TrimRight(strings.TrimSpace(s.BaseURL), "/")
+	if base == "" {
+		return nil, errors.New("searxng: base URL is missing")
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+	if len(s.opts.Engines) > 0 {
+		params.Set("engines", strings.Join(s.opts.Engines, ","))
+	}
+	if len(s.opts.Categories) > 0 {
+		params.Set("categories", strings.Join(s.opts.Categories, ","))
+	}
+	if s.opts.Language != "" {
+		params.Set("language", s.opts.Language)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// SearXNG answers 403 when the requested format is not enabled.
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, errSearXNGNoJSON
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("searxng http %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("searxng: reading response: %w", err)
+	}
+	// Some setups fall back to the HTML results page instead.
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '<' {
+		return nil, errSearXNGNoJSON
+	}
+
+	var response struct {
+		Results []struct {
+			Title   string  `json:"title"`
+			URL     string  `json:"url"`
+			Content string  `json:"content"`
+			Score   float64 `json:"score"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("searxng: decoding response: %w", err)
+	}
+
+	limit := resultLimit(s.MaxResults)
+	results := make([]laconic.SearchResult, 0, min(len(response.Results), limit))
+	for _, r := range response.Results {
+		results = append(results, laconic.SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content, Score: r.Score})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
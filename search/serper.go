@@ -0,0 +1,163 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+const serperEndpoint = "https://google.serper.dev/search"
+
+// Serper calls the Serper.dev Google SERP API.
+type Serper struct {
+	APIKey string
+	client *http.Client
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+	// IncludeAnswerBox prepends a one-line summary of Google's answer box,
+	// or of its knowledge graph panel when there is no answer box, to the
+	// first result's snippet. The models then see the direct answer Google
+	// found alongside the organic results.
+	IncludeAnswerBox bool
+}
+
+// NewSerper constructs a Serper search provider.
+func NewSerper(apiKey string) *Serper {
+	return &Serper{APIKey: apiKey, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewSerperWithClient constructs a Serper search provider using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewSerperWithClient(apiKey string, client *http.Client) *Serper {
+	return &Serper{APIKey: apiKey, client: client}
+}
+
+// serperResponse is the part of a Serper response that is used.
+type serperResponse struct {
+	Organic []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+	} `json:"organic"`
+	AnswerBox *struct {
+		Title   string `json:"title"`
+		Answer  string `json:"answer"`
+		Snippet string `json:"snippet"`
+	} `json:"answerBox"`
+	KnowledgeGraph *struct {
+		Title       string `json:"title"`
+		Type        string `json:"type"`
+		Description string `json:"description"`
+	} `json:"knowledgeGraph"`
+}
+
+// Search posts a query to Serper.
+func (s *Serper) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	if strings.TrimSpace(s.APIKey) == "" {
+		return nil, errors.New("serper: API key is missing")
+	}
+
+	limit := resultLimit(s.MaxResults)
+	resp, err := doWithBackoff(ctx, s.client, "serper", defaultMaxRetries, func() (*http.Request, error) {
+		req, err := s.newRequest(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("serper http %d", resp.StatusCode)
+	}
+
+	var response serperResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	results := make([]laconic.SearchResult, 0, len(response.Organic))
+	for _, item := range response.Organic {
+		results = append(results, laconic.SearchResult{Title: item.Title, URL: item.Link, Snippet: item.Snippet})
+		if len(results) >= limit {
+			break
+		}
+	}
+	if s.IncludeAnswerBox && len(results) > 0 {
+		if summary := response.summary(); summary != "" {
+			results[0].Snippet = strings.TrimSpace(summary + " " + results[0].Snippet)
+		}
+	}
+	return results, nil
+}
+
+// summary returns one line from the answer box or knowledge graph, or ""
+// when the response has neither.
+func (r serperResponse) summary() string {
+	if box := r.AnswerBox; box != nil {
+		answer := strings.TrimSpace(box.Answer)
+		if answer == "" {
+			answer = strings.TrimSpace(box.Snippet)
+		}
+		if answer != "" {
+			return "Answer: " + oneLine(answer)
+		}
+	}
+	if kg := r.KnowledgeGraph; kg != nil && strings.TrimSpace(kg.Description) != "" {
+		title := strings.TrimSpace(kg.Title)
+		if t := strings.TrimSpace(kg.Type); t != "" {
+			title += " (" + t + ")"
+		}
+		return oneLine(title + ": " + kg.Description)
+	}
+	return ""
+}
+
+// oneLine collapses whitespace, newlines included, to single spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Validate issues a single one-result query to check that the API key is
+// accepted. Note that this consumes one Serper credit. It returns an error
+// wrapping ErrInvalidAPIKey on 401/403.
+func (s *Serper) Validate(ctx context.Context) error {
+	if strings.TrimSpace(s.APIKey) == "" {
+		return errors.New("serper: API key is missing")
+	}
+	req, err := s.newRequest(ctx, "test", 1)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return checkValidateStatus("serper", resp.StatusCode)
+}
+
+// newRequest builds the request for query, asking for num results.
+func (s *Serper) newRequest(ctx context.Context, query string, num int) (*http.Request, error) {
+	payload, err := json.Marshal(map[string]any{"q": query, "num": num})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serperEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", strings.TrimSpace(s.APIKey))
+	return req, nil
+}
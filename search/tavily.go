@@ -19,6 +19,11 @@ type Tavily struct {
 	client *http.Client
 	// Depth controls Tavily's depth parameter (basic or advanced).
 	Depth string
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+	// IncludeRawContent asks Tavily for the full page text of each result,
+	// returned in SearchResult.Content. Responses become much larger.
+	IncludeRawContent bool
 }
 
 // NewTavily constructs a Tavily search provider.
@@ -44,10 +49,15 @@ func (t *Tavily) Search(ctx context.Context, query string) ([]laconic.SearchResu
 		return nil, errors.New("tavily: API key is missing")
 	}
 
+	limit := resultLimit(t.MaxResults)
 	body := map[string]any{
-		"query":   query,
-		"api_key": t.APIKey,
-		"depth":   t.Depth,
+		"query":       query,
+		"api_key":     t.APIKey,
+		"depth":       t.Depth,
+		"max_results": limit,
+	}
+	if t.IncludeRawContent {
+		body["include_raw_content"] = true
 	}
 
 	payload, err := json.Marshal(body)
@@ -55,34 +65,16 @@ func (t *Tavily) Search(ctx context.Context, query string) ([]laconic.SearchResu
 		return nil, err
 	}
 
-	var resp *http.Response
-	delay := 1 * time.Second
-	for {
+	resp, err := doWithBackoff(ctx, t.client, "tavily", defaultMaxRetries, func() (*http.Request, error) {
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.tavily.com/search", bytes.NewReader(payload))
 		if err != nil {
 			return nil, err
 		}
 		req.Header.Set("Content-Type", "application/json")
-
-		resp, err = t.client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-
-		if resp.StatusCode != http.StatusTooManyRequests {
-			break
-		}
-		resp.Body.Close()
-
-		// Back off and retry on 429, doubling the delay each time up to 30 s.
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-time.After(delay):
-		}
-		if delay < 30*time.Second {
-			delay *= 2
-		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -92,9 +84,11 @@ func (t *Tavily) Search(ctx context.Context, query string) ([]laconic.SearchResu
 
 	var response struct {
 		Results []struct {
-			Title   string `json:"title"`
-			URL     string `json:"url"`
-			Content string `json:"content"`
+			Title      string  `json:"title"`
+			URL        string  `json:"url"`
+			Content    string  `json:"content"`
+			RawContent string  `json:"raw_content"`
+			Score      float64 `json:"score"`
 		} `json:"results"`
 	}
 
@@ -104,10 +98,40 @@ func (t *Tavily) Search(ctx context.Context, query string) ([]laconic.SearchResu
 
 	results := make([]laconic.SearchResult, 0, len(response.Results))
 	for _, r := range response.Results {
-		results = append(results, laconic.SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
-		if len(results) >= 5 {
+		results = append(results, laconic.SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content, Content: r.RawContent, Score: r.Score})
+		if len(results) >= limit {
 			break
 		}
 	}
 	return results, nil
 }
+
+// Validate issues a single one-result basic query to check that the API key
+// is accepted. Note that this consumes one Tavily request credit. It returns
+// an error wrapping ErrInvalidAPIKey on 401/403.
+func (t *Tavily) Validate(ctx context.Context) error {
+	if strings.TrimSpace(t.APIKey) == "" {
+		return errors.New("tavily: API key is missing")
+	}
+	payload, err := json.Marshal(map[string]any{
+		"query":       "test",
+		"api_key":     t.APIKey,
+		"depth":       "basic",
+		"max_results": 1,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.tavily.com/search", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return checkValidateStatus("tavily", resp.StatusCode)
+}
@@ -0,0 +1,25 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrInvalidAPIKey is wrapped by Validate when a provider rejects its
+// credentials.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// checkValidateStatus maps the HTTP status of a validation request to an error.
+func checkValidateStatus(name string, status int) error {
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%s: %w (http %d)", name, ErrInvalidAPIKey, status)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%s: rate limited or quota exhausted (http %d)", name, status)
+	default:
+		return fmt.Errorf("%s http %d", name, status)
+	}
+}
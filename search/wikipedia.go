@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smhanov/laconic"
+)
+
+// wikipediaUserAgent identifies the client, as the Wikimedia API policy
+// asks of every caller.
+const wikipediaUserAgent = "laconic/1.0 (https://github.com/smhanov/laconic)"
+
+// maxWikipediaSnippet caps the article intro kept as a result's snippet.
+const maxWikipediaSnippet = 600
+
+// wikipediaMaxExtracts is the most pages the API returns intro extracts for
+// in one request.
+const wikipediaMaxExtracts = 20
+
+// Wikipedia searches Wikipedia through the MediaWiki API. No API key is
+// needed. Each result's snippet is the opening of the article's intro, and
+// disambiguation pages are left out in favour of concrete articles.
+type Wikipedia struct {
+	// Lang is the Wikipedia language edition, such as "en" or "de".
+	Lang   string
+	client *http.Client
+	// MaxResults caps the results returned per search (default 5).
+	MaxResults int
+}
+
+// NewWikipedia constructs a Wikipedia search provider for the language
+// edition lang ("en" when empty).
+func NewWikipedia(lang string) *Wikipedia {
+	return &Wikipedia{Lang: lang, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewWikipediaWithClient constructs a Wikipedia search provider using the supplied HTTP client.
+// This is useful for overriding the default timeout.
+func NewWikipediaWithClient(lang string, client *http.Client) *Wikipedia {
+	return &Wikipedia{Lang: lang, client: client}
+}
+
+// Search runs a full-text search and returns the matching articles with
+// their intros. It asks for extra matches so that skipping disambiguation
+// pages still leaves MaxResults articles.
+func (w *Wikipedia) Search(ctx context.Context, query string) ([]laconic.SearchResult, error) {
+	limit := resultLimit(w.MaxResults)
+	endpoint := w.endpoint(query, min(2*limit, wikipediaMaxExtracts))
+
+	resp, err := doWithBackoff(ctx, w.client, "wikipedia", defaultMaxRetries, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", wikipediaUserAgent)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wikipedia http %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Error *struct {
+			Info string `json:"info"`
+		} `json:"error"`
+		Query struct {
+			Pages []struct {
+				Title        string            `json:"title"`
+				Index        int               `json:"index"`
+				Extract      string            `json:"extract"`
+				CanonicalURL string            `json:"canonicalurl"`
+				FullURL      string            `json:"fullurl"`
+				PageProps    map[string]string `json:"pageprops"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("wikipedia: %s", response.Error.Info)
+	}
+
+	// Generator results come back keyed by page, not in rank order.
+	pages := response.Query.Pages
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].Index < pages[j].Index })
+
+	results := make([]laconic.SearchResult, 0, limit)
+	for _, p := range pages {
+		if _, ok := p.PageProps["disambiguation"]; ok {
+			continue
+		}
+		u := p.CanonicalURL
+		if u == "" {
+			u = p.FullURL
+		}
+		results = append(results, laconic.SearchResult{Title: p.Title, URL: u, Snippet: wikipediaIntro(p.Extract)})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// endpoint returns the API URL that searches for query and returns up to n
+// pages with their plain-text intros, canonical URLs and disambiguation flag.
+func (w *Wikipedia) endpoint(query string, n int) string {
+	lang := strings.ToLower(strings.TrimSpace(w.Lang))
+	if lang == "" {
+		lang = "en"
+	}
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	params.Set("generator", "search")
+	params.Set("gsrsearch", query)
+	params.Set("gsrlimit", strconv.Itoa(n))
+	params.Set("prop", "extracts|info|pageprops")
+	params.Set("exintro", "1")
+	params.Set("explaintext", "1")
+	params.Set("exlimit", "max")
+	params.Set("inprop", "url")
+	params.Set("ppprop", "disambiguation")
+	params.Set("redirects", "1")
+	return "https://" + url.PathEscape(lang) + ".wikipedia.org/w/api.php?" + params.Encode()
+}
+
+// wikipediaIntro shortens an article intro to at most maxWikipediaSnippet
+// characters, ending at a sentence where possible.
+func wikipediaIntro(extract string) string {
+	s := strings.Join(strings.Fields(extract), " ")
+	if len(s) <= maxWikipediaSnippet {
+		return s
+	}
+	s = s[:maxWikipediaSnippet]
+	if i := strings.LastIndex(s, ". "); i > maxWikipediaSnippet/2 {
+		return s[:i+1]
+	}
+	if i := strings.LastIndexByte(s, ' '); i > 0 {
+		s = s[:i]
+	}
+	return s + "…"
+}
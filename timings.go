@@ -0,0 +1,76 @@
+package laconic
+
+import (
+	"context"
+	"time"
+)
+
+// Timings reports where a run spent its time. Search, Fetch and LLM sum the
+// time spent inside each call; calls that ran concurrently (parallel
+// searches, page reads, condensation) each count in full, so together they
+// can exceed Total.
+type Timings struct {
+	// Total is the wall time of the whole run, including answer validation
+	// and summaries.
+	Total time.Duration
+	// Search is the time spent in SearchProvider.Search, including any
+	// rate-limit waits inside the provider.
+	Search time.Duration
+	// Fetch is the time spent in FetchProvider.Fetch.
+	Fetch time.Duration
+	// LLM is the time spent in model calls.
+	LLM time.Duration
+	// Stages splits LLM by stage ("planner", "synthesizer", "finalizer",
+	// "extractor", ...), the same keys as Result.CostBreakdown.
+	Stages map[string]time.Duration
+	// Searches, Fetches and LLMCalls count the calls timed above.
+	Searches int
+	Fetches  int
+	LLMCalls int
+}
+
+const (
+	// timingSearch and timingFetch are the addRunTiming phases for search
+	// and fetch calls; every other phase is a model stage.
+	timingSearch = "search"
+	timingFetch  = "fetch"
+)
+
+// addRunTiming records one call of phase that took d.
+func addRunTiming(ctx context.Context, phase string, d time.Duration) {
+	rs := runStateFrom(ctx)
+	if rs == nil {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	t := &rs.timings
+	switch phase {
+	case timingSearch:
+		t.Search += d
+		t.Searches++
+	case timingFetch:
+		t.Fetch += d
+		t.Fetches++
+	default:
+		t.LLM += d
+		t.LLMCalls++
+		if t.Stages == nil {
+			t.Stages = make(map[string]time.Duration)
+		}
+		t.Stages[phase] += d
+	}
+}
+
+// runTimings returns the run's timings, with Total measured up to now.
+func runTimings(ctx context.Context) Timings {
+	rs := runStateFrom(ctx)
+	if rs == nil {
+		return Timings{}
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	t := rs.timings
+	t.Total = time.Since(rs.start)
+	return t
+}
@@ -0,0 +1,85 @@
+package laconic
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultTimings(t *testing.T) {
+	script := &scriptedLLM{
+		planner: []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+		synth:   []string{"The sky is blue."},
+		final:   []string{"Blue."},
+	}
+	llm := llmFunc(func(ctx context.Context, systemPrompt, userPrompt string) (LLMResponse, error) {
+		time.Sleep(5 * time.Millisecond)
+		return script.Generate(ctx, systemPrompt, userPrompt)
+	})
+	searcher := searchFunc(func(context.Context, string) ([]SearchResult, error) {
+		time.Sleep(30 * time.Millisecond)
+		return []SearchResult{{Title: "Sky", URL: "https://example.com", Snippet: "The sky is blue."}}, nil
+	})
+	agent := New(WithPlannerModel(llm), WithSynthesizerModel(llm), WithSearchProvider(searcher))
+	res, err := agent.Answer(context.Background(), "Why is the sky blue?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tm := res.Timings
+	if tm.Searches != 1 || tm.Search < 30*time.Millisecond {
+		t.Fatalf("search: %d calls in %v, want 1 call of at least 30ms", tm.Searches, tm.Search)
+	}
+	if tm.LLMCalls != 4 || tm.LLM < 20*time.Millisecond {
+		t.Fatalf("LLM: %d calls in %v, want 4 calls of at least 5ms each", tm.LLMCalls, tm.LLM)
+	}
+	var stages time.Duration
+	for _, stage := range []string{stagePlanner, stageSynthesizer, stageFinalizer} {
+		if tm.Stages[stage] == 0 {
+			t.Fatalf("no time recorded for %s in %v", stage, tm.Stages)
+		}
+	}
+	for _, d := range tm.Stages {
+		stages += d
+	}
+	if stages != tm.LLM {
+		t.Fatalf("stages sum to %v, LLM is %v", stages, tm.LLM)
+	}
+	if tm.Total < tm.Search+tm.LLM || tm.Fetches != 0 {
+		t.Fatalf("total %v should cover sequential search %v and LLM %v; fetches = %d", tm.Total, tm.Search, tm.LLM, tm.Fetches)
+	}
+}
+
+func TestResultTimingsCountFetches(t *testing.T) {
+	script := defaultGraphScript()
+	script.extract = func(user string) string {
+		if strings.Contains(user, "Full page") {
+			return `{"new_facts": [{"content": "Paris is the capital of France"}]}`
+		}
+		return `{"new_facts": [], "read_more_urls": ["https://example.com/page"]}`
+	}
+	llm := script.llm()
+	fetcher := fetchFunc(func(context.Context, string) (string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return "Full page. " + strings.Repeat("Paris is the capital of France. ", 10), nil
+	})
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com/page", Snippet: "s"}}}),
+		WithFetchProvider(fetcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{MaxSteps: 1}),
+	)
+	res, err := agent.Answer(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Timings.Fetches != 1 || res.Timings.Fetch < 20*time.Millisecond {
+		t.Fatalf("fetch: %d calls in %v, want 1 call of at least 20ms", res.Timings.Fetches, res.Timings.Fetch)
+	}
+	if res.Timings.Stages[stageExtractor] == 0 {
+		t.Fatalf("no extractor time in %v", res.Timings.Stages)
+	}
+}
@@ -0,0 +1,69 @@
+package laconic
+
+import "unicode/utf8"
+
+// charsPerToken is the rough characters-per-token ratio used to estimate
+// token counts when no tokenizer is configured with WithTokenizer.
+const charsPerToken = 4
+
+// countTokens returns the number of tokens in s according to the configured
+// tokenizer, or a chars/4 estimate.
+func (a *Agent) countTokens(s string) int {
+	if a.tokenizer != nil {
+		return a.tokenizer(s)
+	}
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// truncateTokens returns the longest prefix of s, ending on a rune boundary,
+// that fits within limit tokens.
+func (a *Agent) truncateTokens(s string, limit int) string {
+	return s[:a.fitTokens(s, limit)]
+}
+
+// fitTokens returns the length of the longest prefix of s, ending on a rune
+// boundary, that fits within limit tokens. The first prefix tried is the
+// chars/4 size of limit tokens, doubled until it no longer fits, so the
+// tokenizer sees text in proportion to the prefix rather than all of s.
+func (a *Agent) fitTokens(s string, limit int) int {
+	lo, hi := 0, max(limit*charsPerToken, 1)
+	for hi < len(s) && a.countTokens(s[:hi]) <= limit {
+		lo, hi = hi, hi*2
+	}
+	if hi >= len(s) {
+		if a.countTokens(s) <= limit {
+			return len(s)
+		}
+		hi = len(s)
+	}
+	// s[:lo] fits and s[:hi] does not.
+	hi--
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if a.countTokens(s[:mid]) <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	for lo > 0 && lo < len(s) && !utf8.RuneStart(s[lo]) {
+		lo--
+	}
+	return lo
+}
+
+// splitTokens cuts s into consecutive chunks of at most limit tokens each.
+func (a *Agent) splitTokens(s string, limit int) []string {
+	var chunks []string
+	for s != "" {
+		n := a.fitTokens(s, limit)
+		if n == 0 {
+			// A single rune exceeds the limit; take it anyway so the loop
+			// always makes progress.
+			_, n = utf8.DecodeRuneInString(s)
+		}
+		chunks = append(chunks, s[:n])
+		s = s[n:]
+	}
+	return chunks
+}
@@ -0,0 +1,57 @@
+package laconic
+
+import "context"
+
+// Tracer starts a span for one step of a run, so laconic shows up inside a
+// larger traced request. StartSpan returns the context for the step's work
+// and a function that ends the span; it is called exactly once, with the
+// step's attributes. Adapt it to OpenTelemetry or any other tracing library
+// and install it with WithTracer.
+//
+// Spans are named laconic.answer for the whole run, laconic.search and
+// laconic.fetch for provider calls, and laconic.plan, laconic.synthesize,
+// laconic.finalize and so on for model calls. Model spans carry
+// laconic.cost, laconic.prompt_tokens and laconic.completion_tokens (counted
+// with the WithTokenizer function, or estimated). Failed steps carry an
+// "error" attribute.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, func(attrs map[string]any))
+}
+
+const (
+	spanAnswer = "laconic.answer"
+	spanSearch = "laconic.search"
+	spanFetch  = "laconic.fetch"
+)
+
+// stageSpans names the span for each model-calling stage.
+var stageSpans = map[string]string{ //nolint:gochecknoglobals
+	stagePlanner:     "laconic.plan",
+	stageSynthesizer: "laconic.synthesize",
+	stageFinalizer:   "laconic.finalize",
+	stageReformulate: "laconic.reformulate",
+	stageExtractor:   "laconic.extract",
+	stageNeighbor:    "laconic.neighbors",
+	stageAnswerCheck: "laconic.answer_check",
+	stageCondense:    "laconic.condense",
+	stageValidator:   "laconic.validate",
+	stageProgressive: "laconic.progressive",
+	stageSummary:     "laconic.summarize",
+	stageJSONRepair:  "laconic.json_repair",
+}
+
+// startSpan starts a span with the configured Tracer, or does nothing.
+func (a *Agent) startSpan(ctx context.Context, name string) (context.Context, func(map[string]any)) {
+	if a.tracer == nil {
+		return ctx, func(map[string]any) {}
+	}
+	return a.tracer.StartSpan(ctx, name)
+}
+
+// withError adds err to attrs when it is non-nil.
+func withError(attrs map[string]any, err error) map[string]any {
+	if err != nil {
+		attrs["error"] = err.Error()
+	}
+	return attrs
+}
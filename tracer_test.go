@@ -0,0 +1,65 @@
+package laconic
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type span struct {
+	name   string
+	parent string
+	attrs  map[string]any
+}
+
+type spanKey struct{}
+
+// recordingTracer records ended spans with the name of their parent span.
+type recordingTracer struct{ spans []span }
+
+func (r *recordingTracer) StartSpan(ctx context.Context, name string) (context.Context, func(map[string]any)) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	return context.WithValue(ctx, spanKey{}, name), func(attrs map[string]any) {
+		r.spans = append(r.spans, span{name: name, parent: parent, attrs: attrs})
+	}
+}
+
+func TestTracerSpans(t *testing.T) {
+	llm := &scriptedLLM{
+		planner:     []string{"Action: Search\nQuery: sky color", "Action: Answer"},
+		synth:       []string{"The sky is blue."},
+		final:       []string{"Blue."},
+		costPerCall: 0.01,
+	}
+	tracer := &recordingTracer{}
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(fakeSearch{results: []SearchResult{{Title: "t", URL: "u", Snippet: "s"}}}),
+		WithTracer(tracer),
+	)
+	if _, err := agent.Answer(context.Background(), "Why is the sky blue?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, s := range tracer.spans {
+		names = append(names, s.name)
+		if s.name != spanAnswer && s.parent != spanAnswer {
+			t.Fatalf("%s should be a child of %s, got parent %q", s.name, spanAnswer, s.parent)
+		}
+	}
+	want := "laconic.plan,laconic.search,laconic.synthesize,laconic.plan,laconic.finalize,laconic.answer"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("spans = %s, want %s", got, want)
+	}
+	if s := tracer.spans[1]; s.attrs["laconic.query"] != "sky color" || s.attrs["laconic.results"] != 1 {
+		t.Fatalf("search attrs = %v", s.attrs)
+	}
+	if s := tracer.spans[0]; s.attrs["laconic.cost"] != 0.01 || s.attrs["laconic.prompt_tokens"].(int) <= 0 {
+		t.Fatalf("plan attrs = %v", s.attrs)
+	}
+	if s := tracer.spans[5]; s.attrs["laconic.stop_reason"] != "answered" || s.attrs["laconic.strategy"] != "scratchpad" {
+		t.Fatalf("answer attrs = %v", s.attrs)
+	}
+}
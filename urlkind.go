@@ -0,0 +1,66 @@
+package laconic
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// URLKind classifies a link by the type of document it likely points to.
+type URLKind string
+
+const (
+	// URLKindHTML is a regular web page. Links without a recognized document
+	// extension are assumed to be HTML.
+	URLKindHTML URLKind = "html"
+	// URLKindPDF is a PDF document.
+	URLKindPDF URLKind = "pdf"
+	// URLKindDoc is an office document (Word, Excel, PowerPoint, ODF, RTF).
+	URLKindDoc URLKind = "doc"
+)
+
+var docExtensions = map[string]bool{ //nolint:gochecknoglobals
+	".doc": true, ".docx": true, ".odt": true, ".rtf": true,
+	".xls": true, ".xlsx": true, ".ods": true,
+	".ppt": true, ".pptx": true, ".odp": true,
+}
+
+// InferURLKind guesses the document type of rawURL from its path extension.
+func InferURLKind(rawURL string) URLKind {
+	p := rawURL
+	if u, err := url.Parse(strings.TrimSpace(rawURL)); err == nil {
+		p = u.Path
+	}
+	ext := strings.ToLower(path.Ext(p))
+	switch {
+	case ext == ".pdf":
+		return URLKindPDF
+	case docExtensions[ext]:
+		return URLKindDoc
+	default:
+		return URLKindHTML
+	}
+}
+
+// URLDomain returns the lowercase host of rawURL without a leading "www.",
+// or "" when rawURL has no host.
+func URLDomain(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// tagResults fills in the Kind and Domain of every result that the provider
+// left unset.
+func tagResults(results []SearchResult) {
+	for i := range results {
+		if results[i].Kind == "" {
+			results[i].Kind = InferURLKind(results[i].URL)
+		}
+		if results[i].Domain == "" {
+			results[i].Domain = URLDomain(results[i].URL)
+		}
+	}
+}
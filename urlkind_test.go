@@ -0,0 +1,73 @@
+package laconic
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInferURLKind(t *testing.T) {
+	cases := map[string]URLKind{
+		"https://example.com/":                     URLKindHTML,
+		"https://example.com/article":              URLKindHTML,
+		"https://example.com/page.html":            URLKindHTML,
+		"https://example.com/paper.PDF":            URLKindPDF,
+		"https://example.com/paper.pdf?download=1": URLKindPDF,
+		"https://example.com/report.docx":          URLKindDoc,
+		"https://example.com/slides.pptx#p2":       URLKindDoc,
+	}
+	for u, want := range cases {
+		if got := InferURLKind(u); got != want {
+			t.Errorf("InferURLKind(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
+
+func TestURLDomain(t *testing.T) {
+	cases := map[string]string{
+		"https://www.Example.com/a":     "example.com",
+		"http://news.example.com:8080/": "news.example.com",
+		"example.com/no-scheme":         "",
+		"":                              "",
+	}
+	for u, want := range cases {
+		if got := URLDomain(u); got != want {
+			t.Errorf("URLDomain(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
+
+type fetchFunc func(ctx context.Context, url string) (string, error)
+
+func (f fetchFunc) Fetch(ctx context.Context, url string) (string, error) { return f(ctx, url) }
+
+func TestSkipNonHTMLSkipsDocumentFetches(t *testing.T) {
+	script := defaultGraphScript()
+	script.extract = func(string) string {
+		return `{"new_facts": [{"content": "Paris is the capital of France"}],
+			"read_more_urls": ["https://example.com/report.pdf", "https://example.com/page"]}`
+	}
+	llm := script.llm()
+	var fetched []string
+	fetcher := fetchFunc(func(_ context.Context, url string) (string, error) {
+		fetched = append(fetched, url)
+		return "", nil
+	})
+	searcher := fakeSearch{results: []SearchResult{{Title: "t", URL: "https://example.com/page", Snippet: "s"}}}
+
+	agent := New(
+		WithPlannerModel(llm),
+		WithSynthesizerModel(llm),
+		WithSearchProvider(searcher),
+		WithFetchProvider(fetcher),
+		WithStrategyName("graph-reader"),
+		WithGraphReaderConfig(GraphReaderConfig{MaxSteps: 1}),
+		WithSkipNonHTML(true),
+	)
+
+	if _, err := agent.Answer(context.Background(), "What is the capital of France?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fetched) != 1 || fetched[0] != "https://example.com/page" {
+		t.Fatalf("expected only the HTML page to be fetched, got %v", fetched)
+	}
+}